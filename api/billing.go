@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"fmt"
+)
+
+// Balance is the account's credit and what it is spending right now.
+type Balance struct {
+	ClientBalance     float64 `json:"clientBalance"`
+	CurrentSpendPerHr float64 `json:"currentSpendPerHr"`
+	SpendLimit        float64 `json:"spendLimit"`
+}
+
+func (c *Client) GetBalance(ctx context.Context) (*Balance, error) {
+	input := Input{
+		Query: `
+		query myBalance {
+			myself {
+			  clientBalance
+			  currentSpendPerHr
+			  spendLimit
+			}
+		  }
+		`,
+	}
+	var data struct {
+		Myself *Balance
+	}
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
+	}
+	if data.Myself == nil {
+		return nil, fmt.Errorf("myself is nil")
+	}
+	return data.Myself, nil
+}
+
+type BillingInput struct {
+	Granularity string `json:"granularity"`
+	StartTime   string `json:"startTime"`
+	EndTime     string `json:"endTime,omitempty"`
+}
+
+// BillingRecord is what the account was charged in one period, by product.
+type BillingRecord struct {
+	Time             string  `json:"time"`
+	GpuCloudAmount   float64 `json:"gpuCloudAmount"`
+	ServerlessAmount float64 `json:"serverlessAmount"`
+	StorageAmount    float64 `json:"storageAmount"`
+}
+
+// Total is the amount charged in the period across products.
+func (r *BillingRecord) Total() float64 {
+	return r.GpuCloudAmount + r.ServerlessAmount + r.StorageAmount
+}
+
+// GetBilling returns the account charges between the input times, one record
+// per HOURLY or DAILY period, oldest first.
+func (c *Client) GetBilling(ctx context.Context, in *BillingInput) ([]*BillingRecord, error) {
+	input := Input{
+		Query: `
+		query myBilling($input: UserBillingInput!) {
+			myself {
+			  billing(input: $input) {
+				summary {
+				  time
+				  gpuCloudAmount
+				  serverlessAmount
+				  storageAmount
+				}
+			  }
+			}
+		  }
+		`,
+		Variables: map[string]interface{}{"input": in},
+	}
+	var data struct {
+		Myself *struct {
+			Billing *struct {
+				Summary []*BillingRecord
+			}
+		}
+	}
+	err := c.Query(ctx, input, &data)
+	if data.Myself == nil || data.Myself.Billing == nil {
+		if err == nil {
+			err = fmt.Errorf("billing is nil")
+		}
+		return nil, total(err)
+	}
+	return data.Myself.Billing.Summary, err
+}
+
+// HoursPerMonth is the month monthly prices are given for, as on the
+// runpod.io pricing page.
+const HoursPerMonth = 730
+
+// StorageRates are the published prices of pod disks per GB and month, while
+// the pod runs and once it is stopped. A stopped pod keeps paying for the
+// disks it keeps.
+type StorageRates struct {
+	ContainerDiskPerGbMonth        float64 `json:"containerDiskPerGbMonth"`
+	VolumePerGbMonth               float64 `json:"volumePerGbMonth"`
+	StoppedContainerDiskPerGbMonth float64 `json:"stoppedContainerDiskPerGbMonth"`
+	StoppedVolumePerGbMonth        float64 `json:"stoppedVolumePerGbMonth"`
+}
+
+func (c *Client) GetStorageRates(ctx context.Context) (*StorageRates, error) {
+	input := Input{
+		Query: `
+		query storageRates {
+			storageRates {
+			  containerDiskPerGbMonth
+			  volumePerGbMonth
+			  stoppedContainerDiskPerGbMonth
+			  stoppedVolumePerGbMonth
+			}
+		  }
+		`,
+	}
+	var data struct {
+		StorageRates *StorageRates
+	}
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
+	}
+	if data.StorageRates == nil {
+		return nil, fmt.Errorf("storageRates is nil")
+	}
+	return data.StorageRates, nil
+}
+
+// PodPerHr is what the pod's container disk and volume cost per hour in its
+// current state. Network volumes are billed on their own and left out.
+func (r *StorageRates) PodPerHr(p *Pod) float64 {
+	container, volume := r.ContainerDiskPerGbMonth, r.VolumePerGbMonth
+	if p.DesiredStatus != "RUNNING" {
+		container, volume = r.StoppedContainerDiskPerGbMonth, r.StoppedVolumePerGbMonth
+	}
+	return (float64(p.ContainerDiskInGb)*container + float64(p.VolumeInGb)*volume) / HoursPerMonth
+}
@@ -1,21 +1,57 @@
 package api
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
 	"fmt"
-	"io"
+	"math"
 )
 
 type GetCloudInput struct {
-	GpuCount      int   `json:"gpuCount"`
-	MinMemoryInGb int   `json:"minMemoryInGb,omitempty"`
-	MinVcpuCount  int   `json:"minVcpuCount,omitempty"`
-	SecureCloud   *bool `json:"secureCloud"`
-	TotalDisk     int   `json:"totalDisk,omitempty"`
+	DataCenterId  string `json:"dataCenterId,omitempty"`
+	GpuCount      int    `json:"gpuCount"`
+	MinMemoryInGb int    `json:"minMemoryInGb,omitempty"`
+	MinVcpuCount  int    `json:"minVcpuCount,omitempty"`
+	SecureCloud   *bool  `json:"secureCloud"`
+	TotalDisk     int    `json:"totalDisk,omitempty"`
 }
 
-func GetCloud(in *GetCloudInput) (gpuTypes []interface{}, err error) {
+type GpuType struct {
+	Id             string       `json:"id,omitempty"`
+	DisplayName    string       `json:"displayName,omitempty"`
+	MemoryInGb     int          `json:"memoryInGb,omitempty"`
+	SecureCloud    bool         `json:"secureCloud,omitempty"`
+	CommunityCloud bool         `json:"communityCloud,omitempty"`
+	LowestPrice    *LowestPrice `json:"lowestPrice"`
+}
+
+// LowestPrice is the cheapest listing of a gpu type. Prices and resources
+// are zero when no machine is available.
+type LowestPrice struct {
+	GpuName              string  `json:"gpuName"`
+	GpuTypeId            string  `json:"gpuTypeId"`
+	MinimumBidPrice      float64 `json:"minimumBidPrice"`
+	UninterruptablePrice float64 `json:"uninterruptablePrice"`
+	MinMemory            float64 `json:"minMemory"`
+	MinVcpu              float64 `json:"minVcpu"`
+}
+
+// Price returns what count gpus of the type cost per hour on demand and at
+// the minimum spot bid. ok is false when none is available.
+func (g *GpuType) Price(count int) (onDemand float64, spot float64, ok bool) {
+	kv := g.LowestPrice
+	if kv == nil || kv.MinMemory == 0 {
+		return 0, 0, false
+	}
+	return kv.UninterruptablePrice * float64(count), kv.MinimumBidPrice * float64(count), true
+}
+
+// Cents rounds a price to a tenth of a cent, the precision prices are shown
+// with, so json carries no float noise.
+func Cents(price float64) float64 {
+	return math.Round(price*1000) / 1000
+}
+
+func (c *Client) GetCloud(ctx context.Context, in *GetCloudInput) ([]*GpuType, error) {
 	input := Input{
 		Query: `
 		query LowestPrice($input: GpuLowestPriceInput!) {
@@ -33,38 +69,68 @@ func GetCloud(in *GetCloudInput) (gpuTypes []interface{}, err error) {
 		`,
 		Variables: map[string]interface{}{"input": in},
 	}
-	res, err := Query(input)
-	if err != nil {
-		return
+	var data struct {
+		GpuTypes []*GpuType
 	}
-	defer res.Body.Close()
-	rawData, err := io.ReadAll(res.Body)
-	if err != nil {
-		return
+	err := c.Query(ctx, input, &data)
+	if data.GpuTypes == nil {
+		if err == nil {
+			err = fmt.Errorf("gpuTypes is nil")
+		}
+		return nil, total(err)
 	}
-	if res.StatusCode != 200 {
-		err = fmt.Errorf("statuscode %d: %s", res.StatusCode, string(rawData))
-		return
+	return data.GpuTypes, err
+}
+
+// GetGpuTypes lists every gpu type with its vram, the clouds it is offered in
+// and the cheapest current listing for in.
+func (c *Client) GetGpuTypes(ctx context.Context, in *GetCloudInput) ([]*GpuType, error) {
+	input := Input{
+		Query: `
+		query GpuTypes($input: GpuLowestPriceInput!) {
+			gpuTypes {
+			  id
+			  displayName
+			  memoryInGb
+			  secureCloud
+			  communityCloud
+			  lowestPrice(input: $input) {
+				gpuName
+				gpuTypeId
+				minimumBidPrice
+				uninterruptablePrice
+				minMemory
+				minVcpu
+			  }
+			}
+		}
+		`,
+		Variables: map[string]interface{}{"input": in},
 	}
-	data := make(map[string]interface{})
-	if err = json.Unmarshal(rawData, &data); err != nil {
-		return
+	var data struct {
+		GpuTypes []*GpuType
 	}
-	gqlErrors, ok := data["errors"].([]interface{})
-	if ok && len(gqlErrors) > 0 {
-		firstErr, _ := gqlErrors[0].(map[string]interface{})
-		err = errors.New(firstErr["message"].(string))
-		return
+	err := c.Query(ctx, input, &data)
+	if data.GpuTypes == nil {
+		if err == nil {
+			err = fmt.Errorf("gpuTypes is nil")
+		}
+		return nil, total(err)
 	}
-	gqldata, ok := data["data"].(map[string]interface{})
-	if !ok || gqldata == nil {
-		err = fmt.Errorf("data is nil: %s", string(rawData))
-		return
+	return data.GpuTypes, err
+}
+
+// GpuAvailable reports whether gpuCount gpus of gpuTypeId can be rented in
+// dataCenterId, on secure or community cloud.
+func (c *Client) GpuAvailable(ctx context.Context, gpuTypeId string, gpuCount int, secure bool, dataCenterId string) (bool, error) {
+	gpus, err := c.GetCloud(ctx, &GetCloudInput{GpuCount: gpuCount, SecureCloud: &secure, DataCenterId: dataCenterId})
+	if err != nil {
+		return false, err
 	}
-	gpuTypes, ok = gqldata["gpuTypes"].([]interface{})
-	if !ok || gpuTypes == nil {
-		err = fmt.Errorf("gpuTypes is nil: %s", string(rawData))
-		return
+	for _, gpu := range gpus {
+		if kv := gpu.LowestPrice; kv != nil && kv.GpuTypeId == gpuTypeId {
+			return kv.MinMemory != 0, nil
+		}
 	}
-	return
+	return false, nil
 }
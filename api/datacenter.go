@@ -0,0 +1,56 @@
+package api
+
+import "context"
+
+type DataCenter struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	// SpeedtestUrl is only set by GetDataCenterEndpoints.
+	SpeedtestUrl string `json:"speedtestUrl,omitempty"`
+}
+
+func (c *Client) GetDataCenters(ctx context.Context) ([]*DataCenter, error) {
+	input := Input{
+		Query: `
+		query dataCenters {
+			dataCenters {
+			  id
+			  name
+			  location
+			}
+		}
+		`,
+	}
+	var data struct {
+		DataCenters []*DataCenter
+	}
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
+	}
+	return data.DataCenters, nil
+}
+
+// GetDataCenterEndpoints returns the datacenters with the url of their
+// speedtest endpoint, for measuring the network path to each of them.
+func (c *Client) GetDataCenterEndpoints(ctx context.Context) ([]*DataCenter, error) {
+	input := Input{
+		Query: `
+		query dataCenterEndpoints {
+			dataCenters {
+			  id
+			  name
+			  location
+			  speedtestUrl
+			}
+		}
+		`,
+	}
+	var data struct {
+		DataCenters []*DataCenter
+	}
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
+	}
+	return data.DataCenters, nil
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type Endpoint struct {
+	Id              string            `json:"id"`
+	Name            string            `json:"name"`
+	GpuIds          string            `json:"gpuIds"`
+	NetworkVolumeId string            `json:"networkVolumeId"`
+	TemplateId      string            `json:"templateId"`
+	WorkersMin      int               `json:"workersMin"`
+	WorkersMax      int               `json:"workersMax"`
+	Template        *EndpointTemplate `json:"template"`
+}
+type EndpointTemplate struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	ImageName string `json:"imageName"`
+}
+
+func (c *Client) GetEndpoints(ctx context.Context) ([]*Endpoint, error) {
+	input := Input{
+		Query: `
+		query myEndpoints {
+			myself {
+			  endpoints {
+				id
+				name
+				gpuIds
+				networkVolumeId
+				templateId
+				workersMin
+				workersMax
+				template {
+				  id
+				  name
+				  imageName
+				}
+			  }
+			}
+		  }
+		`,
+	}
+	var data struct {
+		Myself *struct {
+			Endpoints []*Endpoint
+		}
+	}
+	err := c.Query(ctx, input, &data)
+	if data.Myself == nil {
+		if err == nil {
+			err = fmt.Errorf("myself is nil")
+		}
+		return nil, total(err)
+	}
+	return data.Myself.Endpoints, err
+}
+
+type EndpointWorker struct {
+	Id               string `json:"id"`
+	DesiredStatus    string `json:"desiredStatus"`
+	LastStatusChange string `json:"lastStatusChange"`
+
+	StatusChangedAt time.Time `json:"-"`
+}
+
+func (c *Client) GetEndpointWorkers(ctx context.Context, endpointId string) ([]*EndpointWorker, error) {
+	input := Input{
+		Query: `
+		query endpointWorkers {
+			myself {
+			  endpoints {
+				id
+				pods {
+				  id
+				  desiredStatus
+				  lastStatusChange
+				}
+			  }
+			}
+		  }
+		`,
+	}
+	var data struct {
+		Myself *struct {
+			Endpoints []*struct {
+				Id   string
+				Pods []*EndpointWorker
+			}
+		}
+	}
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
+	}
+	if data.Myself == nil {
+		return nil, fmt.Errorf("myself is nil")
+	}
+	for _, e := range data.Myself.Endpoints {
+		if e.Id == endpointId {
+			return e.Pods, nil
+		}
+	}
+	return nil, fmt.Errorf(`endpoint "%s" not found`, endpointId)
+}
+
+type EndpointMetrics struct {
+	Requests     int     `json:"requests"`
+	Failed       int     `json:"failed"`
+	LatencyP95Ms float64 `json:"latencyP95Ms"`
+}
+
+// GetEndpointMetrics returns request counts and latency of an endpoint since the given time.
+func (c *Client) GetEndpointMetrics(ctx context.Context, endpointId string, since time.Time) (*EndpointMetrics, error) {
+	input := Input{
+		Query: `
+		query endpointMetrics($input: EndpointMetricsInput!) {
+			endpointMetrics(input: $input) {
+			  requests
+			  failed
+			  latencyP95Ms
+			}
+		}
+		`,
+		Variables: map[string]interface{}{"input": map[string]interface{}{
+			"endpointId": endpointId,
+			"startTime":  since.UTC().Format(time.RFC3339),
+		}},
+	}
+	var data struct {
+		EndpointMetrics *EndpointMetrics
+	}
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
+	}
+	if data.EndpointMetrics == nil {
+		return nil, fmt.Errorf("endpointMetrics is nil")
+	}
+	return data.EndpointMetrics, nil
+}
+
+// SaveEndpointInput creates a serverless endpoint, or updates the one with Id.
+type SaveEndpointInput struct {
+	Id              string `json:"id,omitempty"`
+	Name            string `json:"name"`
+	TemplateId      string `json:"templateId"`
+	GpuIds          string `json:"gpuIds"`
+	NetworkVolumeId string `json:"networkVolumeId,omitempty"`
+	WorkersMin      int    `json:"workersMin"`
+	WorkersMax      int    `json:"workersMax"`
+}
+
+func (c *Client) SaveEndpoint(ctx context.Context, endpointInput *SaveEndpointInput) (*Endpoint, error) {
+	input := Input{
+		Query: `
+		mutation saveEndpoint($input: EndpointInput!) {
+			saveEndpoint(input: $input) {
+				id
+				name
+				gpuIds
+				networkVolumeId
+				templateId
+				workersMin
+				workersMax
+			}
+		}
+		`,
+		Variables: map[string]interface{}{"input": endpointInput},
+	}
+	var data struct {
+		SaveEndpoint *Endpoint
+	}
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
+	}
+	if data.SaveEndpoint == nil {
+		return nil, fmt.Errorf("endpoint is nil")
+	}
+	return data.SaveEndpoint, nil
+}
+
+func (c *Client) DeleteEndpoint(ctx context.Context, id string) error {
+	input := Input{
+		Query: `
+		mutation deleteEndpoint($id: String!) {
+			deleteEndpoint(id: $id)
+		}
+		`,
+		Variables: map[string]interface{}{"id": id},
+	}
+	var data struct {
+		DeleteEndpoint interface{}
+	}
+	return c.Query(ctx, input, &data)
+}
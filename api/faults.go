@@ -0,0 +1,182 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Faults injects failures and latency into api requests, so retries,
+// backoff and the bulk commands can be exercised without a flaky network.
+// Debug builds (go build -tags debug) read it from RUNPOD_FAULTS; programs
+// embedding the client can wrap their transport with it in any build.
+//
+// A spec is a comma-separated list of kind:probability rules:
+//
+//	429:0.2,timeout:0.1    20% of requests get a 429, 10% time out
+//	503:0.5,reset:0.1      an http status, or a reset connection
+//	latency:200ms          every request is delayed 200ms
+//	latency:2s:0.3         30% of requests are delayed 2s
+//	seed:7                 seeds the random draws; the default is 1
+//
+// At most one failure is injected per request, so the failure
+// probabilities must add up to no more than 1. With the same seed, a
+// sequential program sees the same faults on every run.
+type Faults struct {
+	rules   []faultRule
+	latency []faultRule
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// faultRule is one rule of a spec: a status code, "timeout", "reset" or
+// "latency", and how often it applies.
+type faultRule struct {
+	kind        string
+	status      int
+	delay       time.Duration
+	probability float64
+}
+
+// ParseFaults parses a RUNPOD_FAULTS spec.
+func ParseFaults(spec string) (*Faults, error) {
+	f := &Faults{}
+	seed := int64(1)
+	total := 0.0
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Split(part, ":")
+		kind := strings.ToLower(fields[0])
+		switch {
+		case kind == "seed" && len(fields) == 2:
+			s, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid fault %q: seed must be an integer", part)
+			}
+			seed = s
+		case kind == "latency" && (len(fields) == 2 || len(fields) == 3):
+			r := faultRule{kind: kind, probability: 1}
+			var err error
+			if r.delay, err = time.ParseDuration(fields[1]); err != nil || r.delay < 0 {
+				return nil, fmt.Errorf("invalid fault %q: latency must be a duration such as 200ms", part)
+			}
+			if len(fields) == 3 {
+				if r.probability, err = probability(fields[2]); err != nil {
+					return nil, fmt.Errorf("invalid fault %q: %w", part, err)
+				}
+			}
+			f.latency = append(f.latency, r)
+		case len(fields) == 2:
+			r := faultRule{kind: kind}
+			if kind != "timeout" && kind != "reset" {
+				status, err := strconv.Atoi(kind)
+				if err != nil || status < 400 || status > 599 {
+					return nil, fmt.Errorf("invalid fault %q: want an http status from 400 to 599, timeout, reset, latency or seed", part)
+				}
+				r.status = status
+			}
+			var err error
+			if r.probability, err = probability(fields[1]); err != nil {
+				return nil, fmt.Errorf("invalid fault %q: %w", part, err)
+			}
+			total += r.probability
+			f.rules = append(f.rules, r)
+		default:
+			return nil, fmt.Errorf("invalid fault %q: want kind:probability, e.g. 429:0.2", part)
+		}
+	}
+	if total > 1 {
+		return nil, fmt.Errorf("invalid faults %q: failure probabilities add up to %g, more than 1", spec, total)
+	}
+	f.rand = rand.New(rand.NewSource(seed)) //nolint:gosec
+	return f, nil
+}
+
+func probability(s string) (float64, error) {
+	p, err := strconv.ParseFloat(s, 64)
+	if err != nil || p < 0 || p > 1 {
+		return 0, fmt.Errorf("probability %q is not between 0 and 1", s)
+	}
+	return p, nil
+}
+
+// Wrap returns a Transport that injects the faults into the requests it
+// passes to next. Transports wrapped by the same Faults share its draws.
+func (f *Faults) Wrap(next Transport) Transport {
+	return TransportFunc(func(req *http.Request) (*http.Response, error) {
+		delay, fault := f.draw()
+		if delay > 0 {
+			select {
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			case <-time.After(delay):
+			}
+		}
+		switch {
+		case fault == nil:
+			return next.Do(req)
+		case fault.status != 0:
+			body := fmt.Sprintf(`{"errors":[{"message":"injected fault: statuscode %d"}]}`, fault.status)
+			return &http.Response{
+				Status:     fmt.Sprintf("%d %s", fault.status, http.StatusText(fault.status)),
+				StatusCode: fault.status,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Request:    req,
+			}, nil
+		default:
+			return nil, &faultError{kind: fault.kind}
+		}
+	})
+}
+
+// draw picks the latency and the failure, if any, of one request.
+func (f *Faults) draw() (time.Duration, *faultRule) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var delay time.Duration
+	for _, r := range f.latency {
+		if f.rand.Float64() < r.probability {
+			delay += r.delay
+		}
+	}
+	x := f.rand.Float64()
+	for i := range f.rules {
+		if x < f.rules[i].probability {
+			return delay, &f.rules[i]
+		}
+		x -= f.rules[i].probability
+	}
+	return delay, nil
+}
+
+// faultError is an injected network failure.
+type faultError struct {
+	kind string
+}
+
+func (e *faultError) Error() string {
+	if e.kind == "timeout" {
+		return "injected fault: request timed out"
+	}
+	return "injected fault: connection reset by peer"
+}
+
+// Timeout reports whether the fault is a timeout, like net.Error.
+func (e *faultError) Timeout() bool {
+	return e.kind == "timeout"
+}
+
+// Temporary reports true, like net.Error for retryable failures.
+func (e *faultError) Temporary() bool {
+	return true
+}
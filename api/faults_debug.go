@@ -0,0 +1,33 @@
+//go:build debug
+// +build debug
+
+package api
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+var (
+	envFaultsOnce sync.Once
+	envFaults     *Faults
+	envFaultsErr  error
+)
+
+// withFaults wraps t in the faults of RUNPOD_FAULTS, parsed once so every
+// client and retry shares its draws.
+func withFaults(t Transport) (Transport, error) {
+	envFaultsOnce.Do(func() {
+		if spec := os.Getenv("RUNPOD_FAULTS"); spec != "" {
+			envFaults, envFaultsErr = ParseFaults(spec)
+			if envFaultsErr == nil {
+				fmt.Fprintf(os.Stderr, "warning: injecting api faults from RUNPOD_FAULTS=%s\n", spec)
+			}
+		}
+	})
+	if envFaults == nil {
+		return t, envFaultsErr
+	}
+	return envFaults.Wrap(t), nil
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	LogTypeContainer = "CONTAINER"
+	LogTypeSystem    = "SYSTEM"
+)
+
+type PodLogsInput struct {
+	PodId string `json:"podId"`
+	Type  string `json:"type"`
+	Tail  int    `json:"tail,omitempty"`
+	Since string `json:"since,omitempty"`
+}
+type PodLogLine struct {
+	Timestamp string `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+func (c *Client) GetPodLogs(ctx context.Context, in *PodLogsInput) ([]*PodLogLine, error) {
+	input := Input{
+		Query: `
+		query podLogs($input: PodLogsInput!) {
+			podLogs(input: $input) {
+			  timestamp
+			  message
+			}
+		}
+		`,
+		Variables: map[string]interface{}{"input": in},
+	}
+	var data struct {
+		PodLogs []*PodLogLine
+	}
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
+	}
+	return data.PodLogs, nil
+}
+
+// StreamPodLogs sends the requested log lines and then keeps polling for new
+// ones until ctx is done. Lines are sent exactly once, in order.
+func (c *Client) StreamPodLogs(ctx context.Context, in *PodLogsInput, interval time.Duration, out chan<- *PodLogLine) error {
+	req := *in
+	lastTimestamp := ""
+	seenAtLast := 0
+	for {
+		lines, err := c.GetPodLogs(ctx, &req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		// lines at the cursor timestamp were already sent by the previous
+		// poll; lines of this poll that share a timestamp are all new
+		sentTimestamp, sentAtLast := lastTimestamp, seenAtLast
+		skip := 0
+		for _, line := range lines {
+			if line.Timestamp == sentTimestamp && skip < sentAtLast {
+				skip++
+				continue
+			}
+			if line.Timestamp != lastTimestamp {
+				lastTimestamp = line.Timestamp
+				seenAtLast = 0
+			}
+			seenAtLast++
+			select {
+			case out <- line:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		req.Tail = 0
+		if lastTimestamp != "" {
+			req.Since = lastTimestamp
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// PodConsole is the output of a pod's container from its start, captured
+// before the image's own logging takes over: the entrypoint and bootstrap
+// scripts and the errors of a container that fails to start.
+type PodConsole struct {
+	StartedAt string            `json:"startedAt"`
+	ExitCode  *int              `json:"exitCode"`
+	Truncated bool              `json:"truncated"`
+	Lines     []*PodConsoleLine `json:"lines"`
+}
+type PodConsoleLine struct {
+	Timestamp string `json:"timestamp"`
+	Stream    string `json:"stream"`
+	Message   string `json:"message"`
+}
+
+// GetPodConsole returns the console output of the pod's last container
+// start. ExitCode is set once that container has exited.
+func (c *Client) GetPodConsole(ctx context.Context, podId string) (*PodConsole, error) {
+	input := Input{
+		Query: `
+		query podConsole($input: PodConsoleInput!) {
+			podConsole(input: $input) {
+			  startedAt
+			  exitCode
+			  truncated
+			  lines {
+				timestamp
+				stream
+				message
+			  }
+			}
+		}
+		`,
+		Variables: map[string]interface{}{"input": map[string]string{"podId": podId}},
+	}
+	var data struct {
+		PodConsole *PodConsole
+	}
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
+	}
+	if data.PodConsole == nil {
+		return nil, fmt.Errorf(`pod "%s" not found`, podId)
+	}
+	return data.PodConsole, nil
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestStreamPodLogs(t *testing.T) {
+	type page []*PodLogLine
+	line := func(timestamp, message string) *PodLogLine {
+		return &PodLogLine{Timestamp: timestamp, Message: message}
+	}
+	tests := []struct {
+		name  string
+		pages []page
+		want  []string
+	}{
+		{
+			name:  "one poll",
+			pages: []page{{line("t1", "a"), line("t2", "b")}},
+			want:  []string{"a", "b"},
+		},
+		{
+			name:  "lines sharing a timestamp in a poll",
+			pages: []page{{line("t1", "a"), line("t1", "b"), line("t1", "c")}},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name: "lines at the cursor are not sent again",
+			pages: []page{
+				{line("t1", "a"), line("t2", "b"), line("t2", "c")},
+				{line("t2", "b"), line("t2", "c"), line("t3", "d")},
+			},
+			want: []string{"a", "b", "c", "d"},
+		},
+		{
+			name: "new lines at the cursor timestamp",
+			pages: []page{
+				{line("t1", "a"), line("t2", "b")},
+				{line("t2", "b"), line("t2", "c"), line("t2", "d")},
+				{line("t2", "b"), line("t2", "c"), line("t2", "d"), line("t3", "e"), line("t3", "f")},
+			},
+			want: []string{"a", "b", "c", "d", "e", "f"},
+		},
+		{
+			name: "empty polls",
+			pages: []page{
+				{},
+				{line("t1", "a")},
+				{line("t1", "a")},
+				{line("t1", "a"), line("t2", "b")},
+			},
+			want: []string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			f := &fakeApi{}
+			for _, p := range tt.pages {
+				raw, err := json.Marshal(map[string]interface{}{"data": map[string]interface{}{"podLogs": p}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				f.responses = append(f.responses, respond(200, string(raw)))
+			}
+			// the stream ends after the last page
+			f.responses = append(f.responses, func() (*http.Response, error) {
+				cancel()
+				return nil, errors.New("canceled")
+			})
+			out := make(chan *PodLogLine, 100)
+			if err := f.client(t).StreamPodLogs(ctx, &PodLogsInput{PodId: "a", Type: LogTypeContainer}, 0, out); err != nil {
+				t.Fatal(err)
+			}
+			close(out)
+			var got []string
+			for line := range out {
+				got = append(got, line.Message)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lines = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
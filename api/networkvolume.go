@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"fmt"
+)
+
+type NetworkVolume struct {
+	Id           string `json:"id"`
+	Name         string `json:"name"`
+	Size         int    `json:"size"`
+	DataCenterId string `json:"dataCenterId"`
+}
+
+const networkVolumeFields = `
+				id
+				name
+				size
+				dataCenterId
+`
+
+func (c *Client) GetNetworkVolumes(ctx context.Context) ([]*NetworkVolume, error) {
+	input := Input{
+		Query: `
+		query myNetworkVolumes {
+			myself {
+			  networkVolumes {
+				` + networkVolumeFields + `
+			  }
+			}
+		  }
+		`,
+	}
+	var data struct {
+		Myself *struct {
+			NetworkVolumes []*NetworkVolume
+		}
+	}
+	err := c.Query(ctx, input, &data)
+	if data.Myself == nil {
+		if err == nil {
+			err = fmt.Errorf("myself is nil")
+		}
+		return nil, total(err)
+	}
+	return data.Myself.NetworkVolumes, err
+}
+
+// GetNetworkVolume returns one of my network volumes by id or name. Names
+// need not be unique, so a name several volumes share is refused.
+func (c *Client) GetNetworkVolume(ctx context.Context, idOrName string) (*NetworkVolume, error) {
+	volumes, err := c.GetNetworkVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var named []*NetworkVolume
+	for _, v := range volumes {
+		if v.Id == idOrName {
+			return v, nil
+		}
+		if v.Name == idOrName {
+			named = append(named, v)
+		}
+	}
+	switch len(named) {
+	case 0:
+		return nil, fmt.Errorf(`network volume "%s" not found`, idOrName)
+	case 1:
+		return named[0], nil
+	}
+	return nil, fmt.Errorf(`%d network volumes are named "%s"; use the id`, len(named), idOrName)
+}
+
+type CreateNetworkVolumeInput struct {
+	Name         string `json:"name"`
+	Size         int    `json:"size"`
+	DataCenterId string `json:"dataCenterId"`
+}
+
+func (c *Client) CreateNetworkVolume(ctx context.Context, volumeInput *CreateNetworkVolumeInput) (*NetworkVolume, error) {
+	input := Input{
+		Query: `
+		mutation createNetworkVolume($input: CreateNetworkVolumeInput!) {
+			createNetworkVolume(input: $input) {
+				` + networkVolumeFields + `
+			}
+		}
+		`,
+		Variables: map[string]interface{}{"input": volumeInput},
+	}
+	var data struct {
+		CreateNetworkVolume *NetworkVolume
+	}
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
+	}
+	if data.CreateNetworkVolume == nil {
+		return nil, fmt.Errorf("network volume is nil")
+	}
+	return data.CreateNetworkVolume, nil
+}
+
+// UpdateNetworkVolumeInput renames or resizes a network volume. Volumes can
+// only grow.
+type UpdateNetworkVolumeInput struct {
+	Id   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	Size int    `json:"size,omitempty"`
+}
+
+func (c *Client) UpdateNetworkVolume(ctx context.Context, volumeInput *UpdateNetworkVolumeInput) (*NetworkVolume, error) {
+	input := Input{
+		Query: `
+		mutation updateNetworkVolume($input: UpdateNetworkVolumeInput!) {
+			updateNetworkVolume(input: $input) {
+				` + networkVolumeFields + `
+			}
+		}
+		`,
+		Variables: map[string]interface{}{"input": volumeInput},
+	}
+	var data struct {
+		UpdateNetworkVolume *NetworkVolume
+	}
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
+	}
+	if data.UpdateNetworkVolume == nil {
+		return nil, fmt.Errorf("network volume is nil")
+	}
+	return data.UpdateNetworkVolume, nil
+}
+
+func (c *Client) DeleteNetworkVolume(ctx context.Context, id string) error {
+	input := Input{
+		Query: `
+		mutation deleteNetworkVolume($input: DeleteNetworkVolumeInput!) {
+			deleteNetworkVolume(input: $input)
+		}
+		`,
+		Variables: map[string]interface{}{"input": map[string]string{"id": id}},
+	}
+	var data struct {
+		DeleteNetworkVolume interface{}
+	}
+	return c.Query(ctx, input, &data)
+}
@@ -1,56 +1,119 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"regexp"
 	"strings"
+	"time"
 )
 
-type PodOut struct {
-	Data   *PodData        `json:"data"`
-	Errors []*GraphQLError `json:"errors"`
+// ErrNoFreeGpus is returned by StartOnDemandPod when the pod's machine does
+// not have enough free gpus to resume it.
+var ErrNoFreeGpus = errors.New("no free gpus on the pod's machine")
+
+var noFreeGpus = regexp.MustCompile(`(?i)not enough free gpus|no (longer any )?(gpus|instances) available`)
+
+// ErrPodRunning is returned by UpdatePod when the backend only edits stopped pods.
+var ErrPodRunning = errors.New("the pod must be stopped to edit it")
+
+var podRunning = regexp.MustCompile(`(?i)(stop|terminate) the pod (first|before)|pod (is|must not be) running|must be stopped`)
+
+type Pod struct {
+	Id                string `json:"id"`
+	ContainerDiskInGb int    `json:"containerDiskInGb"`
+	// ContainerRegistryAuthId is the id of the registry credentials the
+	// pod's image is pulled with.
+	ContainerRegistryAuthId string   `json:"containerRegistryAuthId"`
+	CostPerHr               float32  `json:"costPerHr"`
+	DesiredStatus           string   `json:"desiredStatus"`
+	DockerArgs              string   `json:"dockerArgs"`
+	Env                     []string `json:"env"`
+	GpuCount                int      `json:"gpuCount"`
+	ImageName               string   `json:"imageName"`
+	LastStatusChange        string   `json:"lastStatusChange"`
+	MemoryInGb              int      `json:"memoryInGb"`
+	Name                    string   `json:"name"`
+	NetworkVolumeId         string   `json:"networkVolumeId"`
+	PodType                 string   `json:"podType"`
+	Ports                   string   `json:"ports"`
+	TemplateId              string   `json:"templateId"`
+	VcpuCount               int      `json:"vcpuCount"`
+	VolumeInGb              int      `json:"volumeInGb"`
+	VolumeMountPath         string   `json:"volumeMountPath"`
+	Machine                 *Machine `json:"machine"`
+	Runtime                 *Runtime `json:"runtime"`
+
+	// StatusChangedAt and StartedAt are parsed from lastStatusChange and the
+	// runtime uptime when the pod is decoded; they are zero when unknown.
+	StatusChangedAt time.Time `json:"-"`
+	StartedAt       time.Time `json:"-"`
+
+	// StorageCostPerHr is what the pod's disks cost per hour, including
+	// while it is stopped, when set from StorageRates.
+	StorageCostPerHr *float64 `json:"storageCostPerHr,omitempty"`
 }
-type GraphQLError struct {
-	Message string
+type Runtime struct {
+	UptimeInSeconds int                 `json:"uptimeInSeconds"`
+	Ports           []*RuntimePort      `json:"ports"`
+	Container       *ContainerTelemetry `json:"container"`
+	Gpus            []*GpuTelemetry     `json:"gpus"`
 }
-type PodData struct {
-	Myself *MySelfData
+type ContainerTelemetry struct {
+	CpuPercent    int `json:"cpuPercent"`
+	MemoryPercent int `json:"memoryPercent"`
 }
-type MySelfData struct {
-	Pods []*Pod
+type GpuTelemetry struct {
+	Id                string `json:"id"`
+	GpuUtilPercent    int    `json:"gpuUtilPercent"`
+	MemoryUtilPercent int    `json:"memoryUtilPercent"`
 }
-type Pod struct {
-	Id                string
-	ContainerDiskInGb int
-	CostPerHr         float32
-	DesiredStatus     string
-	DockerArgs        string
-	Env               []string
-	GpuCount          int
-	ImageName         string
-	MemoryInGb        int
-	Name              string
-	PodType           string
-	Ports             string
-	VcpuCount         int
-	VolumeInGb        int
-	VolumeMountPath   string
-	Machine           *Machine
+type RuntimePort struct {
+	Ip          string `json:"ip"`
+	IsIpPublic  bool   `json:"isIpPublic"`
+	PrivatePort int    `json:"privatePort"`
+	PublicPort  int    `json:"publicPort"`
+	Type        string `json:"type"`
 }
+
+// GpuUtil averages the utilization and memory use of the pod's gpus. ok is
+// false when the pod reports no gpu telemetry.
+func (r *Runtime) GpuUtil() (util int, memory int, ok bool) {
+	if r == nil || len(r.Gpus) == 0 {
+		return 0, 0, false
+	}
+	for _, g := range r.Gpus {
+		util += g.GpuUtilPercent
+		memory += g.MemoryUtilPercent
+	}
+	return util / len(r.Gpus), memory / len(r.Gpus), true
+}
+
+// Idle reports whether a running pod's gpus are busy less than threshold
+// percent. Pods without gpu telemetry are never idle.
+func (p *Pod) Idle(threshold int) bool {
+	util, _, ok := p.Runtime.GpuUtil()
+	return ok && p.DesiredStatus == "RUNNING" && util < threshold
+}
+
 type Machine struct {
-	GpuDisplayName string
+	CpuModel       string `json:"cpuModel"`
+	DataCenterId   string `json:"dataCenterId"`
+	GpuDisplayName string `json:"gpuDisplayName"`
+	// GpuInterconnect is how the machine's gpus talk to each other, e.g.
+	// NVLINK, NVSWITCH or PCIE.
+	GpuInterconnect string `json:"gpuInterconnect"`
+	GpuTypeId       string `json:"gpuTypeId"`
+	PodHostId       string `json:"podHostId"`
+	SecureCloud     bool   `json:"secureCloud"`
 }
 
-func GetPods() (pods []*Pod, err error) {
-	input := Input{
-		Query: `
-		query myPods {
-			myself {
-			  pods {
+const podFields = `
 				id
 				containerDiskInGb
+				containerRegistryAuthId
 				costPerHr
 				desiredStatus
 				dockerArgs
@@ -62,313 +125,408 @@ func GetPods() (pods []*Pod, err error) {
 				machineId
 				memoryInGb
 				name
+				networkVolumeId
 				podType
 				port
 				ports
+				templateId
 				uptimeSeconds
 				vcpuCount
 				volumeInGb
 				volumeMountPath
 				machine {
+				  dataCenterId
 				  gpuDisplayName
+				  gpuTypeId
+				  podHostId
+				  secureCloud
+				}
+				runtime {
+				  uptimeInSeconds
+				  ports {
+					ip
+					isIpPublic
+					privatePort
+					publicPort
+					type
+				  }
+				  container {
+					cpuPercent
+					memoryPercent
+				  }
+				  gpus {
+					id
+					gpuUtilPercent
+					memoryUtilPercent
+				  }
+				}
+`
+
+func (c *Client) GetPods(ctx context.Context) ([]*Pod, error) {
+	return c.getPods(ctx, podFields)
+}
+
+// machineDetailFields are machine fields that not every api version has yet.
+// They are left out of podFields so that a server without them only fails
+// the wide listings that fetch them, with GetMachineDetails.
+const machineDetailFields = `
+				id
+				machine {
+				  cpuModel
+				  gpuInterconnect
 				}
+`
+
+// GetMachineDetails returns the cpu model and gpu interconnect of the
+// machines of my pods, by pod id.
+func (c *Client) GetMachineDetails(ctx context.Context) (map[string]*Machine, error) {
+	pods, err := c.getPods(ctx, machineDetailFields)
+	if pods == nil {
+		return nil, err
+	}
+	machines := make(map[string]*Machine, len(pods))
+	for _, p := range pods {
+		if p.Machine != nil {
+			machines[p.Id] = p.Machine
+		}
+	}
+	return machines, err
+}
+
+func (c *Client) getPods(ctx context.Context, fields string) ([]*Pod, error) {
+	input := Input{
+		Query: `
+		query myPods {
+			myself {
+			  pods {
+				` + fields + `
 			  }
 			}
 		  }
 		`,
 	}
-	res, err := Query(input)
-	if err != nil {
-		return
+	var data struct {
+		Myself *struct {
+			Pods []*Pod
+		}
 	}
-	if res.StatusCode != 200 {
-		err = fmt.Errorf("statuscode %d", res.StatusCode)
-		return
+	err := c.Query(ctx, input, &data)
+	if data.Myself == nil || data.Myself.Pods == nil {
+		if err == nil {
+			err = fmt.Errorf("pods are nil")
+		}
+		return nil, total(err)
 	}
-	defer res.Body.Close()
-	rawData, err := io.ReadAll(res.Body)
+	return data.Myself.Pods, err
+}
+
+func (c *Client) GetPod(ctx context.Context, id string) (*Pod, error) {
+	return c.GetPodFields(ctx, id, nil)
+}
+
+// GetPodFields is GetPod fetching only the named fields, as with
+// PodsInput.Fields.
+func (c *Client) GetPodFields(ctx context.Context, id string, fields []string) (*Pod, error) {
+	selected, err := selectPodFields(fields)
 	if err != nil {
-		return
+		return nil, err
+	}
+	input := Input{
+		Query: `
+		query pod($podId: String!) {
+			pod(input: {podId: $podId}) {
+				` + selected + `
+			}
+		}
+		`,
+		Variables: map[string]interface{}{"podId": id},
 	}
-	data := &PodOut{}
-	if err = json.Unmarshal(rawData, data); err != nil {
-		return
+	var data struct {
+		Pod *Pod
 	}
-	if len(data.Errors) > 0 {
-		err = errors.New(data.Errors[0].Message)
-		return
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
 	}
-	if data == nil || data.Data == nil || data.Data.Myself == nil || data.Data.Myself.Pods == nil {
-		err = fmt.Errorf("data is nil: %s", string(rawData))
-		return
+	if data.Pod == nil {
+		return nil, fmt.Errorf(`pod "%s" not found`, id)
 	}
-	pods = data.Data.Myself.Pods
-	return
+	return data.Pod, nil
 }
 
 type CreatePodInput struct {
-	CloudType         string    `json:"cloudType"`
-	ContainerDiskInGb int       `json:"containerDiskInGb"`
-	DeployCost        float32   `json:"deployCost,omitempty"`
-	DockerArgs        string    `json:"dockerArgs"`
-	Env               []*PodEnv `json:"env"`
-	GpuCount          int       `json:"gpuCount"`
-	GpuTypeId         string    `json:"gpuTypeId"`
-	ImageName         string    `json:"imageName"`
-	MinMemoryInGb     int       `json:"minMemoryInGb"`
-	MinVcpuCount      int       `json:"minVcpuCount"`
-	Name              string    `json:"name"`
-	Ports             string    `json:"ports"`
-	TemplateId        string    `json:"templateId"`
-	VolumeInGb        int       `json:"volumeInGb"`
-	VolumeMountPath   string    `json:"volumeMountPath"`
+	// BidPerGpu makes the pod a spot pod bidding that much per gpu per hour.
+	BidPerGpu               float32   `json:"bidPerGpu,omitempty"`
+	CloudType               string    `json:"cloudType"`
+	ContainerDiskInGb       int       `json:"containerDiskInGb"`
+	ContainerRegistryAuthId string    `json:"containerRegistryAuthId,omitempty"`
+	DataCenterId            string    `json:"dataCenterId,omitempty"`
+	DeployCost              float32   `json:"deployCost,omitempty"`
+	DockerArgs              string    `json:"dockerArgs"`
+	Env                     []*PodEnv `json:"env"`
+	GpuCount                int       `json:"gpuCount"`
+	GpuTypeId               string    `json:"gpuTypeId"`
+	ImageName               string    `json:"imageName"`
+	MinMemoryInGb           int       `json:"minMemoryInGb"`
+	MinVcpuCount            int       `json:"minVcpuCount"`
+	Name                    string    `json:"name"`
+	NetworkVolumeId         string    `json:"networkVolumeId,omitempty"`
+	Ports                   string    `json:"ports"`
+	TemplateId              string    `json:"templateId"`
+	VolumeInGb              int       `json:"volumeInGb"`
+	VolumeMountPath         string    `json:"volumeMountPath"`
 }
 type PodEnv struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
 }
 
-func CreatePod(podInput *CreatePodInput) (pod map[string]interface{}, err error) {
+// podStatusFields are returned by the pod mutations.
+const podStatusFields = `
+				id
+				costPerHr
+				desiredStatus
+				lastStatusChange
+`
+
+func (c *Client) CreatePod(ctx context.Context, podInput *CreatePodInput) (*Pod, error) {
 	if podInput.Name == "" {
 		names := strings.Split(podInput.ImageName, ":")
 		podInput.Name = names[0]
 	}
 
+	if podInput.BidPerGpu > 0 {
+		return c.createSpotPod(ctx, podInput)
+	}
 	input := Input{
 		Query: `
 		mutation createPod($input: PodFindAndDeployOnDemandInput!) {
 			podFindAndDeployOnDemand(input: $input) {
-			  id
-			  costPerHr
-			  desiredStatus
-			  lastStatusChange
+				` + podStatusFields + `
 			}
 		}
 		`,
 		Variables: map[string]interface{}{"input": podInput},
 	}
-	res, err := Query(input)
-	if err != nil {
-		return
+	var data struct {
+		PodFindAndDeployOnDemand *Pod
 	}
-	defer res.Body.Close()
-	rawData, err := io.ReadAll(res.Body)
-	if err != nil {
-		return
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
 	}
-	if res.StatusCode != 200 {
-		err = fmt.Errorf("statuscode %d: %s", res.StatusCode, string(rawData))
-		return
+	if data.PodFindAndDeployOnDemand == nil {
+		return nil, fmt.Errorf("pod is nil")
 	}
-	data := make(map[string]interface{})
-	if err = json.Unmarshal(rawData, &data); err != nil {
-		return
+	return data.PodFindAndDeployOnDemand, nil
+}
+
+func (c *Client) createSpotPod(ctx context.Context, podInput *CreatePodInput) (*Pod, error) {
+	input := Input{
+		Query: `
+		mutation createSpotPod($input: PodRentInterruptableInput!) {
+			podRentInterruptable(input: $input) {
+				` + podStatusFields + `
+			}
+		}
+		`,
+		Variables: map[string]interface{}{"input": podInput},
 	}
-	gqlErrors, ok := data["errors"].([]interface{})
-	if ok && len(gqlErrors) > 0 {
-		firstErr, _ := gqlErrors[0].(map[string]interface{})
-		err = errors.New(firstErr["message"].(string))
-		return
+	var data struct {
+		PodRentInterruptable *Pod
 	}
-	gqldata, ok := data["data"].(map[string]interface{})
-	if !ok || gqldata == nil {
-		err = fmt.Errorf("data is nil: %s", string(rawData))
-		return
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
 	}
-	pod, ok = gqldata["podFindAndDeployOnDemand"].(map[string]interface{})
-	if !ok || pod == nil {
-		err = fmt.Errorf("pod is nil: %s", string(rawData))
-		return
+	if data.PodRentInterruptable == nil {
+		return nil, fmt.Errorf("pod is nil")
 	}
-	return
+	return data.PodRentInterruptable, nil
 }
 
-func StopPod(id string) (podStop map[string]interface{}, err error) {
+// BidPerGpu is the bid per gpu of a spot pod, or 0 for other pods. The bid
+// is what the pod costs while it runs.
+func (p *Pod) BidPerGpu() float32 {
+	if p.PodType != "INTERRUPTABLE" || p.GpuCount == 0 {
+		return 0
+	}
+	return p.CostPerHr / float32(p.GpuCount)
+}
+
+func (c *Client) StopPod(ctx context.Context, id string) (*Pod, error) {
 	input := Input{
 		Query: `
 		mutation stopPod($podId: String!) {
-		  podStop(input: {podId:  $podId}) {
-			id
-			desiredStatus
-			lastStatusChange
-		  }
+			podStop(input: {podId: $podId}) {
+				` + podStatusFields + `
+			}
 		}
 		`,
 		Variables: map[string]interface{}{"podId": id},
 	}
-	res, err := Query(input)
-	if err != nil {
-		return
-	}
-	if res.StatusCode != 200 {
-		err = fmt.Errorf("statuscode %d", res.StatusCode)
-		return
+	var data struct {
+		PodStop *Pod
 	}
-	defer res.Body.Close()
-	rawData, err := io.ReadAll(res.Body)
-	if err != nil {
-		return
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
 	}
-	data := make(map[string]interface{})
-	if err = json.Unmarshal(rawData, &data); err != nil {
-		return
+	if data.PodStop == nil {
+		return nil, fmt.Errorf("podStop is nil")
 	}
-	gqlErrors, ok := data["errors"].([]interface{})
-	if ok && len(gqlErrors) > 0 {
-		firstErr, _ := gqlErrors[0].(map[string]interface{})
-		err = errors.New(firstErr["message"].(string))
-		return
-	}
-	gqldata, ok := data["data"].(map[string]interface{})
-	if !ok || gqldata == nil {
-		err = fmt.Errorf("data is nil: %s", string(rawData))
-		return
-	}
-	podStop, ok = gqldata["podStop"].(map[string]interface{})
-	if !ok || podStop == nil {
-		err = fmt.Errorf("podStop is nil: %s", string(rawData))
-		return
-	}
-	return
+	return data.PodStop, nil
 }
 
-func RemovePod(id string) (ok bool, err error) {
+func (c *Client) RemovePod(ctx context.Context, id string) error {
 	input := Input{
 		Query: `
 		mutation terminatePod($podId: String!) {
-		  podTerminate(input: {podId:  $podId})
+			podTerminate(input: {podId: $podId})
 		}
 		`,
 		Variables: map[string]interface{}{"podId": id},
 	}
-	res, err := Query(input)
-	if err != nil {
-		return
-	}
-	if res.StatusCode != 200 {
-		err = fmt.Errorf("statuscode %d", res.StatusCode)
-		return
-	}
-	defer res.Body.Close()
-	rawData, err := io.ReadAll(res.Body)
-	if err != nil {
-		return
-	}
-	data := make(map[string]interface{})
-	if err = json.Unmarshal(rawData, &data); err != nil {
-		return
+	var data struct {
+		PodTerminate interface{}
 	}
-	gqlErrors, ok := data["errors"].([]interface{})
-	if ok && len(gqlErrors) > 0 {
-		firstErr, _ := gqlErrors[0].(map[string]interface{})
-		err = errors.New(firstErr["message"].(string))
-		return
-	}
-	gqldata, ok := data["data"].(map[string]interface{})
-	if !ok || gqldata == nil {
-		err = fmt.Errorf("data is nil: %s", string(rawData))
-		return
-	}
-	_, ok = gqldata["podTerminate"]
-	return
+	return c.Query(ctx, input, &data)
 }
 
-func StartOnDemandPod(id string) (pod map[string]interface{}, err error) {
+func (c *Client) StartOnDemandPod(ctx context.Context, id string) (*Pod, error) {
 	input := Input{
 		Query: `
 		mutation podResume($podId: String!) {
-		  podResume(input: {podId: $podId}) {
-			id
-			costPerHr
-			desiredStatus
-			lastStatusChange
-		  }
+			podResume(input: {podId: $podId}) {
+				` + podStatusFields + `
+			}
 		}
 		`,
 		Variables: map[string]interface{}{"podId": id},
 	}
-	res, err := Query(input)
-	if err != nil {
-		return
+	var data struct {
+		PodResume *Pod
 	}
-	if res.StatusCode != 200 {
-		err = fmt.Errorf("PodBidResume: statuscode %d", res.StatusCode)
-		return
+	if err := c.Query(ctx, input, &data); err != nil {
+		if noFreeGpus.MatchString(err.Error()) {
+			return nil, fmt.Errorf("%w: %s", ErrNoFreeGpus, err)
+		}
+		return nil, err
 	}
-	defer res.Body.Close()
-	rawData, err := io.ReadAll(res.Body)
-	if err != nil {
-		return
+	if data.PodResume == nil {
+		return nil, fmt.Errorf("pod is nil")
 	}
-	data := make(map[string]interface{})
-	if err = json.Unmarshal(rawData, &data); err != nil {
-		return
+	return data.PodResume, nil
+}
+
+func (c *Client) StartSpotPod(ctx context.Context, id string, bidPerGpu float32) (*Pod, error) {
+	input := Input{
+		Query: `
+		mutation Mutation($podId: String!, $bidPerGpu: Float!) {
+			podBidResume(input: {podId: $podId, bidPerGpu: $bidPerGpu}) {
+				` + podStatusFields + `
+			}
+		}
+		`,
+		Variables: map[string]interface{}{"podId": id, "bidPerGpu": bidPerGpu},
 	}
-	gqlErrors, ok := data["errors"].([]interface{})
-	if ok && len(gqlErrors) > 0 {
-		firstErr, _ := gqlErrors[0].(map[string]interface{})
-		err = errors.New(firstErr["message"].(string))
-		return
+	var data struct {
+		PodBidResume *Pod
 	}
-	gqldata, ok := data["data"].(map[string]interface{})
-	if !ok || gqldata == nil {
-		err = fmt.Errorf("data is nil: %s", string(rawData))
-		return
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
 	}
-	pod, ok = gqldata["podResume"].(map[string]interface{})
-	if !ok || pod == nil {
-		err = fmt.Errorf("pod is nil: %s", string(rawData))
-		return
+	if data.PodBidResume == nil {
+		return nil, fmt.Errorf("podBidResume is nil")
 	}
-	return
+	return data.PodBidResume, nil
+}
+
+// UpdatePodInput replaces the editable settings of a pod. Unlike
+// CreatePodInput every field is sent, so callers start from the pod's current
+// settings and change what they need.
+type UpdatePodInput struct {
+	PodId             string    `json:"podId"`
+	ContainerDiskInGb int       `json:"containerDiskInGb"`
+	DockerArgs        string    `json:"dockerArgs"`
+	Env               []*PodEnv `json:"env"`
+	ImageName         string    `json:"imageName"`
+	Ports             string    `json:"ports"`
+	VolumeInGb        int       `json:"volumeInGb"`
+	VolumeMountPath   string    `json:"volumeMountPath"`
 }
 
-func StartSpotPod(id string, bidPerGpu float32) (podBidResume map[string]interface{}, err error) {
+// UpdatePod edits a pod in place, keeping its volume. A running pod is
+// restarted with the new settings.
+func (c *Client) UpdatePod(ctx context.Context, podInput *UpdatePodInput) (*Pod, error) {
 	input := Input{
 		Query: `
-		mutation Mutation($podId: String!, $bidPerGpu: Float!) {
-			podBidResume(input: {podId: $podId, bidPerGpu: $bidPerGpu}) {
-			  id
-			  costPerHr
-			  desiredStatus
-			  lastStatusChange
+		mutation podEditJob($input: PodEditJobInput!) {
+			podEditJob(input: $input) {
+				` + podStatusFields + `
 			}
 		}
 		`,
-		Variables: map[string]interface{}{"podId": id, "bidPerGpu": bidPerGpu},
+		Variables: map[string]interface{}{"input": podInput},
 	}
-	res, err := Query(input)
-	if err != nil {
-		return
+	var data struct {
+		PodEditJob *Pod
+	}
+	if err := c.Query(ctx, input, &data); err != nil {
+		if podRunning.MatchString(err.Error()) {
+			return nil, fmt.Errorf("%w: %s", ErrPodRunning, err)
+		}
+		return nil, err
 	}
-	if res.StatusCode != 200 {
-		err = fmt.Errorf("PodBidResume: statuscode %d", res.StatusCode)
-		return
+	if data.PodEditJob == nil {
+		return nil, fmt.Errorf("podEditJob is nil")
 	}
-	defer res.Body.Close()
-	rawData, err := io.ReadAll(res.Body)
+	return data.PodEditJob, nil
+}
+
+// UpdatePodRestarting is UpdatePod for pods in any state: when the backend
+// only edits stopped pods, the pod is stopped, edited and resumed the way it
+// ran, a spot pod at the bid it had.
+func (c *Client) UpdatePodRestarting(ctx context.Context, podInput *UpdatePodInput) (*Pod, error) {
+	pod, err := c.UpdatePod(ctx, podInput)
+	if !errors.Is(err, ErrPodRunning) {
+		return pod, err
+	}
+	running, err := c.GetPod(ctx, podInput.PodId)
 	if err != nil {
-		return
+		return nil, err
 	}
-	data := make(map[string]interface{})
-	if err = json.Unmarshal(rawData, &data); err != nil {
-		return
+	if _, err := c.StopPod(ctx, podInput.PodId); err != nil {
+		return nil, err
 	}
-	gqlErrors, ok := data["errors"].([]interface{})
-	if ok && len(gqlErrors) > 0 {
-		firstErr, _ := gqlErrors[0].(map[string]interface{})
-		err = errors.New(firstErr["message"].(string))
-		return
+	if _, err := c.UpdatePod(ctx, podInput); err != nil {
+		return nil, err
 	}
-	gqldata, ok := data["data"].(map[string]interface{})
-	if !ok || gqldata == nil {
-		err = fmt.Errorf("data is nil: %s", string(rawData))
-		return
+	if bid := running.BidPerGpu(); bid > 0 {
+		pod, err = c.StartSpotPod(ctx, podInput.PodId, bid)
+	} else {
+		pod, err = c.StartOnDemandPod(ctx, podInput.PodId)
 	}
-	podBidResume, ok = gqldata["podBidResume"].(map[string]interface{})
-	if !ok || podBidResume == nil {
-		err = fmt.Errorf("podBidResume is nil: %s", string(rawData))
-		return
+	if err != nil {
+		return nil, fmt.Errorf(`pod "%s" was updated but is stopped; start it again: %w`, podInput.PodId, err)
 	}
-	return
+	return pod, nil
+}
+
+// SubscribePods calls onChange whenever one of the account's pods changes,
+// and each time the subscription connects so changes missed while
+// disconnected are picked up. It returns ErrSubscriptionsUnsupported when the
+// api has no pod subscription.
+func (c *Client) SubscribePods(ctx context.Context, onChange func() error) error {
+	return c.Subscribe(ctx, &Subscription{
+		Input: Input{
+			Query: `
+			subscription podsChanged {
+				podsChanged {
+				  id
+				}
+			  }
+			`,
+		},
+		OnConnect: onChange,
+		OnData: func(data json.RawMessage) error {
+			return onChange()
+		},
+	})
 }
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PodsInput filters ListPods. Empty filters match every pod.
+type PodsInput struct {
+	Name          string `json:"name,omitempty"`
+	DesiredStatus string `json:"desiredStatus,omitempty"`
+	GpuTypeId     string `json:"gpuTypeId,omitempty"`
+	// Fields limits the fetched pod fields to the named ones, e.g. "name",
+	// "machine" or "machine.gpuTypeId", to cut the response size. The id is
+	// always fetched. Empty fetches them all.
+	Fields []string `json:"-"`
+}
+
+// ListPods returns the pods matching input. The api cannot filter or page
+// pods, so every pod is fetched and filtered here. A PartialError comes
+// with the pods that could be fetched.
+func (c *Client) ListPods(ctx context.Context, input *PodsInput) ([]*Pod, error) {
+	fields, err := selectPodFields(input.FilterFields())
+	if err != nil {
+		return nil, err
+	}
+	pods, err := c.getPods(ctx, fields)
+	if pods == nil {
+		return nil, err
+	}
+	return filterPods(pods, input), err
+}
+
+// Match reports whether pod passes the filters of input, the way the server
+// filters: the name matches in part and case is ignored.
+func (input *PodsInput) Match(pod *Pod) bool {
+	switch {
+	case input.Name != "" && !strings.Contains(strings.ToLower(pod.Name), strings.ToLower(input.Name)):
+		return false
+	case input.DesiredStatus != "" && !strings.EqualFold(pod.DesiredStatus, input.DesiredStatus):
+		return false
+	case input.GpuTypeId != "" && (pod.Machine == nil || !strings.EqualFold(pod.Machine.GpuTypeId, input.GpuTypeId)):
+		return false
+	}
+	return true
+}
+
+// FilterFields returns the fields to fetch for input: its Fields and the
+// ones its filters look at, or nil for all of them.
+func (input *PodsInput) FilterFields() []string {
+	if len(input.Fields) == 0 {
+		return nil
+	}
+	var names []string
+	if input.Name != "" {
+		names = append(names, "name")
+	}
+	if input.DesiredStatus != "" {
+		names = append(names, "desiredStatus")
+	}
+	if input.GpuTypeId != "" {
+		names = append(names, "machine.gpuTypeId")
+	}
+	return append(names, input.Fields...)
+}
+
+func filterPods(pods []*Pod, input *PodsInput) []*Pod {
+	found := []*Pod{}
+	for _, p := range pods {
+		if input.Match(p) {
+			found = append(found, p)
+		}
+	}
+	return found
+}
+
+// podField is a field of podFields with its subfields.
+type podField struct {
+	name   string
+	fields []*podField
+}
+
+// parsePodFields parses a graphql selection of the form of podFields.
+func parsePodFields(query string) []*podField {
+	var root podField
+	stack := []*podField{&root}
+	for _, line := range strings.Split(query, "\n") {
+		trimmed := strings.TrimSpace(line)
+		top := stack[len(stack)-1]
+		switch {
+		case trimmed == "":
+		case trimmed == "}":
+			stack = stack[:len(stack)-1]
+		case strings.HasSuffix(trimmed, "{"):
+			f := &podField{name: strings.TrimSpace(strings.TrimSuffix(trimmed, "{"))}
+			top.fields = append(top.fields, f)
+			stack = append(stack, f)
+		default:
+			top.fields = append(top.fields, &podField{name: trimmed})
+		}
+	}
+	return root.fields
+}
+
+// fieldSelection holds the selected subfields of each selected field; nil
+// selects a field with all of its subfields.
+type fieldSelection map[string]fieldSelection
+
+func (sel fieldSelection) add(fields []*podField, path []string) bool {
+	var f *podField
+	for _, c := range fields {
+		if c.name == path[0] {
+			f = c
+		}
+	}
+	if f == nil || len(path) > 1 && len(f.fields) == 0 {
+		return false
+	}
+	sub, ok := sel[f.name]
+	if len(path) == 1 || ok && sub == nil {
+		sel[f.name] = nil
+		return true
+	}
+	if !ok {
+		sub = fieldSelection{}
+		sel[f.name] = sub
+	}
+	return sub.add(f.fields, path[1:])
+}
+
+func (sel fieldSelection) write(b *strings.Builder, fields []*podField, indent string) {
+	for _, f := range fields {
+		sub, ok := sel[f.name]
+		if sel != nil && !ok {
+			continue
+		}
+		if len(f.fields) == 0 {
+			b.WriteString(indent + f.name + "\n")
+			continue
+		}
+		b.WriteString(indent + f.name + " {\n")
+		sub.write(b, f.fields, indent+"  ")
+		b.WriteString(indent + "}\n")
+	}
+}
+
+// selectPodFields returns the part of podFields with the named fields and
+// the id. A name is a top-level field, e.g. "machine", or the path to one of
+// its subfields, e.g. "machine.gpuTypeId".
+func selectPodFields(names []string) (string, error) {
+	if len(names) == 0 {
+		return podFields, nil
+	}
+	fields := parsePodFields(podFields)
+	sel := fieldSelection{"id": nil}
+	for _, n := range names {
+		if !sel.add(fields, strings.Split(n, ".")) {
+			return "", fmt.Errorf("unknown pod field %q", n)
+		}
+	}
+	var b strings.Builder
+	sel.write(&b, fields, "\t\t\t\t")
+	return b.String(), nil
+}
@@ -2,39 +2,507 @@ package api
 
 import (
 	"bytes"
+	"cli/profile"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
 	"net/http"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
+// Client calls the runpod graphql and serverless apis. Transient failures
+// (network errors, 429 and 5xx responses) are retried with exponential
+// backoff and jitter. Mutations are only retried when the response shows
+// the request was not processed (429 and 503), so a pod is never created
+// twice. All calls of a client share a retry budget, so that a bulk command
+// does not turn a degraded api into thousands of retries.
+type Client struct {
+	// ApiUrl is the graphql endpoint. When empty, RUNPOD_API_URL or the
+	// apiUrl config is used.
+	ApiUrl string
+	// ApiKey authenticates the calls. When empty, ApiKeySource is called,
+	// and when that is nil, the key of the active profile or RUNPOD_API_KEY,
+	// as profile.ApiKey picks it.
+	ApiKey string
+	// ApiKeySource returns the api key for each call, e.g. from a secret
+	// store, so a rotated key is picked up without a new client.
+	ApiKeySource func() string
+	// ServerlessUrl is the base url of the serverless api. When empty,
+	// RUNPOD_SERVERLESS_URL or https://api.runpod.ai/v2 is used.
+	ServerlessUrl string
+	// HttpClient sends the requests. When nil, an http.Client is built from
+	// TLSConfig and the proxy config or HTTPS_PROXY.
+	HttpClient Transport
+	// TLSConfig is used by the default http client and the websockets. When
+	// nil, the caFile and insecureSkipVerify config are used.
+	TLSConfig *tls.Config
+	// Timeout bounds each request. Zero means 10 seconds.
+	Timeout time.Duration
+	// DedupWindow is how long a read's response is reused by identical reads
+	// from other runpodctl processes. Zero only shares reads within this
+	// process.
+	DedupWindow time.Duration
+	// MaxResponseBytes caps the size of a response body, so a runaway
+	// response cannot exhaust memory. Zero means no limit.
+	MaxResponseBytes int64
+	MaxRetries       int
+	MinBackoff       time.Duration
+	MaxBackoff       time.Duration
+	// RetryBudget caps the retries of all calls together; once it is spent,
+	// failures are returned as ErrRetryBudget right away. Zero means no cap.
+	RetryBudget int
+	// RetryRefill is how often one retry is added back to the budget, so
+	// long running daemons do not run out of retries for good.
+	RetryRefill time.Duration
+
+	budgetMu   sync.Mutex
+	retries    int
+	refilledAt time.Time
+
+	defaultOnce   sync.Once
+	defaultClient *http.Client
+	defaultErr    error
+}
+
+// DefaultClient is the client used by the CLI commands.
+var DefaultClient = NewClient()
+
+func NewClient() *Client {
+	return &Client{
+		Timeout:          10 * time.Second,
+		DedupWindow:      time.Second,
+		MaxResponseBytes: 256 << 20,
+		MaxRetries:       3,
+		MinBackoff:       500 * time.Millisecond,
+		MaxBackoff:       8 * time.Second,
+		RetryBudget:      50,
+		RetryRefill:      10 * time.Second,
+	}
+}
+
 type Input struct {
 	Query     string                 `json:"query"`
 	Variables map[string]interface{} `json:"variables"`
 }
 
-func Query(input Input) (res *http.Response, err error) {
-	jsonValue, err := json.Marshal(input)
+type GraphQLError struct {
+	Message string
+	Path    []interface{}
+}
+
+// PartialError is returned along with the data of a response that has both
+// data and errors. The fields named in Errors are missing from the data; the
+// rest of it is usable. Null list items, which stand for items that failed,
+// are dropped.
+type PartialError struct {
+	Errors []*GraphQLError
+}
+
+func (e *PartialError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, ge := range e.Errors {
+		msgs[i] = ge.Message
+		if field := ge.field(); field != "" {
+			msgs[i] = field + ": " + ge.Message
+		}
+	}
+	return "partial data: " + strings.Join(msgs, "; ")
+}
+
+// Fields lists the paths of the fields that failed, e.g. myself.pods.2.runtime.
+func (e *PartialError) Fields() []string {
+	var fields []string
+	for _, ge := range e.Errors {
+		if field := ge.field(); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// IsPartial reports whether err is a PartialError, so the data returned with
+// it can be used.
+func IsPartial(err error) bool {
+	var partial *PartialError
+	return errors.As(err, &partial)
+}
+
+func (e *GraphQLError) field() string {
+	parts := make([]string, len(e.Path))
+	for i, p := range e.Path {
+		parts[i] = fmt.Sprint(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// total turns a PartialError into a plain error, for when the data that came
+// with it is not usable either.
+func total(err error) error {
+	if IsPartial(err) {
+		return errors.New(err.Error())
+	}
+	return err
+}
+
+// Query runs a graphql query or mutation and decodes its data into out.
+func (c *Client) Query(ctx context.Context, input Input, out interface{}) error {
+	body, err := json.Marshal(input)
 	if err != nil {
-		return
+		return err
 	}
+	apiKey, err := c.apiKey()
+	if err != nil {
+		return err
+	}
+	mutation := strings.HasPrefix(strings.TrimSpace(input.Query), "mutation")
+	// fetch decodes the response into out as it is read and, for identical
+	// reads to share, copies it to spool. complete reports whether the
+	// whole response made it there.
+	fetch := func(spool *os.File) (complete bool, err error) {
+		err = c.do(ctx, !mutation, func() (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, "POST", c.apiUrl()+"?api_key="+apiKey, bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Add("Content-Type", "application/json")
+			return req, nil
+		}, func(body io.Reader) error {
+			if spool == nil {
+				return decodeResponse(body, out)
+			}
+			// a retried response starts the copy over
+			if err := rewind(spool); err != nil {
+				return err
+			}
+			tee := io.TeeReader(body, spool)
+			err := decodeResponse(tee, out)
+			_, drainErr := io.Copy(io.Discard, tee)
+			complete = drainErr == nil
+			return err
+		})
+		return complete, err
+	}
+	if mutation {
+		_, err = fetch(nil)
+		forgetFlights()
+		return err
+	}
+	return c.singleFlight(ctx, flightKey(c.apiUrl(), apiKey, body), out, fetch)
+}
 
+// decodeResponse decodes a graphql response as it is read, with data going
+// straight into out rather than through an intermediate copy, so large lists
+// are never held in memory twice.
+func decodeResponse(r io.Reader, out interface{}) error {
+	v := reflect.ValueOf(out).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	dec := json.NewDecoder(r)
+	t, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if t != json.Delim('{') {
+		return fmt.Errorf("invalid response: %v", t)
+	}
+	var errs []*GraphQLError
+	data := &nullable{v: out}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "data":
+			err = dec.Decode(data)
+		case "errors":
+			err = dec.Decode(&errs)
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(errs) == 0 {
+		if !data.set {
+			return errors.New("data is nil")
+		}
+		return nil
+	}
+	// data with every field null, as failed mutations return, is no data
+	if !data.set || v.IsZero() {
+		return errors.New(errs[0].Message)
+	}
+	dropNils(v)
+	return &PartialError{errs}
+}
+
+// nullable decodes into v, and records whether the value was other than
+// null.
+type nullable struct {
+	v   interface{}
+	set bool
+}
+
+func (n *nullable) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	n.set = true
+	return json.Unmarshal(data, n.v)
+}
+
+// dropNils removes the nil items of the lists in v, which stand for the
+// items that failed in a partial response.
+func dropNils(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			dropNils(v.Elem())
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			// what an interface holds cannot be changed in place
+			item := reflect.New(v.Elem().Type()).Elem()
+			item.Set(v.Elem())
+			dropNils(item)
+			v.Set(item)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				dropNils(v.Field(i))
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			item := reflect.New(iter.Value().Type()).Elem()
+			item.Set(iter.Value())
+			dropNils(item)
+			v.SetMapIndex(iter.Key(), item)
+		}
+	case reflect.Slice:
+		kept := 0
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i)
+			switch item.Kind() {
+			case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+				if item.IsNil() {
+					continue
+				}
+			}
+			dropNils(item)
+			v.Index(kept).Set(item)
+			kept++
+		}
+		v.SetLen(kept)
+	}
+}
+
+// do sends the request built by newRequest, retrying transient failures,
+// and hands the body of the 200 response to read. The body fails with
+// ErrResponseTooLarge past MaxResponseBytes. When reading the body fails,
+// read is called again with the body of a retry, so it must start over.
+func (c *Client) do(ctx context.Context, idempotent bool, newRequest func() (*http.Request, error), read func(body io.Reader) error) error {
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return err
+		}
+		var sent int64
+		if req.GetBody != nil {
+			sent = req.ContentLength
+		}
+		client, err := c.httpClient()
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		res, err := client.Do(req)
+		recordStats(func(s *Stats) {
+			s.Calls++
+			s.BytesSent += sent
+			s.Latency += time.Since(start)
+		})
+
+		retryAfter := time.Duration(0)
+		retry := false
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			retry = idempotent
+		} else {
+			body := &failingBody{r: c.limit(&countingBody{res.Body})}
+			if res.StatusCode == 200 {
+				err = read(body)
+				res.Body.Close()
+				// a connection that breaks during the response is as
+				// transient as one that breaks before it
+				if err == nil || body.err == nil || errors.Is(body.err, ErrResponseTooLarge) || ctx.Err() != nil {
+					return err
+				}
+				retry = idempotent
+			} else {
+				rawData, readErr := io.ReadAll(io.LimitReader(body, 4096))
+				res.Body.Close()
+				err = fmt.Errorf("statuscode %d: %s", res.StatusCode, strings.TrimSpace(string(rawData)))
+				if readErr != nil {
+					err = readErr
+				}
+				switch {
+				case res.StatusCode == 429 || res.StatusCode == 503:
+					retry = true
+				case res.StatusCode >= 500:
+					retry = idempotent
+				}
+				if seconds, convErr := strconv.Atoi(res.Header.Get("Retry-After")); convErr == nil {
+					retryAfter = time.Duration(seconds) * time.Second
+				}
+			}
+		}
+		if !retry || attempt >= c.MaxRetries {
+			return err
+		}
+		if !c.takeRetry() {
+			return fmt.Errorf("%w after %d retries: %s", ErrRetryBudget, c.RetryBudget, err)
+		}
+
+		wait := c.backoff(attempt)
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		recordStats(func(s *Stats) { s.Retries++ })
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// takeRetry spends one retry of the budget, if any is left.
+func (c *Client) takeRetry() bool {
+	if c.RetryBudget <= 0 {
+		return true
+	}
+	c.budgetMu.Lock()
+	defer c.budgetMu.Unlock()
+	now := time.Now()
+	if c.refilledAt.IsZero() {
+		c.refilledAt = now
+	}
+	if c.RetryRefill > 0 {
+		refill := int(now.Sub(c.refilledAt) / c.RetryRefill)
+		if refill > c.retries {
+			refill = c.retries
+		}
+		c.retries -= refill
+		c.refilledAt = c.refilledAt.Add(time.Duration(refill) * c.RetryRefill)
+		if c.retries == 0 {
+			c.refilledAt = now
+		}
+	}
+	if c.retries >= c.RetryBudget {
+		return false
+	}
+	c.retries++
+	return true
+}
+
+// ErrRetryBudget is returned for failures once the client's RetryBudget is
+// spent: the api is failing too often for retries to help.
+var ErrRetryBudget = errors.New("api retry budget exhausted")
+
+// ErrResponseTooLarge is returned for a response body over
+// Client.MaxResponseBytes.
+var ErrResponseTooLarge = errors.New("api response too large")
+
+// limit caps body at MaxResponseBytes.
+func (c *Client) limit(body io.Reader) io.Reader {
+	if c.MaxResponseBytes <= 0 {
+		return body
+	}
+	return &limitedBody{r: body, max: c.MaxResponseBytes, remaining: c.MaxResponseBytes + 1}
+}
+
+// limitedBody fails once more than max bytes are read, unlike
+// io.LimitReader, which would silently truncate the json.
+type limitedBody struct {
+	r         io.Reader
+	max       int64
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.r.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining <= 0 {
+		return n, fmt.Errorf("%w: over %d bytes", ErrResponseTooLarge, b.max)
+	}
+	return n, err
+}
+
+// failingBody keeps the first error reading the body, other than its end,
+// so a read that failed can be told from a response that did not decode.
+type failingBody struct {
+	r   io.Reader
+	err error
+}
+
+func (b *failingBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err != nil && err != io.EOF && b.err == nil {
+		b.err = err
+	}
+	return n, err
+}
+
+// backoff returns a random wait of up to MinBackoff * 2^attempt, capped at MaxBackoff.
+func (c *Client) backoff(attempt int) time.Duration {
+	max := c.MinBackoff << uint(attempt)
+	if max > c.MaxBackoff || max <= 0 {
+		max = c.MaxBackoff
+	}
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max))) + 1
+}
+
+func (c *Client) apiUrl() string {
+	if c.ApiUrl != "" {
+		return c.ApiUrl
+	}
 	apiUrl := os.Getenv("RUNPOD_API_URL")
 	if apiUrl == "" {
-		apiUrl = viper.GetString("apiUrl")
+		apiUrl = viper.GetString(profile.Key("apiUrl"))
 	}
-	apiKey := os.Getenv("RUNPOD_API_KEY")
-	if apiKey == "" {
-		apiKey = viper.GetString("apiKey")
+	return apiUrl
+}
+
+func (c *Client) apiKey() (string, error) {
+	if c.ApiKey != "" {
+		return c.ApiKey, nil
 	}
-	req, err := http.NewRequest("POST", apiUrl+"?api_key="+apiKey, bytes.NewBuffer(jsonValue))
-	if err != nil {
-		return
+	if c.ApiKeySource != nil {
+		return c.ApiKeySource(), nil
 	}
-	req.Header.Add("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: time.Second * 10}
-	return client.Do(req)
+	return profile.ApiKey()
 }
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+)
+
+type podsData struct {
+	Myself *struct {
+		Pods []*Pod
+	}
+}
+
+// podsResponse is a get pods response with n pods, where every tenth pod
+// failed when partial.
+func podsResponse(b *testing.B, n int, partial bool) []byte {
+	b.Helper()
+	pods := make([]interface{}, n)
+	var errs []map[string]interface{}
+	for i := range pods {
+		pod := &Pod{
+			Id:                fmt.Sprintf("pod%05d", i),
+			Name:              fmt.Sprintf("worker-%d", i),
+			ImageName:         "runpod/pytorch:2.0.1-py3.10-cuda11.8.0-devel-ubuntu22.04",
+			DesiredStatus:     "RUNNING",
+			GpuCount:          1,
+			ContainerDiskInGb: 20,
+			VolumeInGb:        50,
+			CostPerHr:         0.44,
+			Env:               []string{"JUPYTER_PASSWORD=secret", "HF_HOME=/workspace/hf"},
+			Ports:             "8888/http,22/tcp",
+			LastStatusChange:  "Rented by User: Mon Oct 12 2026 10:00:00 GMT+0000 (Coordinated Universal Time)",
+			Machine:           &Machine{GpuDisplayName: "RTX 4090"},
+			Runtime: &Runtime{
+				UptimeInSeconds: 3600,
+				Ports:           []*RuntimePort{{Ip: "10.0.0.1", IsIpPublic: true, PrivatePort: 22, PublicPort: 40022, Type: "tcp"}},
+				Gpus:            []*GpuTelemetry{{Id: "gpu0", GpuUtilPercent: 90, MemoryUtilPercent: 70}},
+			},
+		}
+		pods[i] = pod
+		if partial && i%10 == 0 {
+			pods[i] = nil
+			errs = append(errs, map[string]interface{}{
+				"message": "pod unavailable",
+				"path":    []interface{}{"myself", "pods", i},
+			})
+		}
+	}
+	res := map[string]interface{}{
+		"data": map[string]interface{}{"myself": map[string]interface{}{"pods": pods}},
+	}
+	if partial {
+		res["errors"] = errs
+	}
+	raw, err := json.Marshal(res)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return raw
+}
+
+func benchmarkDecode(b *testing.B, raw []byte, decode func(r io.Reader, out interface{}) error) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(raw)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var data podsData
+		if err := decode(bytes.NewReader(raw), &data); err != nil && !IsPartial(err) {
+			b.Fatal(err)
+		}
+	}
+}
+
+// readAllDecode is how responses were decoded before they were streamed:
+// read whole, then unmarshaled.
+func readAllDecode(r io.Reader, out interface{}) error {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var res struct {
+		Data   json.RawMessage
+		Errors []*GraphQLError
+	}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return err
+	}
+	return json.Unmarshal(res.Data, out)
+}
+
+func BenchmarkDecodeResponse(b *testing.B) {
+	for _, n := range []int{10, 1000} {
+		raw := podsResponse(b, n, false)
+		b.Run(fmt.Sprintf("pods=%d", n), func(b *testing.B) {
+			benchmarkDecode(b, raw, decodeResponse)
+		})
+	}
+}
+
+func BenchmarkDecodeResponseReadAll(b *testing.B) {
+	for _, n := range []int{10, 1000} {
+		raw := podsResponse(b, n, false)
+		b.Run(fmt.Sprintf("pods=%d", n), func(b *testing.B) {
+			benchmarkDecode(b, raw, readAllDecode)
+		})
+	}
+}
+
+func BenchmarkDecodeResponsePartial(b *testing.B) {
+	raw := podsResponse(b, 1000, true)
+	benchmarkDecode(b, raw, decodeResponse)
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeApi answers the requests of a client with responses, in order, and
+// counts the requests.
+type fakeApi struct {
+	responses []func() (*http.Response, error)
+	calls     int
+}
+
+func (f *fakeApi) client(t *testing.T) *Client {
+	t.Helper()
+	// shared responses are kept under the home directory
+	t.Setenv("HOME", t.TempDir())
+	return &Client{
+		ApiUrl:     "http://api.test/graphql",
+		ApiKey:     "key",
+		MaxRetries: 3,
+		MinBackoff: time.Millisecond,
+		MaxBackoff: time.Millisecond,
+		HttpClient: TransportFunc(func(req *http.Request) (*http.Response, error) {
+			if f.calls >= len(f.responses) {
+				t.Fatalf("unexpected request %d", f.calls+1)
+			}
+			f.calls++
+			return f.responses[f.calls-1]()
+		}),
+	}
+}
+
+func respond(status int, body string) func() (*http.Response, error) {
+	return respondWith(status, strings.NewReader(body))
+}
+
+func respondWith(status int, body io.Reader) func() (*http.Response, error) {
+	return func() (*http.Response, error) {
+		return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(body)}, nil
+	}
+}
+
+// brokenBody returns data, then fails as a dropped connection does.
+type brokenBody struct {
+	data string
+}
+
+func (b *brokenBody) Read(p []byte) (int, error) {
+	if b.data == "" {
+		return 0, errors.New("connection reset by peer")
+	}
+	n := copy(p, b.data)
+	b.data = b.data[n:]
+	return n, nil
+}
+
+type podsResult struct {
+	Myself struct {
+		Pods []*struct {
+			Id string `json:"id"`
+		} `json:"pods"`
+	} `json:"myself"`
+}
+
+func podIds(data *podsResult) []string {
+	var ids []string
+	for _, p := range data.Myself.Pods {
+		ids = append(ids, p.Id)
+	}
+	return ids
+}
+
+const podsQuery = "query { myself { pods { id } } }"
+
+func TestQueryRetriesServerErrors(t *testing.T) {
+	f := &fakeApi{responses: []func() (*http.Response, error){
+		respond(503, "unavailable"),
+		func() (*http.Response, error) { return nil, errors.New("connection refused") },
+		respond(200, `{"data": {"myself": {"pods": [{"id": "a"}]}}}`),
+	}}
+	var data podsResult
+	if err := f.client(t).Query(context.Background(), Input{Query: podsQuery}, &data); err != nil {
+		t.Fatal(err)
+	}
+	if f.calls != 3 {
+		t.Errorf("calls = %d, want 3", f.calls)
+	}
+	if ids := podIds(&data); !reflect.DeepEqual(ids, []string{"a"}) {
+		t.Errorf("pods = %v, want [a]", ids)
+	}
+}
+
+func TestQueryRetriesBrokenBody(t *testing.T) {
+	f := &fakeApi{responses: []func() (*http.Response, error){
+		respondWith(200, &brokenBody{data: `{"data": {"myself": {"pods": [{"id": "a"}, {"id": "b"}`}),
+		respond(200, `{"data": {"myself": {"pods": [{"id": "a"}, {"id": "b"}, {"id": "c"}]}}}`),
+	}}
+	var data podsResult
+	if err := f.client(t).Query(context.Background(), Input{Query: podsQuery}, &data); err != nil {
+		t.Fatal(err)
+	}
+	if f.calls != 2 {
+		t.Errorf("calls = %d, want 2", f.calls)
+	}
+	// the pods of the broken response are not kept
+	if ids := podIds(&data); !reflect.DeepEqual(ids, []string{"a", "b", "c"}) {
+		t.Errorf("pods = %v, want [a b c]", ids)
+	}
+}
+
+func TestQueryDoesNotRetryMutations(t *testing.T) {
+	f := &fakeApi{responses: []func() (*http.Response, error){
+		respond(500, "internal error"),
+	}}
+	var data struct{ PodStop *struct{ Id string } }
+	err := f.client(t).Query(context.Background(), Input{Query: `mutation { podStop(input: {podId: "a"}) { id } }`}, &data)
+	if err == nil || !strings.Contains(err.Error(), "statuscode 500") {
+		t.Errorf("err = %v, want statuscode 500", err)
+	}
+	if f.calls != 1 {
+		t.Errorf("calls = %d, want 1", f.calls)
+	}
+}
+
+func TestQueryPartialError(t *testing.T) {
+	f := &fakeApi{responses: []func() (*http.Response, error){
+		respond(200, `{
+			"data": {"myself": {"pods": [{"id": "a"}, null, {"id": "c"}]}},
+			"errors": [{"message": "pod unavailable", "path": ["myself", "pods", 1]}]
+		}`),
+	}}
+	var data podsResult
+	err := f.client(t).Query(context.Background(), Input{Query: podsQuery}, &data)
+	if !IsPartial(err) {
+		t.Fatalf("err = %v, want a PartialError", err)
+	}
+	var partial *PartialError
+	errors.As(err, &partial)
+	if fields := partial.Fields(); !reflect.DeepEqual(fields, []string{"myself.pods.1"}) {
+		t.Errorf("fields = %v, want [myself.pods.1]", fields)
+	}
+	if ids := podIds(&data); !reflect.DeepEqual(ids, []string{"a", "c"}) {
+		t.Errorf("pods = %v, want [a c]", ids)
+	}
+}
+
+func TestQueryErrorWithoutData(t *testing.T) {
+	f := &fakeApi{responses: []func() (*http.Response, error){
+		respond(200, `{"data": {"podStop": null}, "errors": [{"message": "pod not found", "path": ["podStop"]}]}`),
+	}}
+	var data struct{ PodStop *struct{ Id string } }
+	err := f.client(t).Query(context.Background(), Input{Query: `mutation { podStop(input: {podId: "a"}) { id } }`}, &data)
+	if err == nil || IsPartial(err) || err.Error() != "pod not found" {
+		t.Errorf("err = %v, want pod not found", err)
+	}
+}
+
+func TestQuerySharedResponseExpires(t *testing.T) {
+	f := &fakeApi{responses: []func() (*http.Response, error){
+		respond(200, `{"data": {"myself": {"pods": [{"id": "a"}]}}}`),
+	}}
+	c := f.client(t)
+	c.DedupWindow = 50 * time.Millisecond
+	var data podsResult
+	if err := c.Query(context.Background(), Input{Query: podsQuery}, &data); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := flightDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := func() []string {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		return files
+	}
+	if files := saved(); len(files) != 1 {
+		t.Fatalf("saved responses = %v, want one", files)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if files := saved(); len(files) != 0 {
+		t.Errorf("saved responses = %v after the window, want none", files)
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"fmt"
+)
+
+// RegistryAuth is a saved container registry credential. Pods and templates
+// reference it by id to pull private images; the password is never returned.
+type RegistryAuth struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (c *Client) GetRegistryAuths(ctx context.Context) ([]*RegistryAuth, error) {
+	input := Input{
+		Query: `
+		query myRegistryAuths {
+			myself {
+			  containerRegistryCreds {
+				id
+				name
+			  }
+			}
+		  }
+		`,
+	}
+	var data struct {
+		Myself *struct {
+			ContainerRegistryCreds []*RegistryAuth
+		}
+	}
+	err := c.Query(ctx, input, &data)
+	if data.Myself == nil {
+		if err == nil {
+			err = fmt.Errorf("myself is nil")
+		}
+		return nil, total(err)
+	}
+	return data.Myself.ContainerRegistryCreds, err
+}
+
+// GetRegistryAuth returns one of my registry credentials by id or name. A
+// name several credentials share is refused.
+func (c *Client) GetRegistryAuth(ctx context.Context, idOrName string) (*RegistryAuth, error) {
+	auths, err := c.GetRegistryAuths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var named []*RegistryAuth
+	for _, a := range auths {
+		if a.Id == idOrName {
+			return a, nil
+		}
+		if a.Name == idOrName {
+			named = append(named, a)
+		}
+	}
+	switch len(named) {
+	case 0:
+		return nil, fmt.Errorf(`registry auth "%s" not found`, idOrName)
+	case 1:
+		return named[0], nil
+	}
+	return nil, fmt.Errorf(`%d registry auths are named "%s"; use the id`, len(named), idOrName)
+}
+
+type CreateRegistryAuthInput struct {
+	Name     string `json:"name"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (c *Client) CreateRegistryAuth(ctx context.Context, authInput *CreateRegistryAuthInput) (*RegistryAuth, error) {
+	input := Input{
+		Query: `
+		mutation saveRegistryAuth($input: SaveRegistryAuthInput!) {
+			saveRegistryAuth(input: $input) {
+				id
+				name
+			}
+		}
+		`,
+		Variables: map[string]interface{}{"input": authInput},
+	}
+	var data struct {
+		SaveRegistryAuth *RegistryAuth
+	}
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
+	}
+	if data.SaveRegistryAuth == nil {
+		return nil, fmt.Errorf("registry auth is nil")
+	}
+	return data.SaveRegistryAuth, nil
+}
+
+func (c *Client) DeleteRegistryAuth(ctx context.Context, id string) error {
+	input := Input{
+		Query: `
+		mutation deleteRegistryAuth($registryAuthId: String!) {
+			deleteRegistryAuth(registryAuthId: $registryAuthId)
+		}
+		`,
+		Variables: map[string]interface{}{"registryAuthId": id},
+	}
+	var data struct {
+		DeleteRegistryAuth interface{}
+	}
+	return c.Query(ctx, input, &data)
+}
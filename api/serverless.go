@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+const serverlessUrl = "https://api.runpod.ai/v2"
+
+type EndpointHealth struct {
+	Jobs    *EndpointJobs    `json:"jobs"`
+	Workers *EndpointWorkers `json:"workers"`
+}
+type EndpointJobs struct {
+	Completed  int `json:"completed"`
+	Failed     int `json:"failed"`
+	InProgress int `json:"inProgress"`
+	InQueue    int `json:"inQueue"`
+	Retried    int `json:"retried"`
+}
+type EndpointWorkers struct {
+	Idle    int `json:"idle"`
+	Running int `json:"running"`
+}
+
+// serverlessGet calls the serverless REST api of an endpoint.
+func (c *Client) serverlessGet(ctx context.Context, endpointId string, path string, out interface{}) error {
+	baseUrl := c.ServerlessUrl
+	if baseUrl == "" {
+		baseUrl = os.Getenv("RUNPOD_SERVERLESS_URL")
+	}
+	if baseUrl == "" {
+		baseUrl = serverlessUrl
+	}
+	apiKey, err := c.apiKey()
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, true, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", baseUrl+"/"+endpointId+"/"+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Add("Authorization", "Bearer "+apiKey)
+		return req, nil
+	}, func(body io.Reader) error {
+		return json.NewDecoder(body).Decode(out)
+	})
+}
+
+func (c *Client) GetEndpointHealth(ctx context.Context, endpointId string) (*EndpointHealth, error) {
+	health := &EndpointHealth{}
+	if err := c.serverlessGet(ctx, endpointId, "health", health); err != nil {
+		return nil, err
+	}
+	if health.Jobs == nil || health.Workers == nil {
+		return nil, fmt.Errorf("endpoint %s health is incomplete", endpointId)
+	}
+	return health, nil
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// staleFlight is the age past which a lock left by a crashed process is
+// ignored.
+const staleFlight = time.Minute
+
+// flight is a read in progress that identical concurrent reads wait for.
+// The response is shared through file, which stays open until the waiters
+// are done with it.
+type flight struct {
+	done    chan struct{}
+	file    *os.File
+	err     error
+	waiters sync.WaitGroup
+}
+
+// fetchFunc runs a read, copying its response to spool when it is not nil,
+// and reports whether all of the response was copied.
+type fetchFunc func(spool *os.File) (complete bool, err error)
+
+var (
+	flightsMu sync.Mutex
+	flights   = make(map[string]*flight)
+)
+
+// flightKey identifies a read by endpoint, account and query.
+func flightKey(apiUrl, apiKey string, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", apiUrl, apiKey)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// singleFlight makes identical concurrent reads share one api request: in
+// this process, later callers wait for the first one; across processes, the
+// first takes a lock file and the others reuse the response it leaves for
+// DedupWindow. The response is shared as a file, so the first caller decodes
+// it into out as it arrives and the others decode their own copy from disk.
+func (c *Client) singleFlight(ctx context.Context, key string, out interface{}, fetch fetchFunc) error {
+	flightsMu.Lock()
+	if f, ok := flights[key]; ok {
+		f.waiters.Add(1)
+		flightsMu.Unlock()
+		defer f.waiters.Done()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if f.file == nil {
+			if f.err != nil && !errors.Is(f.err, context.Canceled) && !errors.Is(f.err, context.DeadlineExceeded) {
+				return f.err
+			}
+			// the response could not be kept, or the first caller gave up
+			// on it, so this read is made again
+			_, err := fetch(nil)
+			return err
+		}
+		recordStats(func(s *Stats) { s.CacheHits++ })
+		return decodeFile(f.file, out)
+	}
+	f := &flight{done: make(chan struct{})}
+	flights[key] = f
+	flightsMu.Unlock()
+
+	file, release, err := c.sharedFetch(ctx, key, out, fetch)
+	f.file, f.err = file, err
+	flightsMu.Lock()
+	delete(flights, key)
+	flightsMu.Unlock()
+	close(f.done)
+	if release != nil {
+		// the waiters only decode the file, so this is not long
+		f.waiters.Wait()
+		release()
+	}
+	return err
+}
+
+// sharedFetch decodes into out the response another runpodctl process just
+// saved for the same read, or else the one fetch gets. It returns the file
+// holding the response, if any, for the callers waiting in this process,
+// and a func that lets go of it once they are done.
+func (c *Client) sharedFetch(ctx context.Context, key string, out interface{}, fetch fetchFunc) (*os.File, func(), error) {
+	dir, err := flightDir()
+	if err != nil {
+		_, err := fetch(nil)
+		return nil, nil, err
+	}
+	if c.DedupWindow <= 0 {
+		return spoolFetch(dir, "", 0, fetch)
+	}
+	expireFlights(dir, c.DedupWindow)
+	result := filepath.Join(dir, key+".json")
+	lock := filepath.Join(dir, key+".lock")
+	deadline := time.Now().Add(c.timeout())
+	for {
+		if file, ok := recent(result, c.DedupWindow); ok {
+			recordStats(func(s *Stats) { s.CacheHits++ })
+			return file, func() { file.Close() }, decodeFile(file, out)
+		}
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			defer os.Remove(lock)
+			return spoolFetch(dir, result, c.DedupWindow, fetch)
+		}
+		if !errors.Is(err, os.ErrExist) || time.Now().After(deadline) {
+			return spoolFetch(dir, "", 0, fetch)
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > staleFlight {
+			os.Remove(lock)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// spoolFetch runs fetch with a spool file in dir, which it keeps if the
+// whole response made it there, and saves it at result for other processes
+// for window when result is set.
+func spoolFetch(dir string, result string, window time.Duration, fetch fetchFunc) (*os.File, func(), error) {
+	spool, err := os.CreateTemp(dir, "spool-*")
+	if err != nil {
+		_, err := fetch(nil)
+		return nil, nil, err
+	}
+	release := func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}
+	complete, err := fetch(spool)
+	if !complete {
+		release()
+		return nil, nil, err
+	}
+	if result != "" {
+		saveFlight(dir, result, spool, window)
+	}
+	return spool, release, err
+}
+
+// decodeFile decodes the response saved in file, which may be shared, so
+// it is read by offset.
+func decodeFile(file *os.File, out interface{}) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	return decodeResponse(io.NewSectionReader(file, 0, info.Size()), out)
+}
+
+// rewind empties a spool file for a response to be copied to it again.
+func rewind(spool *os.File) error {
+	if err := spool.Truncate(0); err != nil {
+		return err
+	}
+	_, err := spool.Seek(0, io.SeekStart)
+	return err
+}
+
+// forgetFlights drops the shared responses after a mutation so no process
+// reads state from before it.
+func forgetFlights() {
+	dir, err := flightDir()
+	if err != nil {
+		return
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, f := range files {
+		os.Remove(f)
+	}
+}
+
+func flightDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(home, ".runpod", "flights")
+	return dir, os.MkdirAll(dir, 0700)
+}
+
+// recent opens the response saved at path if it is younger than window.
+func recent(path string, window time.Duration) (*os.File, bool) {
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > window {
+		return nil, false
+	}
+	file, err := os.Open(path)
+	return file, err == nil
+}
+
+// expireFlights removes the saved responses older than window. Responses
+// can hold secrets, such as the env of pods, so they are only kept on disk
+// as long as they may be reused.
+func expireFlights(dir string, window time.Duration) {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil && time.Since(info.ModTime()) > window {
+			os.Remove(f)
+		}
+	}
+}
+
+// saveFlight copies a response for other processes for window, and removes
+// the files left by crashed processes. A process that exits within window
+// leaves its response to be removed by the next shared read.
+func saveFlight(dir string, path string, spool *os.File, window time.Duration) {
+	info, err := spool.Stat()
+	if err != nil {
+		return
+	}
+	tmp := fmt.Sprintf("%s.%d", path, os.Getpid())
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return
+	}
+	_, err = io.Copy(f, io.NewSectionReader(spool, 0, info.Size()))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return
+	}
+	time.AfterFunc(window, func() { expireFlights(dir, window) })
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil && time.Since(info.ModTime()) > staleFlight {
+			os.Remove(f)
+		}
+	}
+}
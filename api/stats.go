@@ -0,0 +1,46 @@
+package api
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Stats summarizes the API traffic of the current process.
+type Stats struct {
+	Calls         int
+	BytesSent     int64
+	BytesReceived int64
+	CacheHits     int
+	Retries       int
+	Latency       time.Duration
+}
+
+var (
+	statsMu sync.Mutex
+	stats   Stats
+)
+
+// GetStats returns a snapshot of the API traffic so far.
+func GetStats() Stats {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	return stats
+}
+
+func recordStats(f func(s *Stats)) {
+	statsMu.Lock()
+	f(&stats)
+	statsMu.Unlock()
+}
+
+// countingBody counts the response bytes read by callers.
+type countingBody struct {
+	io.ReadCloser
+}
+
+func (b *countingBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	recordStats(func(s *Stats) { s.BytesReceived += int64(n) })
+	return
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// ErrSubscriptionsUnsupported is returned by Subscribe when the api does not
+// accept graphql subscriptions. Callers fall back to polling.
+var ErrSubscriptionsUnsupported = errors.New("the api does not support subscriptions")
+
+// Subscription is a graphql subscription and the callbacks that consume it.
+type Subscription struct {
+	Input Input
+	// OnConnect runs every time the subscription is established, including
+	// after a reconnect, before any event is delivered. Use it to resume:
+	// fetch the state that changed while disconnected.
+	OnConnect func() error
+	// OnData receives the data of every event. Returning an error ends the
+	// subscription with that error.
+	OnData func(data json.RawMessage) error
+}
+
+// wsMessage is a graphql-transport-ws protocol message.
+type wsMessage struct {
+	Id      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Subscribe runs a subscription over a websocket with the graphql-transport-ws
+// protocol until ctx ends or a callback fails. Dropped connections are
+// re-established with the client's backoff. It returns
+// ErrSubscriptionsUnsupported when the first connection is refused.
+func (c *Client) Subscribe(ctx context.Context, s *Subscription) error {
+	connected, delivered := false, false
+	onData := func(data json.RawMessage) error {
+		delivered = true
+		return s.OnData(data)
+	}
+	for attempt := 0; ; attempt++ {
+		ws, err := c.subscribe(ctx, s.Input)
+		if err == nil {
+			connected = true
+			attempt = 0
+			err = s.OnConnect()
+			if err == nil {
+				err = c.receive(ctx, ws, onData)
+			}
+			ws.Close()
+			var rejected *rejectedError
+			if errors.As(err, &rejected) && !delivered {
+				// a server without the subscription rejects it up front
+				return fmt.Errorf("%w: %s", ErrSubscriptionsUnsupported, err)
+			}
+			var dropped *droppedError
+			if !errors.As(err, &dropped) {
+				return err
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !connected {
+			return fmt.Errorf("%w: %s", ErrSubscriptionsUnsupported, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.backoff(attempt)):
+		}
+	}
+}
+
+// droppedError marks a lost connection that Subscribe reconnects after.
+type droppedError struct {
+	err error
+}
+
+func (e *droppedError) Error() string {
+	return "subscription dropped: " + e.err.Error()
+}
+
+// rejectedError is an error message from the server for the subscription.
+type rejectedError struct {
+	message string
+}
+
+func (e *rejectedError) Error() string {
+	return e.message
+}
+
+// subscribe opens the websocket, initializes the connection and starts the
+// subscription.
+func (c *Client) subscribe(ctx context.Context, input Input) (*websocket.Conn, error) {
+	u, err := url.Parse(c.apiUrl())
+	if err != nil {
+		return nil, err
+	}
+	apiKey, err := c.apiKey()
+	if err != nil {
+		return nil, err
+	}
+	origin := u.Scheme + "://" + u.Host
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	u.RawQuery = url.Values{"api_key": {apiKey}}.Encode()
+	config, err := websocket.NewConfig(u.String(), origin)
+	if err != nil {
+		return nil, err
+	}
+	config.Protocol = []string{"graphql-transport-ws"}
+	ws, err := c.dialWebsocket(config)
+	if err != nil {
+		return nil, err
+	}
+	stop := closeOnDone(ctx, ws)
+	defer stop()
+	ws.SetDeadline(time.Now().Add(c.timeout())) //nolint
+
+	init, _ := json.Marshal(map[string]string{"apiKey": apiKey})
+	if err = websocket.JSON.Send(ws, wsMessage{Type: "connection_init", Payload: init}); err != nil {
+		ws.Close()
+		return nil, err
+	}
+	var ack wsMessage
+	if err = websocket.JSON.Receive(ws, &ack); err != nil {
+		ws.Close()
+		return nil, err
+	}
+	if ack.Type != "connection_ack" {
+		ws.Close()
+		return nil, fmt.Errorf("expected connection_ack, got %s", ack.Type)
+	}
+	payload, err := json.Marshal(input)
+	if err != nil {
+		ws.Close()
+		return nil, err
+	}
+	if err = websocket.JSON.Send(ws, wsMessage{Id: "1", Type: "subscribe", Payload: payload}); err != nil {
+		ws.Close()
+		return nil, err
+	}
+	ws.SetDeadline(time.Time{}) //nolint
+	return ws, nil
+}
+
+// receive delivers events until the subscription ends. Errors from the
+// server end it; a broken connection is returned as a droppedError.
+func (c *Client) receive(ctx context.Context, ws *websocket.Conn, onData func(json.RawMessage) error) error {
+	stop := closeOnDone(ctx, ws)
+	defer stop()
+	for {
+		var msg wsMessage
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return &droppedError{err}
+		}
+		switch msg.Type {
+		case "ping":
+			if err := websocket.JSON.Send(ws, wsMessage{Type: "pong"}); err != nil {
+				return &droppedError{err}
+			}
+		case "next":
+			var res struct {
+				Data   json.RawMessage `json:"data"`
+				Errors []*GraphQLError `json:"errors"`
+			}
+			if err := json.Unmarshal(msg.Payload, &res); err != nil {
+				return err
+			}
+			if len(res.Errors) > 0 {
+				return errors.New(res.Errors[0].Message)
+			}
+			if err := onData(res.Data); err != nil {
+				return err
+			}
+		case "error":
+			var errs []*GraphQLError
+			if err := json.Unmarshal(msg.Payload, &errs); err != nil || len(errs) == 0 {
+				return &rejectedError{fmt.Sprintf("subscription failed: %s", msg.Payload)}
+			}
+			return &rejectedError{errs[0].Message}
+		case "complete":
+			return &droppedError{errors.New("completed by the server")}
+		}
+	}
+}
+
+// closeOnDone closes ws when ctx ends so blocked reads return.
+func closeOnDone(ctx context.Context, ws *websocket.Conn) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			ws.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type Template struct {
+	Id                string    `json:"id"`
+	Name              string    `json:"name"`
+	ImageName         string    `json:"imageName"`
+	ContainerDiskInGb int       `json:"containerDiskInGb"`
+	VolumeInGb        int       `json:"volumeInGb"`
+	VolumeMountPath   string    `json:"volumeMountPath"`
+	DockerArgs        string    `json:"dockerArgs"`
+	Env               []*PodEnv `json:"env"`
+	Ports             string    `json:"ports"`
+	Readme            string    `json:"readme"`
+	IsServerless      bool      `json:"isServerless"`
+}
+
+const templateFields = `
+				id
+				name
+				imageName
+				containerDiskInGb
+				volumeInGb
+				volumeMountPath
+				dockerArgs
+				env {
+				  key
+				  value
+				}
+				ports
+				readme
+				isServerless
+`
+
+func (c *Client) GetTemplates(ctx context.Context) ([]*Template, error) {
+	input := Input{
+		Query: `
+		query myTemplates {
+			myself {
+			  podTemplates {
+				` + templateFields + `
+			  }
+			}
+		  }
+		`,
+	}
+	var data struct {
+		Myself *struct {
+			PodTemplates []*Template
+		}
+	}
+	err := c.Query(ctx, input, &data)
+	if data.Myself == nil {
+		if err == nil {
+			err = fmt.Errorf("myself is nil")
+		}
+		return nil, total(err)
+	}
+	return data.Myself.PodTemplates, err
+}
+
+// GetTemplate returns one of my templates by id or name.
+func (c *Client) GetTemplate(ctx context.Context, idOrName string) (*Template, error) {
+	templates, err := c.GetTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range templates {
+		if t.Id == idOrName || t.Name == idOrName {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf(`template "%s" not found`, idOrName)
+}
+
+type SaveTemplateInput struct {
+	Id                string    `json:"id,omitempty"`
+	ContainerDiskInGb int       `json:"containerDiskInGb"`
+	DockerArgs        string    `json:"dockerArgs"`
+	Env               []*PodEnv `json:"env"`
+	ImageName         string    `json:"imageName"`
+	IsServerless      bool      `json:"isServerless"`
+	Name              string    `json:"name"`
+	Ports             string    `json:"ports"`
+	Readme            string    `json:"readme"`
+	VolumeInGb        int       `json:"volumeInGb"`
+	VolumeMountPath   string    `json:"volumeMountPath"`
+}
+
+func (c *Client) CreateTemplate(ctx context.Context, templateInput *SaveTemplateInput) (*Template, error) {
+	templateInput.Id = ""
+	return c.saveTemplate(ctx, templateInput)
+}
+
+func (c *Client) UpdateTemplate(ctx context.Context, templateInput *SaveTemplateInput) (*Template, error) {
+	if templateInput.Id == "" {
+		return nil, errors.New("template id is required")
+	}
+	return c.saveTemplate(ctx, templateInput)
+}
+
+func (c *Client) saveTemplate(ctx context.Context, templateInput *SaveTemplateInput) (*Template, error) {
+	input := Input{
+		Query: `
+		mutation saveTemplate($input: SaveTemplateInput) {
+			saveTemplate(input: $input) {
+				` + templateFields + `
+			}
+		}
+		`,
+		Variables: map[string]interface{}{"input": templateInput},
+	}
+	var data struct {
+		SaveTemplate *Template
+	}
+	if err := c.Query(ctx, input, &data); err != nil {
+		return nil, err
+	}
+	if data.SaveTemplate == nil {
+		return nil, fmt.Errorf("template is nil")
+	}
+	return data.SaveTemplate, nil
+}
+
+func (c *Client) DeleteTemplate(ctx context.Context, name string) error {
+	input := Input{
+		Query: `
+		mutation deleteTemplate($templateName: String!) {
+			deleteTemplate(templateName: $templateName)
+		}
+		`,
+		Variables: map[string]interface{}{"templateName": name},
+	}
+	var data struct {
+		DeleteTemplate interface{}
+	}
+	return c.Query(ctx, input, &data)
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"regexp"
+	"time"
+)
+
+// statusChangeTime matches the date in a lastStatusChange such as
+// "Rented by User: Tue Oct 13 2026 10:00:00 GMT+0000 (Coordinated Universal Time)".
+var statusChangeTime = regexp.MustCompile(`\w{3} \w{3} \d{1,2} \d{4} \d{2}:\d{2}:\d{2} GMT[+-]\d{4}`)
+
+// parseStatusChange returns the time in a lastStatusChange, or the zero time.
+func parseStatusChange(s string) time.Time {
+	match := statusChangeTime.FindString(s)
+	if match == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse("Mon Jan 2 2006 15:04:05 GMT-0700", match)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// UnmarshalJSON decodes a pod and parses its status change and start times.
+func (p *Pod) UnmarshalJSON(b []byte) error {
+	type pod Pod
+	if err := json.Unmarshal(b, (*pod)(p)); err != nil {
+		return err
+	}
+	p.StatusChangedAt = parseStatusChange(p.LastStatusChange)
+	if p.Runtime != nil && p.Runtime.UptimeInSeconds > 0 {
+		p.StartedAt = time.Now().Add(-time.Duration(p.Runtime.UptimeInSeconds) * time.Second).Truncate(time.Second)
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a worker and parses its status change time.
+func (w *EndpointWorker) UnmarshalJSON(b []byte) error {
+	type worker EndpointWorker
+	if err := json.Unmarshal(b, (*worker)(w)); err != nil {
+		return err
+	}
+	w.StatusChangedAt = parseStatusChange(w.LastStatusChange)
+	return nil
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"bufio"
+	"cli/profile"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"golang.org/x/net/websocket"
+)
+
+// Transport sends the api requests. *http.Client implements it, so programs
+// embedding the client can pass their own, and tests a fake that answers
+// without a network.
+type Transport interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// TransportFunc adapts a function to a Transport.
+type TransportFunc func(req *http.Request) (*http.Response, error)
+
+func (f TransportFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// httpClient returns HttpClient, or else a client built once from the proxy
+// and tls settings. Debug builds wrap it in the faults of RUNPOD_FAULTS.
+func (c *Client) httpClient() (Transport, error) {
+	if c.HttpClient != nil {
+		return withFaults(c.HttpClient)
+	}
+	c.defaultOnce.Do(func() {
+		tlsConfig, err := c.tlsConfig()
+		if err != nil {
+			c.defaultErr = err
+			return
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = c.proxy
+		transport.TLSClientConfig = tlsConfig
+		c.defaultClient = &http.Client{Timeout: c.timeout(), Transport: transport}
+	})
+	if c.defaultErr != nil {
+		return nil, c.defaultErr
+	}
+	return withFaults(c.defaultClient)
+}
+
+func (c *Client) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return 10 * time.Second
+}
+
+// proxy returns the proxy config, or else the proxy from HTTPS_PROXY,
+// HTTP_PROXY and NO_PROXY.
+func (c *Client) proxy(req *http.Request) (*url.URL, error) {
+	if p := viper.GetString(profile.Key("proxy")); p != "" {
+		return url.Parse(p)
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// tlsConfig returns TLSConfig, or else one that also trusts the certificates
+// in the caFile config, as needed behind a tls-intercepting proxy.
+func (c *Client) tlsConfig() (*tls.Config, error) {
+	if c.TLSConfig != nil {
+		return c.TLSConfig, nil
+	}
+	config := &tls.Config{InsecureSkipVerify: viper.GetBool(profile.Key("insecureSkipVerify"))} //nolint:gosec
+	caFile := viper.GetString(profile.Key("caFile"))
+	if caFile == "" {
+		return config, nil
+	}
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in caFile %s", caFile)
+	}
+	config.RootCAs = pool
+	return config, nil
+}
+
+// dialWebsocket opens a websocket with the same proxy and tls settings as
+// the http requests.
+func (c *Client) dialWebsocket(config *websocket.Config) (*websocket.Conn, error) {
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.TlsConfig = tlsConfig
+	config.Dialer = &net.Dialer{Timeout: c.timeout()}
+
+	// the proxy is chosen for the http url the websocket upgrades from
+	location := *config.Location
+	location.Scheme = strings.Replace(location.Scheme, "ws", "http", 1)
+	proxyUrl, err := c.proxy(&http.Request{URL: &location})
+	if err != nil {
+		return nil, err
+	}
+	if proxyUrl == nil {
+		return websocket.DialConfig(config)
+	}
+
+	secure := config.Location.Scheme == "wss"
+	conn, err := c.connect(proxyUrl, hostPort(config.Location.Host, secure))
+	if err != nil {
+		return nil, err
+	}
+	if secure {
+		tc := tlsConfig.Clone()
+		if tc.ServerName == "" {
+			tc.ServerName = config.Location.Hostname()
+		}
+		tlsConn := tls.Client(conn, tc)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ws, nil
+}
+
+// connect opens a tunnel to address through an http proxy.
+func (c *Client) connect(proxyUrl *url.URL, address string) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", hostPort(proxyUrl.Host, proxyUrl.Scheme == "https"), c.timeout())
+	if err != nil {
+		return nil, err
+	}
+	if proxyUrl.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyUrl.Hostname()})
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	req := fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", address, address)
+	if u := proxyUrl.User; u != nil {
+		password, _ := u.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(u.Username() + ":" + password))
+		req += "Proxy-Authorization: Basic " + auth + "\r\n"
+	}
+	conn.SetDeadline(time.Now().Add(c.timeout())) //nolint
+	if _, err := conn.Write([]byte(req + "\r\n")); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// the body of a CONNECT response is the tunnel, so it is not read
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, &http.Request{Method: "CONNECT"})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if res.StatusCode != 200 {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s refused the connection: %s", proxyUrl.Host, res.Status)
+	}
+	conn.SetDeadline(time.Time{}) //nolint
+	return &tunnel{Conn: conn, r: br}, nil
+}
+
+// tunnel reads through the reader the proxy response was read with, which
+// may hold the first bytes from the other end.
+type tunnel struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (t *tunnel) Read(p []byte) (int, error) {
+	return t.r.Read(p)
+}
+
+// hostPort adds the default port to host when it has none.
+func hostPort(host string, secure bool) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	if secure {
+		return net.JoinHostPort(host, "443")
+	}
+	return net.JoinHostPort(host, "80")
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetPublicKeys returns the ssh public keys of the account, one per line as
+// in an authorized_keys file.
+func (c *Client) GetPublicKeys(ctx context.Context) (string, error) {
+	input := Input{
+		Query: `
+		query myPubKey {
+			myself {
+			  pubKey
+			}
+		  }
+		`,
+	}
+	var data struct {
+		Myself *struct {
+			PubKey string
+		}
+	}
+	if err := c.Query(ctx, input, &data); err != nil {
+		return "", err
+	}
+	if data.Myself == nil {
+		return "", fmt.Errorf("myself is nil")
+	}
+	return data.Myself.PubKey, nil
+}
+
+// UpdatePublicKeys replaces the ssh public keys of the account. New pods get
+// them in their authorized_keys.
+func (c *Client) UpdatePublicKeys(ctx context.Context, keys string) error {
+	input := Input{
+		Query: `
+		mutation updateUserSettings($input: UserSettingsInput!) {
+			updateUserSettings(input: $input) {
+				id
+			}
+		}
+		`,
+		Variables: map[string]interface{}{"input": map[string]string{"pubKey": keys}},
+	}
+	var data struct {
+		UpdateUserSettings interface{}
+	}
+	return c.Query(ctx, input, &data)
+}
@@ -0,0 +1,266 @@
+package availability
+
+import (
+	"cli/api"
+	"cli/history"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Action is the history action availability changes are recorded under.
+const Action = "availability"
+
+// The states recorded for a resource. Time up or down counts towards its
+// availability, and a preempted spot pod is down until it runs again, for
+// up to MaxPreempted; stopped and terminated resources are left out. Restarted marks a pod that
+// came back up on its own between two observations.
+const (
+	Up         = "up"
+	Down       = "down"
+	Preempted  = "preempted"
+	Restarted  = "restarted"
+	Stopped    = "stopped"
+	Terminated = "terminated"
+)
+
+// MaxPreempted is the longest a preempted spot pod counts as down. One that
+// stays stopped longer is taken as no longer meant to run.
+const MaxPreempted = 24 * time.Hour
+
+// Tracker turns periodic observations of pods and endpoints into history
+// entries, recording only the changes.
+type Tracker struct {
+	last map[string]*observation
+}
+
+type observation struct {
+	state  string
+	uptime int
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{last: make(map[string]*observation)}
+}
+
+// Pods returns the entries for the pods whose state changed since the last
+// call, and for the ones that are gone. stopped holds the pods stopped on
+// purpose, as history.StoppedOnPurpose returns them.
+func (t *Tracker) Pods(pods []*api.Pod, stopped map[string]bool) []*history.Entry {
+	var entries []*history.Entry
+	present := make(map[string]bool, len(pods))
+	for _, p := range pods {
+		key := "pod/" + p.Id
+		present[key] = true
+		prev := t.last[key]
+		state := podState(p, prev, stopped[p.Id])
+		uptime := 0
+		if p.Runtime != nil {
+			uptime = p.Runtime.UptimeInSeconds
+		}
+		entry := &history.Entry{Action: Action, PodId: p.Id, Name: p.Name, Status: state}
+		switch {
+		case prev == nil || prev.state != state:
+			entries = append(entries, entry)
+		case state == Up && uptime < prev.uptime:
+			entry.Status = Restarted
+			entries = append(entries, entry)
+		}
+		t.last[key] = &observation{state: state, uptime: uptime}
+	}
+	return append(entries, t.gone("pod/", present)...)
+}
+
+// podState is up for a running pod, down for one that should run but whose
+// container is not up, and preempted for a spot pod that stopped while it
+// was meant to run, unless it was stopped on purpose.
+func podState(p *api.Pod, prev *observation, stopped bool) string {
+	switch {
+	case p.DesiredStatus == "RUNNING" && p.Runtime != nil:
+		return Up
+	case p.DesiredStatus == "RUNNING":
+		return Down
+	case p.PodType == "INTERRUPTABLE" && !stopped && prev != nil && (prev.state == Up || prev.state == Down || prev.state == Preempted):
+		return Preempted
+	default:
+		return Stopped
+	}
+}
+
+// Endpoints returns the entries for the endpoints whose state changed since
+// the last call, and for the ones that are gone. An endpoint is down while
+// jobs wait in its queue with no worker to take them; endpoints missing
+// from health, e.g. because the health check failed, keep their state.
+func (t *Tracker) Endpoints(endpoints []*api.Endpoint, health map[string]*api.EndpointHealth) []*history.Entry {
+	var entries []*history.Entry
+	present := make(map[string]bool, len(endpoints))
+	for _, e := range endpoints {
+		key := "endpoint/" + e.Id
+		present[key] = true
+		h, ok := health[e.Id]
+		if !ok {
+			continue
+		}
+		state := Up
+		if h.Jobs.InQueue > 0 && h.Workers.Idle+h.Workers.Running == 0 {
+			state = Down
+		}
+		if prev := t.last[key]; prev == nil || prev.state != state {
+			entries = append(entries, &history.Entry{Action: Action, EndpointId: e.Id, Name: e.Name, Status: state})
+		}
+		t.last[key] = &observation{state: state}
+	}
+	return append(entries, t.gone("endpoint/", present)...)
+}
+
+// gone returns terminated entries for the resources of a kind seen before
+// but no longer present.
+func (t *Tracker) gone(prefix string, present map[string]bool) []*history.Entry {
+	var entries []*history.Entry
+	for key := range t.last {
+		if !strings.HasPrefix(key, prefix) || present[key] {
+			continue
+		}
+		e := &history.Entry{Action: Action, Status: Terminated}
+		if prefix == "pod/" {
+			e.PodId = strings.TrimPrefix(key, prefix)
+		} else {
+			e.EndpointId = strings.TrimPrefix(key, prefix)
+		}
+		entries = append(entries, e)
+		delete(t.last, key)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].PodId+entries[i].EndpointId < entries[j].PodId+entries[j].EndpointId
+	})
+	return entries
+}
+
+// Resource is the availability of a pod or endpoint over a period. Its
+// availability is the percentage of the time it was up or down that it
+// was up.
+type Resource struct {
+	Kind         string  `json:"kind"`
+	Id           string  `json:"id"`
+	Name         string  `json:"name"`
+	Availability float64 `json:"availability"`
+	UpSeconds    float64 `json:"upSeconds"`
+	DownSeconds  float64 `json:"downSeconds"`
+	Outages      int     `json:"outages"`
+	Preemptions  int     `json:"preemptions"`
+	Restarts     int     `json:"restarts"`
+}
+
+// Report computes the availability of every tracked resource from from to
+// to, least available first. A resource is in the state of its last entry
+// until the next one, including while no tracker was running, and a pod
+// stopped on purpose is stopped from then on. Resources neither up nor down
+// in the period are left out.
+func Report(entries []*history.Entry, from time.Time, to time.Time) []*Resource {
+	type track struct {
+		r     *Resource
+		state string
+		since time.Time
+		// preempted is when the pod was preempted, while it is.
+		preempted time.Time
+		// stopped is set from a stop on purpose until the pod runs again.
+		stopped bool
+	}
+	byKey := make(map[string]*track)
+	var order []string
+	add := func(t *track, until time.Time) {
+		if t.since.Before(from) {
+			t.since = from
+		}
+		if !until.After(t.since) {
+			return
+		}
+		switch t.state {
+		case Up:
+			t.r.UpSeconds += until.Sub(t.since).Seconds()
+		case Down:
+			t.r.DownSeconds += until.Sub(t.since).Seconds()
+		case Preempted:
+			if end := t.preempted.Add(MaxPreempted); end.Before(until) {
+				if end.After(t.since) {
+					t.r.DownSeconds += end.Sub(t.since).Seconds()
+				}
+				break
+			}
+			t.r.DownSeconds += until.Sub(t.since).Seconds()
+		}
+		t.since = until
+	}
+	for _, e := range entries {
+		if !e.Time.Before(to) {
+			continue
+		}
+		if history.IsStop(e.Action) {
+			if t, ok := byKey["pod/"+e.PodId]; ok {
+				add(t, e.Time)
+				t.state = Stopped
+				t.stopped = true
+			}
+			continue
+		}
+		if e.Action != Action {
+			continue
+		}
+		key := "pod/" + e.PodId
+		r := &Resource{Kind: "pod", Id: e.PodId}
+		if e.EndpointId != "" {
+			key = "endpoint/" + e.EndpointId
+			r = &Resource{Kind: "endpoint", Id: e.EndpointId}
+		}
+		t, ok := byKey[key]
+		if !ok {
+			t = &track{r: r, since: e.Time}
+			byKey[key] = t
+			order = append(order, key)
+		}
+		if e.Name != "" {
+			t.r.Name = e.Name
+		}
+		add(t, e.Time)
+		status := e.Status
+		switch {
+		case status == Up || status == Down:
+			t.stopped = false
+		case status == Preempted && t.stopped:
+			status = Stopped
+		}
+		if !e.Time.Before(from) {
+			switch {
+			case status == Restarted:
+				t.r.Restarts++
+			case status == Preempted && t.state != Preempted:
+				t.r.Preemptions++
+			}
+			if t.state == Up && (status == Down || status == Preempted) {
+				t.r.Outages++
+			}
+		}
+		if status == Preempted && t.state != Preempted {
+			t.preempted = e.Time
+		}
+		if status != Restarted {
+			t.state = status
+		}
+	}
+
+	var resources []*Resource
+	for _, key := range order {
+		t := byKey[key]
+		add(t, to)
+		r := t.r
+		if r.UpSeconds+r.DownSeconds == 0 {
+			continue
+		}
+		r.Availability = 100 * r.UpSeconds / (r.UpSeconds + r.DownSeconds)
+		resources = append(resources, r)
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].Availability < resources[j].Availability
+	})
+	return resources
+}
@@ -0,0 +1,98 @@
+package billing
+
+import (
+	"cli/api"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// PodSpend is what a pod costs now and has cost since it last started.
+type PodSpend struct {
+	Id        string  `json:"id"`
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	CostPerHr float64 `json:"costPerHr"`
+	// UptimeInSeconds and SpentSinceStart cover the current run only; the
+	// api does not keep per pod totals across restarts.
+	UptimeInSeconds int     `json:"uptimeInSeconds"`
+	SpentSinceStart float64 `json:"spentSinceStart"`
+}
+
+// Report is the spend of the account: the running burn rate, per pod costs
+// and, when a window is asked for, the charges over it.
+type Report struct {
+	BurnRatePerHr float64              `json:"burnRatePerHr"`
+	Balance       *api.Balance         `json:"balance,omitempty"`
+	Pods          []*PodSpend          `json:"pods"`
+	Since         string               `json:"since,omitempty"`
+	History       []*api.BillingRecord `json:"history,omitempty"`
+	HistoryTotal  float64              `json:"historyTotal,omitempty"`
+}
+
+// Build collects the report. A zero since skips the history. The balance is
+// best effort, so pod-scoped api keys still get the pod costs.
+func Build(ctx context.Context, since time.Time, granularity string) (*Report, error) {
+	pods, err := api.DefaultClient.GetPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{Pods: make([]*PodSpend, 0, len(pods))}
+	for _, p := range pods {
+		s := &PodSpend{Id: p.Id, Name: p.Name, Status: p.DesiredStatus, CostPerHr: toFloat64(p.CostPerHr)}
+		if p.DesiredStatus == "RUNNING" {
+			r.BurnRatePerHr += s.CostPerHr
+			if p.Runtime != nil {
+				s.UptimeInSeconds = p.Runtime.UptimeInSeconds
+				s.SpentSinceStart = s.CostPerHr * float64(s.UptimeInSeconds) / 3600
+			}
+		}
+		r.Pods = append(r.Pods, s)
+	}
+	if balance, err := api.DefaultClient.GetBalance(ctx); err == nil {
+		r.Balance = balance
+	}
+
+	if !since.IsZero() {
+		r.Since = since.UTC().Format(time.RFC3339)
+		r.History, err = api.DefaultClient.GetBilling(ctx, &api.BillingInput{
+			Granularity: granularity,
+			StartTime:   r.Since,
+			EndTime:     time.Now().UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("billing history: %w", err)
+		}
+		for _, h := range r.History {
+			r.HistoryTotal += h.Total()
+		}
+	}
+	return r, nil
+}
+
+// toFloat64 widens a float32 price without picking up binary noise, so 0.44
+// stays 0.44 in json.
+func toFloat64(f float32) float64 {
+	v, _ := strconv.ParseFloat(strconv.FormatFloat(float64(f), 'f', -1, 32), 64)
+	return v
+}
+
+var days = regexp.MustCompile(`^(\d+)d$`)
+
+// ParseSince parses a window start given as a duration back from now, such as
+// 7d or 12h, or as an RFC3339 time.
+func ParseSince(s string) (time.Time, error) {
+	if m := days.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return time.Now().AddDate(0, 0, -n), nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration like 7d or 12h, or an RFC3339 time", s)
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"cli/cmd/image"
+
+	"github.com/spf13/cobra"
+)
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze [command]",
+	Short: "analyze a resource",
+	Long:  "analyze a resource before deploying to runpod.io",
+}
+
+func init() {
+	analyzeCmd.AddCommand(image.AnalyzeImageCmd)
+}
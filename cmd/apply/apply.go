@@ -0,0 +1,338 @@
+package apply
+
+import (
+	"bufio"
+	"cli/api"
+	"cli/exit"
+	"cli/history"
+	"cli/manifest"
+	"cli/secrets"
+	"cli/watch"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var dryRun bool
+var file string
+var params []string
+var prune bool
+var readyTimeout time.Duration
+var yes bool
+
+var ApplyCmd = &cobra.Command{
+	Use:   "apply -f [manifest]",
+	Args:  cobra.ExactArgs(0),
+	Short: "apply manifests",
+	Long: `create, update or replace the pods, templates and services in a yaml or json manifest so they match it;
+resources are matched by name and pods apply created that are missing from the manifest are only terminated with --prune.
+A pod that must be replaced is created again before the old one is terminated; terminating anything asks first unless --yes is given.
+Pods are created after the pods in their dependsOn, once those are running.
+A service runs as a pod or a serverless endpoint depending on its mode`,
+	Run: func(cmd *cobra.Command, args []string) {
+		values, err := manifest.ParseParams(params)
+		exit.CheckErr(err)
+		docs, err := manifest.Load(file, values)
+		exit.CheckErr(err)
+		var pods []*manifest.Pod
+		var templates []*manifest.Template
+		var services []*manifest.Service
+		var endpoints []*manifest.Endpoint
+		for _, doc := range docs {
+			switch doc.Kind {
+			case manifest.KindPod:
+				p, err := doc.Pod()
+				exit.CheckErr(err)
+				pods = append(pods, p)
+			case manifest.KindTemplate:
+				t, err := doc.Template()
+				exit.CheckErr(err)
+				templates = append(templates, t)
+			case manifest.KindService:
+				s, err := doc.Service()
+				exit.CheckErr(err)
+				services = append(services, s)
+				if s.Spec.Mode == manifest.ModePod {
+					pods = append(pods, s.Pod())
+				} else {
+					templates = append(templates, s.Template())
+					endpoints = append(endpoints, s.Endpoint())
+				}
+			default:
+				exit.CheckErr(fmt.Errorf("%s: unsupported kind %q", doc.Source, doc.Kind))
+			}
+		}
+
+		var actions []*manifest.Action
+		var liveTemplates []*api.Template
+		if len(templates) > 0 || len(services) > 0 {
+			liveTemplates, err = api.DefaultClient.GetTemplates(cmd.Context())
+			exit.CheckErr(err)
+			templateActions, err := manifest.PlanTemplates(templates, liveTemplates)
+			exit.CheckErr(err)
+			actions = append(actions, templateActions...)
+		}
+		var livePods []*api.Pod
+		if len(pods) > 0 || len(services) > 0 || prune {
+			livePods, err = api.DefaultClient.GetPods(cmd.Context())
+			exit.CheckErr(err)
+		}
+		var managed map[string]bool
+		if len(pods) > 0 || len(services) > 0 || prune {
+			managed, err = history.Applied()
+			exit.CheckErr(err)
+		}
+		var switchActions []*manifest.Action
+		if len(services) > 0 {
+			liveEndpoints, err := api.DefaultClient.GetEndpoints(cmd.Context())
+			exit.CheckErr(err)
+			switchActions = manifest.PlanModeSwitch(services, livePods, managed, liveEndpoints, liveTemplates)
+			endpointActions, err := manifest.PlanEndpoints(endpoints, liveEndpoints)
+			exit.CheckErr(err)
+			actions = append(actions, endpointActions...)
+		}
+		if len(pods) > 0 || prune {
+			podActions, err := manifest.PlanPods(pods, livePods, managed, prune)
+			exit.CheckErr(err)
+			actions = append(actions, podActions...)
+		}
+		actions = append(actions, switchActions...)
+
+		exit.CheckErr(manifest.OrderPods(actions))
+		var touched []string
+		for _, a := range actions {
+			if a.Kind == manifest.KindPod && a.Id != "" && a.Op != manifest.OpUnchanged {
+				touched = append(touched, a.Id)
+			}
+		}
+		exit.CheckErr(history.CheckPods(touched))
+
+		counts := make(map[manifest.Op]int)
+		cost := newPlanCost(livePods)
+		for _, a := range actions {
+			fmt.Println(a)
+			if line := cost.annotate(cmd.Context(), a); line != "" {
+				fmt.Println(line)
+			}
+			counts[a.Op]++
+		}
+		fmt.Printf("plan: %d to create, %d to update, %d to replace, %d to terminate\n",
+			counts[manifest.OpCreate], counts[manifest.OpUpdate], counts[manifest.OpReplace], counts[manifest.OpDelete])
+		if cost.priced > 0 {
+			fmt.Println(cost.total())
+		}
+		if dryRun {
+			return
+		}
+		if terminated := counts[manifest.OpReplace] + counts[manifest.OpDelete]; terminated > 0 && !yes {
+			if !confirm(fmt.Sprintf("%d resource(s) will be terminated; continue?", terminated)) {
+				exit.CheckErr(fmt.Errorf("not applied; pass --yes to terminate resources without asking"))
+			}
+		}
+
+		for _, a := range actions {
+			if a.Kind == manifest.KindPod && a.Pod != nil && a.Id != "" {
+				podIds[a.Name] = a.Id
+			}
+		}
+		for _, a := range actions {
+			if a.Op == manifest.OpUnchanged {
+				continue
+			}
+			if a.Kind == manifest.KindPod && a.Pod != nil {
+				exit.CheckErr(waitDependencies(cmd.Context(), a.Pod))
+			}
+			exit.CheckErr(applyAction(cmd.Context(), a))
+		}
+	},
+}
+
+// createdTemplates maps the names of templates created by this apply to their
+// ids, for the endpoints that run them.
+var createdTemplates = make(map[string]string)
+
+// podIds maps the names of the manifest's pods to their ids, and ready holds
+// the ones seen running, for dependsOn.
+var podIds = make(map[string]string)
+var ready = make(map[string]bool)
+
+// waitDependencies waits until the pods pod depends on are running.
+func waitDependencies(ctx context.Context, pod *manifest.Pod) error {
+	for _, name := range pod.Spec.DependsOn {
+		if ready[name] {
+			continue
+		}
+		id, ok := podIds[name]
+		if !ok {
+			return fmt.Errorf(`pod "%s" depends on "%s", which was not created`, pod.Name, name)
+		}
+		fmt.Printf(`waiting for pod "%s" to run before "%s"`+"\n", name, pod.Name)
+		waitCtx, cancel := context.WithTimeout(ctx, readyTimeout)
+		_, err := watch.Running(waitCtx, id)
+		cancel()
+		if err != nil {
+			return fmt.Errorf(`pod "%s" depends on "%s": %w`, pod.Name, name, err)
+		}
+		ready[name] = true
+	}
+	return nil
+}
+
+func applyAction(ctx context.Context, a *manifest.Action) error {
+	switch a.Kind {
+	case manifest.KindTemplate:
+		if a.Op == manifest.OpDelete {
+			if err := api.DefaultClient.DeleteTemplate(ctx, a.Name); err != nil {
+				return err
+			}
+			fmt.Printf(`template "%s" removed`+"\n", a.Name)
+			return nil
+		}
+		input := a.Template.SaveTemplateInput()
+		if err := secrets.Check(input.Env); err != nil {
+			return err
+		}
+		if a.Op == manifest.OpCreate {
+			t, err := api.DefaultClient.CreateTemplate(ctx, input)
+			if err != nil {
+				return err
+			}
+			fmt.Printf(`template "%s" created with id "%s"`+"\n", t.Name, t.Id)
+			createdTemplates[t.Name] = t.Id
+			return nil
+		}
+		input.Id = a.Id
+		if _, err := api.DefaultClient.UpdateTemplate(ctx, input); err != nil {
+			return err
+		}
+		fmt.Printf(`template "%s" updated`+"\n", a.Id)
+		return nil
+	case manifest.KindEndpoint:
+		if a.Op == manifest.OpDelete {
+			if err := api.DefaultClient.DeleteEndpoint(ctx, a.Id); err != nil {
+				return err
+			}
+			fmt.Printf(`endpoint "%s" removed`+"\n", a.Id)
+			return nil
+		}
+		templateId, ok := createdTemplates[a.Endpoint.TemplateName]
+		if !ok {
+			t, err := api.DefaultClient.GetTemplate(ctx, a.Endpoint.TemplateName)
+			if err != nil {
+				return err
+			}
+			templateId = t.Id
+		}
+		input := a.Endpoint.SaveEndpointInput(templateId)
+		input.Id = a.Id
+		e, err := api.DefaultClient.SaveEndpoint(ctx, input)
+		if err != nil {
+			return err
+		}
+		if a.Op == manifest.OpCreate {
+			fmt.Printf(`endpoint "%s" created with id "%s"`+"\n", e.Name, e.Id)
+		} else {
+			fmt.Printf(`endpoint "%s" updated`+"\n", e.Id)
+		}
+		return nil
+	}
+
+	if a.Op == manifest.OpUpdate {
+		live, err := api.DefaultClient.GetPod(ctx, a.Id)
+		if err != nil {
+			return err
+		}
+		input := a.Pod.UpdatePodInput(live)
+		if err := secrets.Check(input.Env); err != nil {
+			return err
+		}
+		if _, err := api.DefaultClient.UpdatePodRestarting(ctx, input); err != nil {
+			return err
+		}
+		fmt.Printf(`pod "%s" updated`+"\n", a.Id)
+		return nil
+	}
+	if a.Op == manifest.OpDelete {
+		if err := api.DefaultClient.RemovePod(ctx, a.Id); err != nil {
+			return err
+		}
+		fmt.Printf(`pod "%s" removed`+"\n", a.Id)
+		return nil
+	}
+	if err := createPod(ctx, a.Pod); err != nil {
+		return err
+	}
+	if a.Op == manifest.OpReplace {
+		// the old pod only goes once its replacement exists
+		if err := api.DefaultClient.RemovePod(ctx, a.Id); err != nil {
+			return fmt.Errorf(`pod "%s" was replaced but could not be removed: %w`, a.Id, err)
+		}
+		fmt.Printf(`pod "%s" removed`+"\n", a.Id)
+	}
+	return nil
+}
+
+// createPod creates pod and records it as created by apply.
+func createPod(ctx context.Context, p *manifest.Pod) error {
+	input := p.CreatePodInput()
+	if err := secrets.Check(input.Env); err != nil {
+		return err
+	}
+	if input.NetworkVolumeId != "" {
+		// a network volume can only be mounted by pods in its datacenter
+		volume, err := api.DefaultClient.GetNetworkVolume(ctx, input.NetworkVolumeId)
+		if err != nil {
+			return err
+		}
+		input.DataCenterId = volume.DataCenterId
+	}
+	pod, err := api.DefaultClient.CreatePod(ctx, input)
+	if err != nil {
+		return err
+	}
+	if pod.DesiredStatus != "RUNNING" {
+		return fmt.Errorf(`pod "%s" start failed; status is %s`, pod.Id, pod.DesiredStatus)
+	}
+	fmt.Printf(`pod "%s" created for $%.3f / hr`+"\n", pod.Id, pod.CostPerHr)
+	podIds[input.Name] = pod.Id
+	err = history.Append(&history.Entry{
+		Action:    history.ApplyAction,
+		PodId:     pod.Id,
+		Name:      input.Name,
+		ImageName: input.ImageName,
+		GpuType:   input.GpuTypeId,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not record history: %s\n", err)
+	}
+	return nil
+}
+
+func init() {
+	ApplyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the plan without changing anything")
+	ApplyCmd.Flags().StringVarP(&file, "file", "f", "", "yaml or json manifest ('-' for stdin)")
+	ApplyCmd.Flags().StringArrayVar(&params, "param", nil, "value of a manifest param as NAME=value; repeat for more")
+	ApplyCmd.Flags().BoolVar(&prune, "prune", false, "terminate pods that are not in the manifest")
+	ApplyCmd.Flags().DurationVar(&readyTimeout, "ready-timeout", 10*time.Minute, "how long to wait for a pod to run before starting the pods that depend on it")
+
+	ApplyCmd.Flags().BoolVarP(&yes, "yes", "y", false, "terminate pods and endpoints without asking")
+
+	ApplyCmd.MarkFlagRequired("file") //nolint
+}
+
+// confirm asks a yes/no question on the terminal. It is false when stdin is
+// not a terminal.
+func confirm(question string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
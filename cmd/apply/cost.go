@@ -0,0 +1,121 @@
+package apply
+
+import (
+	"cli/api"
+	"cli/manifest"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// planCost prices the pod actions of a plan at the current gpu prices.
+// Prices are looked up once per cloud and gpu count.
+type planCost struct {
+	live   map[string]*api.Pod
+	prices map[string][]*api.GpuType
+	// delta is the change in on-demand $/hr once the plan is applied.
+	delta float64
+	// priced and unknown count the pods whose cost changes, and those of
+	// them that could not be priced.
+	priced  int
+	unknown int
+}
+
+func newPlanCost(livePods []*api.Pod) *planCost {
+	c := &planCost{live: make(map[string]*api.Pod, len(livePods)), prices: make(map[string][]*api.GpuType)}
+	for _, p := range livePods {
+		c.live[p.Id] = p
+	}
+	return c
+}
+
+// annotate returns the cost line printed under a pod action, and adds the
+// action to the plan's total. Updates are made in place on the same machine,
+// so they do not change what the pod costs.
+func (c *planCost) annotate(ctx context.Context, a *manifest.Action) string {
+	if a.Kind != manifest.KindPod {
+		return ""
+	}
+	var was float64
+	if p := c.live[a.Id]; p != nil && p.DesiredStatus == "RUNNING" {
+		was = float64(p.CostPerHr)
+	}
+	switch a.Op {
+	case manifest.OpDelete:
+		c.priced++
+		if was == 0 {
+			return "    cost: unchanged, the pod is not running"
+		}
+		c.delta -= was
+		return fmt.Sprintf("    cost: -$%.3f / hr", was)
+	case manifest.OpCreate, manifest.OpReplace:
+	default:
+		return ""
+	}
+
+	c.priced++
+	onDemand, spot, err := c.price(ctx, a.Pod.Spec)
+	if err != nil {
+		c.unknown++
+		return "    cost: unknown, " + err.Error()
+	}
+	c.delta += onDemand - was
+	s := fmt.Sprintf("    cost: $%.3f / hr on-demand", onDemand)
+	if spot > 0 {
+		s += fmt.Sprintf(", $%.3f / hr spot", spot)
+	}
+	if a.Op == manifest.OpReplace && was > 0 {
+		s += fmt.Sprintf(", was $%.3f / hr", was)
+	}
+	return s
+}
+
+// price returns the on-demand and lowest spot bid $/hr of spec on the first
+// of its gpu types that is available.
+func (c *planCost) price(ctx context.Context, spec *manifest.PodSpec) (float64, float64, error) {
+	cloud := spec.CloudType
+	if cloud == "" {
+		cloud = "COMMUNITY"
+	}
+	key := fmt.Sprintf("%s/%d", cloud, spec.GpuCount)
+	gpuTypes, ok := c.prices[key]
+	if !ok {
+		secure := cloud == "SECURE"
+		var err error
+		gpuTypes, err = api.DefaultClient.GetGpuTypes(ctx, &api.GetCloudInput{GpuCount: spec.GpuCount, SecureCloud: &secure})
+		if err != nil {
+			return 0, 0, err
+		}
+		c.prices[key] = gpuTypes
+	}
+	for _, id := range strings.Split(spec.GpuType, ",") {
+		id = strings.TrimSpace(id)
+		for _, g := range gpuTypes {
+			if g.Id != id {
+				continue
+			}
+			if onDemand, spot, ok := g.Price(spec.GpuCount); ok {
+				return api.Cents(onDemand), api.Cents(spot), nil
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("no %d x %s available on %s cloud right now", spec.GpuCount, spec.GpuType, strings.ToLower(cloud))
+}
+
+// total summarizes the change in cost of the whole plan.
+func (c *planCost) total() string {
+	sign := "+"
+	if c.delta < 0 {
+		sign = "-"
+	}
+	d := math.Abs(api.Cents(c.delta))
+	s := fmt.Sprintf("cost: %s$%.3f / hr, %s$%.2f / month at on-demand prices", sign, d, sign, d*api.HoursPerMonth)
+	switch {
+	case c.unknown == 1:
+		s += ", not counting 1 pod that could not be priced"
+	case c.unknown > 1:
+		s += fmt.Sprintf(", not counting %d pods that could not be priced", c.unknown)
+	}
+	return s
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"cli/cmd/endpoint"
+
+	"github.com/spf13/cobra"
+)
+
+var chaosCmd = &cobra.Command{
+	Use:   "chaos [command]",
+	Short: "chaos test a resource",
+	Long:  "chaos test a resource in runpod.io",
+}
+
+func init() {
+	chaosCmd.AddCommand(endpoint.ChaosEndpointCmd)
+}
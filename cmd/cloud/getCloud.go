@@ -2,6 +2,7 @@ package cloud
 
 import (
 	"cli/api"
+	"cli/exit"
 	"cli/format"
 	"fmt"
 	"os"
@@ -14,6 +15,8 @@ import (
 var community bool
 var disk int
 var memory int
+var output string
+var outputTemplate string
 var vcpu int
 var secure bool
 
@@ -27,9 +30,9 @@ var GetCloudCmd = &cobra.Command{
 		gpuCount := 1
 		if len(args) > 0 {
 			gpuCount, err = strconv.Atoi(args[0])
-			cobra.CheckErr(err)
+			exit.CheckErr(err)
 			if gpuCount <= 0 {
-				cobra.CheckErr(fmt.Errorf("gpu count must be > 0: %d", gpuCount))
+				exit.CheckErr(fmt.Errorf("gpu count must be > 0: %d", gpuCount))
 			}
 		}
 		var secureCloud *bool
@@ -43,40 +46,41 @@ var GetCloudCmd = &cobra.Command{
 			SecureCloud:   secureCloud,
 			TotalDisk:     disk,
 		}
-		gpuTypes, err := api.GetCloud(input)
-		cobra.CheckErr(err)
+		gpuTypes, err := api.DefaultClient.GetCloud(cmd.Context(), input)
+		exit.CheckErr(format.Partial(err))
+		printed, err := format.Print(os.Stdout, output, outputTemplate, gpuTypes)
+		exit.CheckErr(err)
+		if printed {
+			return
+		}
 
-		data := [][]string{}
+		var available []*api.LowestPrice
 		for _, gpu := range gpuTypes {
-			gpuType, ok := gpu.(map[string]interface{})
-			if !ok {
-				continue
-			}
-			kv, ok := gpuType["lowestPrice"].(map[string]interface{})
-			if !ok || kv["minMemory"] == nil {
-				continue
-			}
-			spotPrice, ok := kv["minimumBidPrice"].(float64)
-			spotPriceString := "Reserved"
-			if ok && spotPrice > 0 {
-				spotPriceString = fmt.Sprintf("%.3f", spotPrice)
-			}
-			onDemandPrice, ok := kv["uninterruptablePrice"].(float64)
-			onDemandPriceString := "Reserved"
-			if ok && spotPrice > 0 {
-				onDemandPriceString = fmt.Sprintf("%.3f", onDemandPrice)
+			if kv := gpu.LowestPrice; kv != nil && kv.MinMemory != 0 {
+				available = append(available, kv)
 			}
+		}
+		columns, err := format.ColumnSet(output)
+		exit.CheckErr(err)
+		if columns != nil {
+			exit.CheckErr(format.PrintColumns(os.Stdout, columns, available, cloudColumns(gpuCount)))
+			return
+		}
+
+		data := [][]string{}
+		for _, kv := range available {
 			row := []string{
-				fmt.Sprintf("%dx %s", gpuCount, kv["gpuTypeId"].(string)),
-				fmt.Sprintf("%.f", kv["minMemory"]),
-				fmt.Sprintf("%.f", kv["minVcpu"]),
-				spotPriceString,
-				onDemandPriceString,
+				fmt.Sprintf("%dx %s", gpuCount, kv.GpuTypeId),
+				fmt.Sprintf("%.f", kv.MinMemory),
+				fmt.Sprintf("%.f", kv.MinVcpu),
+				spotPrice(kv),
+				onDemandPrice(kv),
 			}
 			data = append(data, row)
 		}
 
 		header := []string{"GPU Type", "Mem GB", "vCPU", "Spot $/HR", "OnDemand $/HR"}
+		format.Highlight(header, data)
 		tb := tablewriter.NewWriter(os.Stdout)
 		tb.SetHeader(header)
 		tb.AppendBulk(data)
@@ -85,10 +89,41 @@ var GetCloudCmd = &cobra.Command{
 	},
 }
 
+// cloudColumns are the -o columns=<set> columns that are not plain json fields.
+func cloudColumns(gpuCount int) map[string]format.Column {
+	return map[string]format.Column{
+		"gpu": func(row interface{}) string {
+			return fmt.Sprintf("%dx %s", gpuCount, row.(*api.LowestPrice).GpuTypeId)
+		},
+		"spot": func(row interface{}) string {
+			return spotPrice(row.(*api.LowestPrice))
+		},
+		"onDemand": func(row interface{}) string {
+			return onDemandPrice(row.(*api.LowestPrice))
+		},
+	}
+}
+
+func spotPrice(kv *api.LowestPrice) string {
+	if kv.MinimumBidPrice > 0 {
+		return fmt.Sprintf("%.3f", kv.MinimumBidPrice)
+	}
+	return "Reserved"
+}
+
+func onDemandPrice(kv *api.LowestPrice) string {
+	if kv.MinimumBidPrice > 0 {
+		return fmt.Sprintf("%.3f", kv.UninterruptablePrice)
+	}
+	return "Reserved"
+}
+
 func init() {
 	GetCloudCmd.Flags().BoolVarP(&community, "community", "c", false, "show listings from community cloud only")
 	GetCloudCmd.Flags().IntVar(&disk, "disk", 0, "minimum disk size in GB you need")
 	GetCloudCmd.Flags().IntVar(&memory, "mem", 0, "minimum sys memory size in GB you need")
+	GetCloudCmd.Flags().StringVarP(&output, "output", "o", "", "output format: columns=<set>, json, yaml, jsonpath=<expression> or template=<go template>")
+	GetCloudCmd.Flags().StringVar(&outputTemplate, "template", "", "go template for the output; fields are named as in -o json, e.g. '{{range .}}{{.id}}{{end}}'")
 	GetCloudCmd.Flags().IntVar(&vcpu, "vcpu", 0, "minimum vCPUs you need")
 	GetCloudCmd.Flags().BoolVarP(&secure, "secure", "s", false, "show listings from secure cloud only")
 }
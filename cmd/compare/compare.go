@@ -0,0 +1,143 @@
+package compare
+
+import (
+	"cli/api"
+	"cli/complete"
+	"cli/exit"
+	"cli/format"
+	"cli/prices"
+	"cli/profile"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var gpu string
+var maxAge time.Duration
+var output string
+var sources []string
+
+var CompareCmd = &cobra.Command{
+	Use:   "compare [command]",
+	Short: "compare runpod with other providers",
+	Long:  "compare runpod with other providers",
+}
+
+var pricesCmd = &cobra.Command{
+	Use:   "prices",
+	Args:  cobra.ExactArgs(0),
+	Short: "compare gpu prices",
+	Long: `show runpod's secure and community prices for a gpu next to the offers of other providers,
+cheapest first. Offers come from json files or urls given with --source or the priceSources config;
+urls are cached for --max-age`,
+	Run: func(cmd *cobra.Command, args []string) {
+		offers, err := runpodOffers(cmd.Context())
+		exit.CheckErr(err)
+		if len(sources) == 0 {
+			sources = viper.GetStringSlice(profile.Key("priceSources"))
+		}
+		for _, location := range sources {
+			source := prices.NewSource(location, maxAge)
+			more, err := source.Offers(cmd.Context())
+			exit.CheckErr(err)
+			for _, o := range more {
+				if prices.Matches(o.Gpu, gpu) {
+					offers = append(offers, o)
+				}
+			}
+		}
+		if len(offers) == 0 {
+			exit.CheckErr(fmt.Errorf("no offers for a gpu matching %q; see runpodctl get gpu-types", gpu))
+		}
+		sort.SliceStable(offers, func(i, j int) bool {
+			return offers[i].PricePerHour < offers[j].PricePerHour
+		})
+
+		printed, err := format.Print(os.Stdout, output, "", offers)
+		exit.CheckErr(err)
+		if printed {
+			return
+		}
+		base := cheapestOnDemand(offers)
+		data := make([][]string, len(offers))
+		for i, o := range offers {
+			vram := "-"
+			if o.VramInGb > 0 {
+				vram = fmt.Sprint(o.VramInGb)
+			}
+			diff := "-"
+			if base > 0 {
+				diff = fmt.Sprintf("%+.0f%%", 100*(o.PricePerHour/base-1))
+			}
+			data[i] = []string{o.Provider, o.Gpu, vram, o.Kind, fmt.Sprintf("%.3f", o.PricePerHour), diff}
+		}
+		tb := tablewriter.NewWriter(os.Stdout)
+		tb.SetHeader([]string{"Provider", "GPU", "VRAM GB", "Kind", "$/HR", "vs RunPod"})
+		tb.AppendBulk(data)
+		format.TableDefaults(tb)
+		tb.Render()
+		if len(sources) == 0 {
+			fmt.Println("add other providers' prices with --source or: runpodctl config --priceSources prices.json")
+		}
+	},
+}
+
+func init() {
+	pricesCmd.Flags().StringVar(&gpu, "gpu", "", "gpu to compare, e.g. A100 or 'RTX 4090'")
+	pricesCmd.Flags().DurationVar(&maxAge, "max-age", 24*time.Hour, "how long prices downloaded from a url are reused")
+	pricesCmd.Flags().StringVarP(&output, "output", "o", "", "output format: json, yaml, jsonpath=<expression> or template=<go template>")
+	pricesCmd.Flags().StringSliceVar(&sources, "source", nil, "json file or url with other providers' offers; defaults to the priceSources config")
+	pricesCmd.MarkFlagRequired("gpu")                              //nolint
+	pricesCmd.RegisterFlagCompletionFunc("gpu", complete.GpuTypes) //nolint
+
+	CompareCmd.AddCommand(pricesCmd)
+}
+
+// runpodOffers lists the on-demand and spot prices per gpu of the matching
+// gpu types in secure and community cloud.
+func runpodOffers(ctx context.Context) ([]*prices.Offer, error) {
+	var offers []*prices.Offer
+	for _, secure := range []bool{true, false} {
+		cloud := "community"
+		if secure {
+			cloud = "secure"
+		}
+		secure := secure
+		gpuTypes, err := api.DefaultClient.GetGpuTypes(ctx, &api.GetCloudInput{GpuCount: 1, SecureCloud: &secure})
+		if err := format.Partial(err); err != nil {
+			return nil, err
+		}
+		for _, g := range gpuTypes {
+			kv := g.LowestPrice
+			if kv == nil || !prices.Matches(g.Id, gpu) && !prices.Matches(g.DisplayName, gpu) {
+				continue
+			}
+			if kv.UninterruptablePrice > 0 {
+				offers = append(offers, &prices.Offer{Provider: "runpod", Gpu: g.Id, VramInGb: g.MemoryInGb, Kind: cloud + " on-demand", PricePerHour: kv.UninterruptablePrice})
+			}
+			if kv.MinimumBidPrice > 0 {
+				offers = append(offers, &prices.Offer{Provider: "runpod", Gpu: g.Id, VramInGb: g.MemoryInGb, Kind: cloud + " spot", PricePerHour: kv.MinimumBidPrice})
+			}
+		}
+	}
+	return offers, nil
+}
+
+// cheapestOnDemand is the lowest runpod on-demand price, which the other
+// offers are compared against.
+func cheapestOnDemand(offers []*prices.Offer) float64 {
+	cheapest := 0.0
+	for _, o := range offers {
+		if o.Provider == "runpod" && strings.HasSuffix(o.Kind, "on-demand") && (cheapest == 0 || o.PricePerHour < cheapest) {
+			cheapest = o.PricePerHour
+		}
+	}
+	return cheapest
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"cli/exit"
+	"cli/store"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -10,14 +12,20 @@ import (
 var ConfigFile string
 var apiKey string
 var apiUrl string
+var secretScan string
+var warnings string
+var proxy string
+var caFile string
+var insecureSkipVerify bool
+var priceSources []string
 
 var ConfigCmd = &cobra.Command{
 	Use:   "config",
 	Short: "CLI Config",
 	Long:  "RunPod CLI Config Settings",
 	Run: func(c *cobra.Command, args []string) {
-		err := viper.WriteConfig()
-		cobra.CheckErr(err)
+		err := store.UpdateConfig(nil)
+		exit.CheckErr(err)
 
 		fmt.Println("saved apiKey into config file: " + ConfigFile)
 	},
@@ -31,4 +39,24 @@ func init() {
 	ConfigCmd.Flags().StringVar(&apiUrl, "apiUrl", "", "runpod api url")
 	viper.BindPFlag("apiUrl", ConfigCmd.Flags().Lookup("apiUrl")) //nolint
 	viper.SetDefault("apiUrl", "https://api.runpod.io/graphql")
+
+	ConfigCmd.Flags().StringVar(&secretScan, "secretScan", "", "plaintext secret check on pod env: warn, block or off")
+	viper.BindPFlag("secretScan", ConfigCmd.Flags().Lookup("secretScan")) //nolint
+	viper.SetDefault("secretScan", "warn")
+
+	ConfigCmd.Flags().StringVar(&warnings, "warnings", "", "warn about failed, gpu-less or stuck pods after get, create and stop: on or off")
+	viper.BindPFlag("warnings", ConfigCmd.Flags().Lookup("warnings")) //nolint
+	viper.SetDefault("warnings", "on")
+
+	ConfigCmd.Flags().StringVar(&proxy, "proxy", "", "proxy url for api calls; HTTPS_PROXY is used when empty")
+	viper.BindPFlag("proxy", ConfigCmd.Flags().Lookup("proxy")) //nolint
+
+	ConfigCmd.Flags().StringVar(&caFile, "caFile", "", "pem file of extra certificate authorities to trust, e.g. of a tls-intercepting proxy")
+	viper.BindPFlag("caFile", ConfigCmd.Flags().Lookup("caFile")) //nolint
+
+	ConfigCmd.Flags().BoolVar(&insecureSkipVerify, "insecureSkipVerify", false, "skip tls certificate verification of the api (unsafe)")
+	viper.BindPFlag("insecureSkipVerify", ConfigCmd.Flags().Lookup("insecureSkipVerify")) //nolint
+
+	ConfigCmd.Flags().StringSliceVar(&priceSources, "priceSources", nil, "json files or urls with other providers' gpu prices for compare prices")
+	viper.BindPFlag("priceSources", ConfigCmd.Flags().Lookup("priceSources")) //nolint
 }
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"cli/exit"
+	"cli/profile"
+	"cli/store"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var profileApiKey string
+var profileApiUrl string
+var profileSecretScan string
+var profileSshKey string
+
+var SetProfileCmd = &cobra.Command{
+	Use:   "set-profile [name]",
+	Args:  cobra.ExactArgs(1),
+	Short: "create or update a profile",
+	Long:  "create or update a named profile with its own api key and settings; settings left out fall back to the top-level config",
+	Run: func(cmd *cobra.Command, args []string) {
+		name := strings.ToLower(args[0])
+		if name == profile.Default || strings.Contains(name, ".") {
+			exit.CheckErr(fmt.Errorf("invalid profile name %q", args[0]))
+		}
+		changed := false
+		for flag, key := range map[string]string{"apiKey": "apiKey", "apiUrl": "apiUrl", "secretScan": "secretScan", "sshKey": "sshKey"} {
+			if f := cmd.Flags().Lookup(flag); f.Changed {
+				viper.Set("profiles."+name+"."+key, f.Value.String())
+				changed = true
+			}
+		}
+		if !changed && !viper.IsSet("profiles."+name) {
+			exit.CheckErr(fmt.Errorf("set at least --apiKey for a new profile"))
+		}
+		exit.CheckErr(store.UpdateConfig(nil))
+		fmt.Printf("saved profile %s into config file: %s\n", name, ConfigFile)
+	},
+}
+
+var UseProfileCmd = &cobra.Command{
+	Use:   "use-profile [name]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "switch the default profile",
+	Long:  "switch the profile used when neither --profile nor RUNPOD_PROFILE is set; 'default' uses the top-level config. Without a name, list the profiles",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			for _, name := range append([]string{profile.Default}, profile.Names()...) {
+				marker := " "
+				if name == profile.Active || name == profile.Default && profile.Active == "" {
+					marker = "*"
+				}
+				fmt.Printf("%s %s\n", marker, name)
+			}
+			return
+		}
+		name := strings.ToLower(args[0])
+		if name != profile.Default && !viper.IsSet("profiles."+name) {
+			exit.CheckErr(fmt.Errorf("unknown profile %q; create it with runpodctl config set-profile %s", name, name))
+		}
+		viper.Set("profile", name)
+		exit.CheckErr(store.UpdateConfig(nil))
+		fmt.Printf("using profile %s\n", name)
+	},
+}
+
+func init() {
+	ConfigCmd.AddCommand(SetProfileCmd)
+	ConfigCmd.AddCommand(UseProfileCmd)
+
+	SetProfileCmd.Flags().StringVar(&profileApiKey, "apiKey", "", "runpod api key")
+	SetProfileCmd.Flags().StringVar(&profileApiUrl, "apiUrl", "", "runpod api url")
+	SetProfileCmd.Flags().StringVar(&profileSecretScan, "secretScan", "", "plaintext secret check on pod env: warn, block or off")
+	SetProfileCmd.Flags().StringVar(&profileSshKey, "sshKey", "", "ssh private key for reaching pods")
+}
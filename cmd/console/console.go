@@ -0,0 +1,62 @@
+package console
+
+import (
+	"cli/api"
+	"cli/complete"
+	"cli/exit"
+	"cli/format"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var output string
+var timestamps bool
+
+var ConsoleCmd = &cobra.Command{
+	Use:               "console [podId]",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: complete.PodId,
+	Short:             "print a pod's boot output",
+	Long: `print what the pod's container wrote from its start, before the image's own logging takes over:
+the entrypoint and bootstrap scripts, and why a misconfigured container exited. Output the container
+wrote to stderr goes to stderr`,
+	Run: func(cmd *cobra.Command, args []string) {
+		console, err := api.DefaultClient.GetPodConsole(cmd.Context(), args[0])
+		exit.CheckErr(err)
+		printed, err := format.Print(os.Stdout, output, "", console)
+		exit.CheckErr(err)
+		if printed {
+			return
+		}
+
+		if console.Truncated {
+			fmt.Fprintln(os.Stderr, "... earlier output was not kept")
+		}
+		for _, line := range console.Lines {
+			out := os.Stdout
+			if line.Stream == "stderr" {
+				out = os.Stderr
+			}
+			if timestamps {
+				fmt.Fprintf(out, "%s %s\n", line.Timestamp, line.Message)
+			} else {
+				fmt.Fprintln(out, line.Message)
+			}
+		}
+		switch {
+		case console.StartedAt == "":
+			fmt.Fprintf(os.Stderr, "the container has not started; see runpodctl logs %s --system for the image pull\n", args[0])
+		case console.ExitCode != nil:
+			fmt.Fprintf(os.Stderr, "the container started at %s exited with code %d\n", console.StartedAt, *console.ExitCode)
+		case len(console.Lines) == 0:
+			fmt.Fprintf(os.Stderr, "the container started at %s has written nothing yet\n", console.StartedAt)
+		}
+	},
+}
+
+func init() {
+	ConsoleCmd.Flags().StringVarP(&output, "output", "o", "", "output format: json, yaml, jsonpath=<expression> or template=<go template>")
+	ConsoleCmd.Flags().BoolVarP(&timestamps, "timestamps", "t", false, "prefix lines with their timestamp")
+}
@@ -0,0 +1,207 @@
+package cp
+
+import (
+	"cli/api"
+	"cli/exit"
+	"cli/fleet"
+	"cli/format"
+	"cli/remote"
+	"cli/selector"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+)
+
+var direct bool
+var labelSelector string
+var parallel int
+var recursive bool
+
+var CpCmd = &cobra.Command{
+	Use:   "cp [src] [dst] | cp [localFile] --selector key=value:/remote/path",
+	Args:  cobra.RangeArgs(1, 2),
+	Short: "copy files to and from pods",
+	Long: `copy files or folders (-r) between this machine and a pod over ssh, e.g. cp ./data pod:/workspace/data;
+interrupted copies resume where they stopped when run again. Copy a local file to every running pod matching
+the selector, or a file or folder from one pod straight to another with --direct`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 2 {
+			src, dst := isPodPath(args[0]), isPodPath(args[1])
+			switch {
+			case src && dst:
+				copyBetweenPods(cmd.Context(), args[0], args[1])
+			case src || dst:
+				copyWithPod(cmd.Context(), args[0], args[1])
+			default:
+				exit.CheckErr(fmt.Errorf("src or dst must be of the form pod:/path"))
+			}
+			return
+		}
+		if labelSelector == "" {
+			exit.CheckErr(fmt.Errorf(`required flag(s) "selector" not set`))
+		}
+		i := strings.LastIndex(labelSelector, ":")
+		if i < 0 {
+			exit.CheckErr(fmt.Errorf("selector must end with :/remote/path, e.g. job=sweep1:/workspace/config.yaml"))
+		}
+		sel, err := selector.Parse(labelSelector[:i])
+		exit.CheckErr(err)
+		remotePath := labelSelector[i+1:]
+		if remotePath == "" || strings.HasSuffix(remotePath, "/") {
+			remotePath = path.Join(remotePath, filepath.Base(args[0]))
+		}
+
+		stat, err := os.Stat(args[0])
+		exit.CheckErr(err)
+		if stat.IsDir() {
+			exit.CheckErr(fmt.Errorf("%s is a directory", args[0]))
+		}
+		sum, err := remote.FileChecksum(args[0])
+		exit.CheckErr(err)
+
+		pods, err := api.DefaultClient.GetPods(cmd.Context())
+		exit.CheckErr(err)
+		var targets []*api.Pod
+		for _, p := range sel.Filter(pods) {
+			if p.DesiredStatus == "RUNNING" {
+				targets = append(targets, p)
+			}
+		}
+		if len(targets) == 0 {
+			exit.CheckErr(fmt.Errorf("no running pods match selector %q", labelSelector[:i]))
+		}
+
+		failed := fleet.Run(targets, parallel, func(p *api.Pod) error {
+			target, err := remote.Resolve(p)
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+			if err := target.Upload(ctx, args[0], remotePath); err != nil {
+				return err
+			}
+			remoteSum, err := target.Checksum(ctx, remotePath)
+			if err != nil {
+				return fmt.Errorf("verify: %w", err)
+			}
+			if remoteSum != sum {
+				return fmt.Errorf("checksum mismatch: %s != %s", remoteSum, sum)
+			}
+			return nil
+		}, func(r fleet.Result) {
+			if r.Err != nil {
+				fmt.Printf("%s (%s): failed: %s\n", r.Pod.Name, r.Pod.Id, r.Err)
+			} else {
+				fmt.Printf("%s (%s): copied to %s\n", r.Pod.Name, r.Pod.Id, remotePath)
+			}
+		})
+		if failed > 0 {
+			exit.CheckErr(fmt.Errorf("copy failed on %d of %d pods", failed, len(targets)))
+		}
+	},
+}
+
+func copyBetweenPods(ctx context.Context, src string, dst string) {
+	if !direct {
+		exit.CheckErr(fmt.Errorf("copying between pods requires --direct"))
+	}
+	pods, err := api.DefaultClient.GetPods(ctx)
+	exit.CheckErr(err)
+	srcTarget, srcPath, err := podPath(pods, src)
+	exit.CheckErr(err)
+	dstTarget, dstPath, err := podPath(pods, dst)
+	exit.CheckErr(err)
+	if srcTarget.PodId == dstTarget.PodId {
+		exit.CheckErr(fmt.Errorf("source and destination are the same pod"))
+	}
+	err = remote.Transfer(ctx, srcTarget, srcPath, dstTarget, dstPath, os.Stdout)
+	exit.CheckErr(err)
+	fmt.Printf("copied %s to %s\n", src, dst)
+}
+
+// copyWithPod copies between a local path and a pod path in either direction.
+func copyWithPod(ctx context.Context, src string, dst string) {
+	pods, err := api.DefaultClient.GetPods(ctx)
+	exit.CheckErr(err)
+	if isPodPath(src) {
+		cleanPartials()
+		target, remotePath, err := podPath(pods, src)
+		exit.CheckErr(err)
+		exit.CheckErr(target.Get(ctx, remotePath, dst, recursive, newBar))
+	} else {
+		target, remotePath, err := podPath(pods, dst)
+		exit.CheckErr(err)
+		if strings.HasSuffix(remotePath, "/") {
+			remotePath = path.Join(remotePath, filepath.Base(src))
+		}
+		exit.CheckErr(target.Put(ctx, src, remotePath, recursive, newBar))
+	}
+	fmt.Printf("copied %s to %s\n", src, dst)
+}
+
+// cleanPartials removes the partial downloads of earlier copies that were
+// abandoned long enough ago that they will not be resumed.
+func cleanPartials() {
+	removed, freed, err := remote.CleanPartials(remote.StalePartialAge)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not clean up partial downloads: %s\n", err)
+	}
+	switch {
+	case len(removed) == 1:
+		fmt.Fprintf(os.Stderr, "removed 1 stale partial download, reclaiming %s\n", format.Bytes(freed))
+	case len(removed) > 1:
+		fmt.Fprintf(os.Stderr, "removed %d stale partial downloads, reclaiming %s\n", len(removed), format.Bytes(freed))
+	}
+}
+
+// isPodPath reports whether arg is of the form pod:/path rather than a local path.
+func isPodPath(arg string) bool {
+	i := strings.Index(arg, ":")
+	return i > 1 && !strings.ContainsAny(arg[:i], `/\`)
+}
+
+func newBar(name string, size int64) remote.Progress {
+	return progressbar.NewOptions64(
+		size,
+		progressbar.OptionSetDescription(name),
+		progressbar.OptionSetWriter(os.Stderr),
+		progressbar.OptionShowBytes(true),
+		progressbar.OptionSetWidth(30),
+		progressbar.OptionThrottle(100*time.Millisecond),
+		progressbar.OptionOnCompletion(func() { fmt.Fprintln(os.Stderr) }),
+	)
+}
+
+// podPath resolves "pod:/path", where pod is a pod id or name, to a running pod's ssh target.
+func podPath(pods []*api.Pod, arg string) (*remote.Target, string, error) {
+	i := strings.Index(arg, ":")
+	if i <= 0 || arg[i+1:] == "" {
+		return nil, "", fmt.Errorf("%q must be of the form pod:/path", arg)
+	}
+	ref, p := arg[:i], arg[i+1:]
+	for _, pod := range pods {
+		if pod.Id != ref && pod.Name != ref {
+			continue
+		}
+		if pod.DesiredStatus != "RUNNING" {
+			return nil, "", fmt.Errorf(`pod "%s" is not running`, ref)
+		}
+		target, err := remote.Resolve(pod)
+		return target, p, err
+	}
+	return nil, "", fmt.Errorf(`pod "%s" not found`, ref)
+}
+
+func init() {
+	CpCmd.Flags().BoolVar(&direct, "direct", false, "copy between two pods directly with runpodctl send/receive on the pods, without a local hop")
+	CpCmd.Flags().StringVarP(&labelSelector, "selector", "l", "", "pods and destination path, e.g. job=sweep1:/workspace/config.yaml")
+	CpCmd.Flags().IntVar(&parallel, "parallel", 10, "maximum number of pods to copy to concurrently")
+	CpCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "copy folders recursively")
+}
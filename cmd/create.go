@@ -3,17 +3,24 @@ package cmd
 import (
 	"cli/cmd/pod"
 	"cli/cmd/pods"
+	"cli/cmd/registryauth"
+	"cli/cmd/template"
+	"cli/cmd/volume"
 
 	"github.com/spf13/cobra"
 )
 
 var createCmd = &cobra.Command{
-	Use:   "create [command]",
-	Short: "create a resource",
-	Long:  "create a resource in runpod.io",
+	Use:              "create [command]",
+	Short:            "create a resource",
+	Long:             "create a resource in runpod.io",
+	PersistentPreRun: printWarnings,
 }
 
 func init() {
 	createCmd.AddCommand(pod.CreatePodCmd)
 	createCmd.AddCommand(pods.CreatePodsCmd)
+	createCmd.AddCommand(registryauth.CreateRegistryAuthCmd)
+	createCmd.AddCommand(template.CreateTemplateCmd)
+	createCmd.AddCommand(volume.CreateNetworkVolumeCmd)
 }
@@ -0,0 +1,195 @@
+package daemons
+
+import (
+	"cli/daemon"
+	"cli/exit"
+	"cli/format"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var DaemonsCmd = &cobra.Command{
+	Use:     "daemons [command]",
+	Aliases: []string{"daemon"},
+	Short:   "manage local daemons",
+	Long:    "list, stop and restart the long running runpodctl commands of this profile, such as keepalive, by name",
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Args:  cobra.ExactArgs(0),
+	Short: "list running daemons",
+	Long:  "list the running daemons of the current profile",
+	Run: func(cmd *cobra.Command, args []string) {
+		list, err := daemon.List()
+		exit.CheckErr(err)
+
+		data := make([][]string, len(list))
+		for i, d := range list {
+			data[i] = []string{d.Name, d.Kind, fmt.Sprint(d.Pid), format.Uptime(d.Started), "runpodctl " + strings.Join(d.Args, " ")}
+		}
+		tb := tablewriter.NewWriter(os.Stdout)
+		tb.SetHeader([]string{"Name", "Kind", "Pid", "Uptime", "Command"})
+		tb.AppendBulk(data)
+		format.TableDefaults(tb)
+		tb.Render()
+	},
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop [name]...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "stop daemons",
+	Long:  "stop daemons by name, waiting for them to exit",
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, name := range args {
+			d, err := daemon.Get(name)
+			exit.CheckErr(err)
+			exit.CheckErr(d.Stop())
+			fmt.Printf(`daemon "%s" stopped`, name)
+			fmt.Println()
+		}
+	},
+}
+
+var restartCmd = &cobra.Command{
+	Use:   "restart [name]...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "restart daemons",
+	Long:  "stop daemons by name and run their commands again in the background, logging to a file",
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, name := range args {
+			d, err := daemon.Get(name)
+			exit.CheckErr(err)
+			exit.CheckErr(d.Stop())
+			pid, err := d.Start()
+			exit.CheckErr(err)
+			if d.Service != "" {
+				fmt.Printf(`daemon "%s" restarted as service %s with pid %d`, name, d.Service, pid)
+			} else {
+				fmt.Printf(`daemon "%s" restarted with pid %d; logging to %s`, name, pid, d.Log)
+			}
+			fmt.Println()
+		}
+	},
+}
+
+var installArgs string
+var installName string
+var installPrint bool
+var noStart bool
+var windowsService bool
+
+var installCmd = &cobra.Command{
+	Use:   "install [command]...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "run a daemon at login",
+	Long: `install a systemd user unit (linux) or launchd agent (macos) that runs a daemon command
+such as keepalive at login and restarts it if it fails, with the current profile.
+On windows, --windows-service installs it as a service that starts at boot and logs to the event log;
+this needs an administrator prompt`,
+	Run: func(cmd *cobra.Command, args []string) {
+		target, rest, err := cmd.Root().Find(args)
+		if err != nil || len(rest) > 0 || target.Annotations["daemon"] == "" {
+			exit.CheckErr(fmt.Errorf("%s is not a daemon command; daemons are: %s", strings.Join(args, " "), strings.Join(daemonCommands(cmd.Root()), ", ")))
+		}
+		// the command's path below the root, e.g. schedule run
+		commandPath := strings.Fields(target.CommandPath())[1:]
+		commandArgs, err := daemon.SplitArgs(installArgs)
+		exit.CheckErr(err)
+		if installName == "" {
+			// name it like the daemon names itself, e.g. keepalive-{podId}
+			installName = commandPath[0]
+			if len(commandArgs) > 0 && !strings.HasPrefix(commandArgs[0], "-") {
+				installName += "-" + commandArgs[0]
+			}
+		}
+		commandArgs = append(commandPath, commandArgs...)
+		if target.Flags().Lookup("name") != nil && !hasFlag(commandArgs, "--name") {
+			// keep the registry name the same as the unit name
+			commandArgs = append(commandArgs, "--name", installName)
+		}
+		unit, err := daemon.NewUnit(installName, commandArgs)
+		exit.CheckErr(err)
+		unit.Service = windowsService
+
+		if installPrint {
+			path, err := unit.Path()
+			exit.CheckErr(err)
+			content, err := unit.Render()
+			exit.CheckErr(err)
+			fmt.Printf("# %s\n%s", path, content)
+			return
+		}
+		path, err := unit.Install(!noStart)
+		exit.CheckErr(err)
+		if unit.Service {
+			fmt.Printf(`daemon "%s" installed as service %s; its output goes to the Application event log`, installName, path)
+		} else {
+			fmt.Printf(`daemon "%s" installed at %s`, installName, path)
+		}
+		fmt.Println()
+	},
+}
+
+var uninstallCmd = &cobra.Command{
+	Use:   "uninstall [name]",
+	Args:  cobra.ExactArgs(1),
+	Short: "stop running a daemon at login",
+	Long:  "stop and remove a daemon installed with runpodctl daemons install",
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := daemon.Uninstall(args[0])
+		exit.CheckErr(err)
+		if runtime.GOOS == "windows" {
+			fmt.Printf(`daemon "%s" uninstalled; removed service %s`, args[0], path)
+		} else {
+			fmt.Printf(`daemon "%s" uninstalled; removed %s`, args[0], path)
+		}
+		fmt.Println()
+	},
+}
+
+// daemonCommands lists the commands that can run as daemons, marked with the
+// "daemon" annotation.
+func daemonCommands(root *cobra.Command) []string {
+	var names []string
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		for _, sub := range c.Commands() {
+			if sub.Annotations["daemon"] != "" {
+				names = append(names, strings.TrimPrefix(sub.CommandPath(), root.Name()+" "))
+			}
+			walk(sub)
+		}
+	}
+	walk(root)
+	return names
+}
+
+func hasFlag(args []string, flag string) bool {
+	for _, a := range args {
+		if a == flag || strings.HasPrefix(a, flag+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	installCmd.Flags().StringVar(&installArgs, "args", "", "arguments for the command, e.g. '{podId} --interval 1m'")
+	installCmd.Flags().StringVar(&installName, "name", "", "daemon name; defaults to the command name and its first argument, e.g. keepalive-{podId}")
+	installCmd.Flags().BoolVar(&installPrint, "print", false, "print the unit file instead of installing it")
+	installCmd.Flags().BoolVar(&noStart, "no-start", false, "install the unit without enabling and starting it")
+	installCmd.Flags().BoolVar(&windowsService, "windows-service", false, "install a windows service instead of a unit file")
+
+	DaemonsCmd.AddCommand(installCmd)
+	DaemonsCmd.AddCommand(listCmd)
+	DaemonsCmd.AddCommand(stopCmd)
+	DaemonsCmd.AddCommand(restartCmd)
+	DaemonsCmd.AddCommand(uninstallCmd)
+}
@@ -0,0 +1,77 @@
+package dataset
+
+import (
+	"cli/dataset"
+	"cli/exit"
+	"cli/format"
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var datasetPath string
+var url string
+var volume string
+
+var DatasetCmd = &cobra.Command{
+	Use:   "dataset [command]",
+	Short: "manage named datasets",
+	Long:  "register named datasets backed by a network volume or a url, for use with create pod --dataset",
+}
+
+var addCmd = &cobra.Command{
+	Use:   "add [name]",
+	Args:  cobra.ExactArgs(1),
+	Short: "add a dataset",
+	Long:  "add a dataset on a network volume (--volume) or at a url that is downloaded into the pod (--url s3://, gs:// or https://)",
+	Run: func(cmd *cobra.Command, args []string) {
+		d := &dataset.Dataset{Name: args[0], Volume: volume, Url: url, Path: datasetPath}
+		exit.CheckErr(dataset.Add(d))
+		fmt.Printf(`dataset "%s" added`, d.Name)
+		fmt.Println()
+	},
+}
+
+var listCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Args:    cobra.ExactArgs(0),
+	Short:   "list datasets",
+	Long:    "list registered datasets",
+	Run: func(cmd *cobra.Command, args []string) {
+		datasets := dataset.List()
+		data := make([][]string, len(datasets))
+		for i, d := range datasets {
+			data[i] = []string{d.Name, d.Volume, d.Url, d.Path, d.Profile}
+		}
+		tb := tablewriter.NewWriter(os.Stdout)
+		tb.SetHeader([]string{"Name", "Volume", "Url", "Path", "Profile"})
+		tb.AppendBulk(data)
+		format.TableDefaults(tb)
+		tb.Render()
+	},
+}
+
+var removeCmd = &cobra.Command{
+	Use:   "remove [name]",
+	Args:  cobra.ExactArgs(1),
+	Short: "remove a dataset",
+	Long:  "remove a dataset from the registry; the data itself is not touched",
+	Run: func(cmd *cobra.Command, args []string) {
+		exit.CheckErr(dataset.Remove(args[0]))
+		fmt.Printf(`dataset "%s" removed`, args[0])
+		fmt.Println()
+	},
+}
+
+func init() {
+	addCmd.Flags().StringVar(&datasetPath, "path", "", "for a volume, the dataset directory inside the volume; for a url, where it is downloaded in the pod (default /workspace/datasets/<name>)")
+	addCmd.Flags().StringVar(&url, "url", "", "url to download the dataset from: s3://, gs:// or https://")
+	addCmd.Flags().StringVar(&volume, "volume", "", "network volume id holding the dataset")
+
+	DatasetCmd.AddCommand(addCmd)
+	DatasetCmd.AddCommand(listCmd)
+	DatasetCmd.AddCommand(removeCmd)
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"cli/cmd/pod"
+
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor [command]",
+	Short: "diagnose a resource",
+	Long:  "run diagnostics on a resource in runpod.io and summarize likely problems",
+}
+
+func init() {
+	doctorCmd.AddCommand(pod.DoctorPodCmd)
+}
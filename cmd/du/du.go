@@ -0,0 +1,92 @@
+package du
+
+import (
+	"bufio"
+	"bytes"
+	"cli/api"
+	"cli/complete"
+	"cli/exit"
+	"cli/format"
+	"cli/remote"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var depth int
+var scanPath string
+var top int
+
+var DuCmd = &cobra.Command{
+	Use:               "du [podId]",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: complete.PodId,
+	Short:             "pod disk usage",
+	Long:              "scan disk usage inside a running pod over ssh and list the largest directories",
+	Run: func(cmd *cobra.Command, args []string) {
+		pod, err := api.DefaultClient.GetPod(cmd.Context(), args[0])
+		exit.CheckErr(err)
+		target, err := remote.Resolve(pod)
+		exit.CheckErr(err)
+
+		script := fmt.Sprintf("df -kP %[1]s | tail -n 1; echo; du -x -k -d %[2]d %[1]s 2>/dev/null", remote.Quote(scanPath), depth)
+		var stdout, stderr bytes.Buffer
+		err = target.Run(context.Background(), script, nil, &stdout, &stderr)
+		if err != nil && stdout.Len() == 0 {
+			exit.CheckErr(fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String())))
+		}
+
+		sc := bufio.NewScanner(&stdout)
+		if sc.Scan() {
+			// Filesystem 1024-blocks Used Available Capacity Mounted-on
+			f := strings.Fields(sc.Text())
+			if len(f) >= 6 {
+				total, _ := strconv.ParseInt(f[1], 10, 64)
+				used, _ := strconv.ParseInt(f[2], 10, 64)
+				fmt.Printf("%s mounted on %s: %s used of %s (%s)\n", scanPath, f[5], format.Bytes(used*1024), format.Bytes(total*1024), f[4])
+			}
+		}
+		type entry struct {
+			size int64
+			path string
+		}
+		var entries []entry
+		for sc.Scan() {
+			parts := strings.SplitN(sc.Text(), "\t", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			size, err := strconv.ParseInt(parts[0], 10, 64)
+			if err != nil {
+				continue
+			}
+			entries = append(entries, entry{size * 1024, parts[1]})
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].size > entries[j].size })
+		if top > 0 && len(entries) > top {
+			entries = entries[:top]
+		}
+
+		data := make([][]string, len(entries))
+		for i, e := range entries {
+			data[i] = []string{format.Bytes(e.size), e.path}
+		}
+		tb := tablewriter.NewWriter(os.Stdout)
+		tb.SetHeader([]string{"Size", "Path"})
+		tb.AppendBulk(data)
+		format.TableDefaults(tb)
+		tb.Render()
+	},
+}
+
+func init() {
+	DuCmd.Flags().IntVar(&depth, "depth", 2, "directory depth to report")
+	DuCmd.Flags().StringVar(&scanPath, "path", "/workspace", "path to scan inside the pod")
+	DuCmd.Flags().IntVar(&top, "top", 20, "number of largest directories to show; 0 for all")
+}
@@ -0,0 +1,121 @@
+package endpoint
+
+import (
+	"cli/api"
+	"cli/exit"
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var duration time.Duration
+var interval time.Duration
+var killWorkers int
+var pollInterval time.Duration
+
+var ChaosEndpointCmd = &cobra.Command{
+	Use:   "endpoint [endpointId]",
+	Args:  cobra.ExactArgs(1),
+	Short: "terminate endpoint workers on a schedule",
+	Long:  "terminate running workers of a serverless endpoint every interval to validate retries and autoscaling, then report the request failures observed during the run",
+	Run: func(cmd *cobra.Command, args []string) {
+		endpointId := args[0]
+		if interval <= 0 || duration <= 0 {
+			exit.CheckErr(fmt.Errorf("interval and duration must be > 0"))
+		}
+		before, err := api.DefaultClient.GetEndpointHealth(cmd.Context(), endpointId)
+		exit.CheckErr(err)
+
+		rand.Seed(time.Now().UnixNano())
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		end := time.After(duration)
+		nextKill := time.After(0)
+		poll := time.NewTicker(pollInterval)
+		defer poll.Stop()
+
+		killed := 0
+		peakQueue := before.Jobs.InQueue
+		minRunning := before.Workers.Running
+	loop:
+		for {
+			select {
+			case <-nextKill:
+				killed += kill(cmd.Context(), endpointId)
+				nextKill = time.After(interval)
+			case <-poll.C:
+				health, err := api.DefaultClient.GetEndpointHealth(cmd.Context(), endpointId)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "health check failed: %s\n", err)
+					continue
+				}
+				if health.Jobs.InQueue > peakQueue {
+					peakQueue = health.Jobs.InQueue
+				}
+				if health.Workers.Running < minRunning {
+					minRunning = health.Workers.Running
+				}
+			case <-end:
+				break loop
+			case <-interrupt:
+				fmt.Println("interrupted")
+				break loop
+			}
+		}
+
+		after, err := api.DefaultClient.GetEndpointHealth(cmd.Context(), endpointId)
+		exit.CheckErr(err)
+		fmt.Printf("workers killed: %d\n", killed)
+		fmt.Printf("jobs completed: %d\n", after.Jobs.Completed-before.Jobs.Completed)
+		fmt.Printf("jobs failed: %d\n", after.Jobs.Failed-before.Jobs.Failed)
+		fmt.Printf("jobs retried: %d\n", after.Jobs.Retried-before.Jobs.Retried)
+		fmt.Printf("peak queue: %d\n", peakQueue)
+		fmt.Printf("min running workers: %d\n", minRunning)
+		if after.Jobs.Failed > before.Jobs.Failed {
+			exit.CheckErr(fmt.Errorf("%d jobs failed during the run", after.Jobs.Failed-before.Jobs.Failed))
+		}
+	},
+}
+
+func init() {
+	ChaosEndpointCmd.Flags().DurationVar(&duration, "duration", time.Hour, "how long to run")
+	ChaosEndpointCmd.Flags().DurationVar(&interval, "interval", 5*time.Minute, "time between worker terminations")
+	ChaosEndpointCmd.Flags().IntVar(&killWorkers, "kill-workers", 1, "number of workers to terminate each interval")
+	ChaosEndpointCmd.Flags().DurationVar(&pollInterval, "poll", 30*time.Second, "health polling interval")
+}
+
+// kill terminates up to killWorkers random running workers and returns how many were terminated.
+func kill(ctx context.Context, endpointId string) (killed int) {
+	workers, err := api.DefaultClient.GetEndpointWorkers(ctx, endpointId)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "list workers failed: %s\n", err)
+		return
+	}
+	var running []*api.EndpointWorker
+	for _, w := range workers {
+		if w.DesiredStatus == "RUNNING" {
+			running = append(running, w)
+		}
+	}
+	rand.Shuffle(len(running), func(i, j int) { running[i], running[j] = running[j], running[i] })
+	for _, w := range running {
+		if killed >= killWorkers {
+			break
+		}
+		if err := api.DefaultClient.RemovePod(ctx, w.Id); err != nil {
+			fmt.Fprintf(os.Stderr, "%s terminate worker %s failed: %s\n", time.Now().Format(time.RFC3339), w.Id, err)
+			continue
+		}
+		killed++
+		fmt.Printf("%s terminated worker %s\n", time.Now().Format(time.RFC3339), w.Id)
+	}
+	if len(running) == 0 {
+		fmt.Printf("%s no running workers to terminate\n", time.Now().Format(time.RFC3339))
+	}
+	return
+}
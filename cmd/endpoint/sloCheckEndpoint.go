@@ -0,0 +1,101 @@
+package endpoint
+
+import (
+	"cli/api"
+	"cli/exit"
+	"cli/format"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var allowIdle bool
+var maxErrorRate string
+var maxP95 time.Duration
+var window time.Duration
+
+var SloCheckEndpointCmd = &cobra.Command{
+	Use:   "endpoint [endpointId]",
+	Args:  cobra.ExactArgs(1),
+	Short: "check endpoint slo",
+	Long:  "check the p95 latency and error rate of an endpoint over a recent window and exit non-zero on breach",
+	Run: func(cmd *cobra.Command, args []string) {
+		errorRate, err := parseRate(maxErrorRate)
+		exit.CheckErr(err)
+		if maxP95 <= 0 && errorRate < 0 {
+			exit.CheckErr(fmt.Errorf("set --p95 and/or --error-rate"))
+		}
+		metrics, err := api.DefaultClient.GetEndpointMetrics(cmd.Context(), args[0], time.Now().Add(-window))
+		exit.CheckErr(err)
+		// without requests there is nothing to measure, and an endpoint that
+		// gets none may well be broken
+		if metrics.Requests == 0 {
+			if !allowIdle {
+				exit.CheckErr(fmt.Errorf("endpoint %s had no requests in the last %s, so its slo cannot be checked; pass --allow-idle to accept that", args[0], window))
+			}
+			fmt.Fprintf(os.Stderr, "warning: endpoint %s had no requests in the last %s; nothing to check\n", args[0], window)
+			return
+		}
+
+		breached := 0
+		data := [][]string{}
+		if maxP95 > 0 {
+			p95 := time.Duration(metrics.LatencyP95Ms * float64(time.Millisecond))
+			status := "ok"
+			if p95 > maxP95 {
+				status = "BREACH"
+				breached++
+			}
+			data = append(data, []string{"p95 latency", p95.Round(time.Millisecond).String(), maxP95.String(), status})
+		}
+		if errorRate >= 0 {
+			observed := float64(metrics.Failed) / float64(metrics.Requests)
+			status := "ok"
+			if observed > errorRate {
+				status = "BREACH"
+				breached++
+			}
+			data = append(data, []string{"error rate", fmt.Sprintf("%.2f%%", observed*100), fmt.Sprintf("%.2f%%", errorRate*100), status})
+		}
+
+		fmt.Printf("%d requests in the last %s\n", metrics.Requests, window)
+		header := []string{"Metric", "Observed", "Threshold", "Status"}
+		format.Highlight(header, data)
+		tb := tablewriter.NewWriter(os.Stdout)
+		tb.SetHeader(header)
+		tb.AppendBulk(data)
+		format.TableDefaults(tb)
+		tb.Render()
+		if breached > 0 {
+			exit.CheckErr(fmt.Errorf("endpoint %s breached %d slo(s)", args[0], breached))
+		}
+	},
+}
+
+func init() {
+	SloCheckEndpointCmd.Flags().BoolVar(&allowIdle, "allow-idle", false, "pass with a warning when the endpoint had no requests in the window")
+	SloCheckEndpointCmd.Flags().StringVar(&maxErrorRate, "error-rate", "", "maximum error rate, e.g. 1% or 0.01")
+	SloCheckEndpointCmd.Flags().DurationVar(&maxP95, "p95", 0, "maximum p95 latency, e.g. 2s")
+	SloCheckEndpointCmd.Flags().DurationVar(&window, "window", 15*time.Minute, "window of recent requests to evaluate")
+}
+
+// parseRate parses "1%" or "0.01"; an empty string returns -1.
+func parseRate(s string) (float64, error) {
+	if s == "" {
+		return -1, nil
+	}
+	percent := strings.HasSuffix(s, "%")
+	rate, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil || rate < 0 {
+		return 0, fmt.Errorf("invalid error rate: %s", s)
+	}
+	if percent {
+		rate /= 100
+	}
+	return rate, nil
+}
@@ -0,0 +1,96 @@
+package env
+
+import (
+	"cli/api"
+	"cli/complete"
+	"cli/exit"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var envFormat string
+
+var EnvCmd = &cobra.Command{
+	Use:               "env [podId]",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: complete.PodId,
+	Short:             "print a running pod's connection details as environment variables",
+	Long: `print the pod id, public ip, public tcp port of each exposed port (RUNPOD_TCP_PORT_22) and the proxy url
+of each http port (RUNPOD_PROXY_URL_8888) of a running pod, for scripts and docker compose:
+
+  eval "$(runpodctl env {podId})"
+  runpodctl env {podId} --format dotenv > .env`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if envFormat != "shell" && envFormat != "dotenv" {
+			exit.CheckErr(fmt.Errorf("invalid --format %q: use shell or dotenv", envFormat))
+		}
+		pod, err := api.DefaultClient.GetPod(cmd.Context(), args[0])
+		exit.CheckErr(err)
+		vars, err := podEnv(pod)
+		exit.CheckErr(err)
+		for _, v := range vars {
+			if envFormat == "shell" {
+				fmt.Printf("export %s=%s\n", v[0], shellQuote(v[1]))
+			} else {
+				fmt.Printf("%s=%s\n", v[0], dotenvQuote(v[1]))
+			}
+		}
+	},
+}
+
+func init() {
+	EnvCmd.Flags().StringVar(&envFormat, "format", "shell", "shell for export statements or dotenv for a .env file")
+}
+
+// podEnv returns the variables of a running pod, in a stable order.
+func podEnv(pod *api.Pod) ([][2]string, error) {
+	if pod.Runtime == nil {
+		return nil, fmt.Errorf(`pod "%s" is not running; its ports are only mapped while it runs`, pod.Id)
+	}
+	vars := [][2]string{{"RUNPOD_POD_ID", pod.Id}, {"RUNPOD_POD_NAME", pod.Name}}
+
+	var public []*api.RuntimePort
+	for _, p := range pod.Runtime.Ports {
+		if p.IsIpPublic && p.Type == "tcp" {
+			public = append(public, p)
+		}
+	}
+	sort.Slice(public, func(i, j int) bool { return public[i].PrivatePort < public[j].PrivatePort })
+	if len(public) > 0 {
+		vars = append(vars, [2]string{"RUNPOD_PUBLIC_IP", public[0].Ip})
+	}
+	for _, p := range public {
+		vars = append(vars, [2]string{"RUNPOD_TCP_PORT_" + strconv.Itoa(p.PrivatePort), strconv.Itoa(p.PublicPort)})
+	}
+
+	var http []int
+	for _, p := range strings.Split(pod.Ports, ",") {
+		port, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(p), "/http"))
+		if err == nil && strings.HasSuffix(strings.TrimSpace(p), "/http") {
+			http = append(http, port)
+		}
+	}
+	sort.Ints(http)
+	for _, port := range http {
+		vars = append(vars, [2]string{"RUNPOD_PROXY_URL_" + strconv.Itoa(port), fmt.Sprintf("https://%s-%d.proxy.runpod.net", pod.Id, port)})
+	}
+	return vars, nil
+}
+
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.:/@") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func dotenvQuote(s string) string {
+	if !strings.ContainsAny(s, " \t#'\"\\$") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`).Replace(s) + `"`
+}
@@ -0,0 +1,181 @@
+package exec
+
+import (
+	"bytes"
+	"cli/api"
+	"cli/complete"
+	"cli/exit"
+	"cli/fleet"
+	"cli/format"
+	"cli/remote"
+	"cli/selector"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var interactive bool
+var labelSelector string
+var parallel int
+var table bool
+var timeout time.Duration
+var tty bool
+
+var ExecCmd = &cobra.Command{
+	Use:               "exec [podId] -- command",
+	ValidArgsFunction: complete.PodId,
+	Short:             "run a command in pods",
+	Long: `run a command over ssh in a pod, or in every running pod matching --selector;
+selector keys are env var names or name, id, status, gpu and image.
+use -it without a command for an interactive shell`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if labelSelector != "" {
+			if cmd.ArgsLenAtDash() != 0 || len(args) == 0 {
+				return fmt.Errorf("command must follow --, e.g. runpodctl exec --selector job=sweep1 -- nvidia-smi")
+			}
+			return nil
+		}
+		dash := cmd.ArgsLenAtDash()
+		if len(args) == 0 || dash > 1 || (dash < 0 && len(args) > 1) {
+			return fmt.Errorf("usage: runpodctl exec [podId] -- command")
+		}
+		if len(args) == 1 && !interactive {
+			return fmt.Errorf("command must follow --, or use -it for an interactive shell")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if labelSelector == "" {
+			execPod(cmd.Context(), args[0], strings.Join(args[1:], " "))
+			return
+		}
+		command := strings.Join(args, " ")
+		sel, err := selector.Parse(labelSelector)
+		exit.CheckErr(err)
+		pods, err := api.DefaultClient.GetPods(cmd.Context())
+		exit.CheckErr(err)
+
+		var targets []*api.Pod
+		for _, p := range sel.Filter(pods) {
+			if p.DesiredStatus == "RUNNING" {
+				targets = append(targets, p)
+			}
+		}
+		if len(targets) == 0 {
+			exit.CheckErr(fmt.Errorf("no running pods match selector %q", labelSelector))
+		}
+
+		outputs := make(map[string]*bytes.Buffer, len(targets))
+		for _, p := range targets {
+			outputs[p.Id] = &bytes.Buffer{}
+		}
+		var rows [][]string
+		var header []string
+		failed := fleet.Run(targets, parallel, func(p *api.Pod) error {
+			target, err := remote.Resolve(p)
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			out := outputs[p.Id]
+			if table {
+				return target.Run(ctx, command, nil, out, os.Stderr)
+			}
+			return target.Run(ctx, command, nil, out, out)
+		}, func(r fleet.Result) {
+			out := outputs[r.Pod.Id]
+			if table {
+				records, err := csv.NewReader(out).ReadAll()
+				if err == nil && len(records) > 0 {
+					if header == nil {
+						header = append([]string{"Pod"}, records[0]...)
+					}
+					for _, rec := range records[1:] {
+						rows = append(rows, append([]string{r.Pod.Name}, trim(rec)...))
+					}
+				}
+				if r.Err != nil {
+					fmt.Fprintf(os.Stderr, "%s (%s): %s\n", r.Pod.Name, r.Pod.Id, r.Err)
+				}
+				return
+			}
+			status := "ok"
+			if r.Err != nil {
+				status = r.Err.Error()
+			}
+			fmt.Printf("==> %s (%s): %s <==\n", r.Pod.Name, r.Pod.Id, status)
+			fmt.Print(out.String())
+			if out.Len() > 0 && !bytes.HasSuffix(out.Bytes(), []byte("\n")) {
+				fmt.Println()
+			}
+		})
+
+		if table && header != nil {
+			tb := tablewriter.NewWriter(os.Stdout)
+			tb.SetHeader(header)
+			tb.AppendBulk(rows)
+			format.TableDefaults(tb)
+			tb.Render()
+		}
+		if failed > 0 {
+			exit.CheckErr(fmt.Errorf("command failed on %d of %d pods", failed, len(targets)))
+		}
+	},
+}
+
+func init() {
+	ExecCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "keep stdin open")
+	ExecCmd.Flags().StringVarP(&labelSelector, "selector", "l", "", "pods to run on, e.g. job=sweep1,name=train-*")
+	ExecCmd.Flags().IntVar(&parallel, "parallel", 10, "maximum number of pods to run on concurrently")
+	ExecCmd.Flags().BoolVar(&table, "table", false, "merge csv output with a header row (e.g. nvidia-smi --format=csv) into one table")
+	ExecCmd.Flags().DurationVar(&timeout, "timeout", 0, "per pod timeout, e.g. 30s; 0 for none")
+	ExecCmd.Flags().BoolVarP(&tty, "tty", "t", false, "allocate a terminal")
+}
+
+// execPod runs command in a single pod with the local terminal attached and
+// exits with the remote exit code.
+func execPod(ctx context.Context, podId string, command string) {
+	pod, err := api.DefaultClient.GetPod(ctx, podId)
+	exit.CheckErr(err)
+	target, err := remote.Resolve(pod)
+	exit.CheckErr(err)
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	c, err := target.Command(ctx, command, tty)
+	exit.CheckErr(err)
+	if interactive || tty {
+		c.Stdin = os.Stdin
+	}
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	err = c.Run()
+	var exitErr *osexec.ExitError
+	if errors.As(err, &exitErr) {
+		exit.With(exitErr.ExitCode())
+	}
+	exit.CheckErr(err)
+}
+
+func trim(fields []string) []string {
+	for i, f := range fields {
+		fields[i] = strings.TrimSpace(f)
+	}
+	return fields
+}
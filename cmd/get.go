@@ -2,18 +2,29 @@ package cmd
 
 import (
 	"cli/cmd/cloud"
+	"cli/cmd/gpu"
 	"cli/cmd/pod"
+	"cli/cmd/registryauth"
+	"cli/cmd/spend"
+	"cli/cmd/template"
+	"cli/cmd/volume"
 
 	"github.com/spf13/cobra"
 )
 
 var getCmd = &cobra.Command{
-	Use:   "get [command]",
-	Short: "get resource",
-	Long:  "get resources for pods",
+	Use:              "get [command]",
+	Short:            "get resource",
+	Long:             "get resources for pods",
+	PersistentPreRun: printWarnings,
 }
 
 func init() {
 	getCmd.AddCommand(cloud.GetCloudCmd)
+	getCmd.AddCommand(gpu.GetGpuTypesCmd)
 	getCmd.AddCommand(pod.GetPodCmd)
+	getCmd.AddCommand(spend.GetSpendCmd)
+	getCmd.AddCommand(registryauth.GetRegistryAuthCmd)
+	getCmd.AddCommand(template.GetTemplateCmd)
+	getCmd.AddCommand(volume.GetNetworkVolumeCmd)
 }
@@ -0,0 +1,142 @@
+package gpu
+
+import (
+	"cli/api"
+	"cli/exit"
+	"cli/format"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var cloudType string
+var gpuCount int
+var maxPrice float64
+var minVram int
+var output string
+var outputTemplate string
+
+var GetGpuTypesCmd = &cobra.Command{
+	Use:     "gpu-types",
+	Aliases: []string{"gpu-type"},
+	Args:    cobra.ExactArgs(0),
+	Short:   "get gpu types with availability and pricing",
+	Long:    "get all gpu types with vram, the clouds they are offered in, on-demand price and lowest spot bid; available types are listed cheapest first",
+	Run: func(cmd *cobra.Command, args []string) {
+		input := &api.GetCloudInput{GpuCount: gpuCount}
+		switch cloudType {
+		case "all":
+		case "secure", "community":
+			secure := cloudType == "secure"
+			input.SecureCloud = &secure
+		default:
+			exit.CheckErr(fmt.Errorf("unknown cloud type %q: use secure, community or all", cloudType))
+		}
+		gpuTypes, err := api.DefaultClient.GetGpuTypes(cmd.Context(), input)
+		exit.CheckErr(format.Partial(err))
+
+		var filtered []*api.GpuType
+		for _, g := range gpuTypes {
+			if cloudType == "secure" && !g.SecureCloud || cloudType == "community" && !g.CommunityCloud {
+				continue
+			}
+			if g.MemoryInGb < minVram {
+				continue
+			}
+			if maxPrice > 0 && !(available(g) && g.LowestPrice.UninterruptablePrice <= maxPrice) {
+				continue
+			}
+			filtered = append(filtered, g)
+		}
+		sort.SliceStable(filtered, func(i, j int) bool {
+			a, b := filtered[i], filtered[j]
+			if available(a) != available(b) {
+				return available(a)
+			}
+			if available(a) && a.LowestPrice.UninterruptablePrice != b.LowestPrice.UninterruptablePrice {
+				return a.LowestPrice.UninterruptablePrice < b.LowestPrice.UninterruptablePrice
+			}
+			return a.Id < b.Id
+		})
+
+		printed, err := format.Print(os.Stdout, output, outputTemplate, filtered)
+		exit.CheckErr(err)
+		if printed {
+			return
+		}
+		columns, err := format.ColumnSet(output)
+		exit.CheckErr(err)
+		if columns != nil {
+			exit.CheckErr(format.PrintColumns(os.Stdout, columns, filtered, gpuTypeColumns))
+			return
+		}
+
+		data := make([][]string, len(filtered))
+		for i, g := range filtered {
+			data[i] = []string{
+				g.Id,
+				fmt.Sprintf("%d", g.MemoryInGb),
+				yesNo(g.SecureCloud),
+				yesNo(g.CommunityCloud),
+				onDemandPrice(g),
+				spotPrice(g),
+			}
+		}
+
+		header := []string{"ID", "VRAM GB", "Secure", "Community", "OnDemand $/HR", "Spot $/HR"}
+		format.Highlight(header, data)
+		tb := tablewriter.NewWriter(os.Stdout)
+		tb.SetHeader(header)
+		tb.AppendBulk(data)
+		format.TableDefaults(tb)
+		tb.Render()
+	},
+}
+
+// gpuTypeColumns are the -o columns=<set> columns that are not plain json fields.
+var gpuTypeColumns = map[string]format.Column{
+	"onDemand": func(row interface{}) string {
+		return onDemandPrice(row.(*api.GpuType))
+	},
+	"spot": func(row interface{}) string {
+		return spotPrice(row.(*api.GpuType))
+	},
+}
+
+// available reports whether a machine with the gpu type can be rented right now.
+func available(g *api.GpuType) bool {
+	return g.LowestPrice != nil && g.LowestPrice.UninterruptablePrice > 0
+}
+
+func onDemandPrice(g *api.GpuType) string {
+	if !available(g) {
+		return "-"
+	}
+	return fmt.Sprintf("%.3f", g.LowestPrice.UninterruptablePrice)
+}
+
+func spotPrice(g *api.GpuType) string {
+	if g.LowestPrice == nil || g.LowestPrice.MinimumBidPrice <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.3f", g.LowestPrice.MinimumBidPrice)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func init() {
+	GetGpuTypesCmd.Flags().StringVar(&cloudType, "cloud-type", "all", "only gpu types offered in this cloud, priced there: secure, community or all")
+	GetGpuTypesCmd.Flags().IntVar(&gpuCount, "gpuCount", 1, "number of gpus the prices are for")
+	GetGpuTypesCmd.Flags().Float64Var(&maxPrice, "max-price", 0, "only available gpu types with an on-demand $/hr at or below this")
+	GetGpuTypesCmd.Flags().IntVar(&minVram, "min-vram", 0, "only gpu types with at least this much vram in GB")
+	GetGpuTypesCmd.Flags().StringVarP(&output, "output", "o", "", "output format: columns=<set>, json, yaml, jsonpath=<expression> or template=<go template>")
+	GetGpuTypesCmd.Flags().StringVar(&outputTemplate, "template", "", "go template for the output; fields are named as in -o json, e.g. '{{range .}}{{.id}}{{end}}'")
+}
@@ -0,0 +1,144 @@
+package graph
+
+import (
+	"cli/api"
+	"cli/exit"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var output string
+
+var GraphCmd = &cobra.Command{
+	Use:   "graph",
+	Args:  cobra.ExactArgs(0),
+	Short: "diagram of resources",
+	Long:  "print a diagram of endpoints, templates, images, pods, volumes and datacenters in mermaid or dot format",
+	Run: func(cmd *cobra.Command, args []string) {
+		if output != "mermaid" && output != "dot" {
+			exit.CheckErr(fmt.Errorf("unknown output format: %s", output))
+		}
+		endpoints, err := api.DefaultClient.GetEndpoints(cmd.Context())
+		exit.CheckErr(err)
+		pods, err := api.DefaultClient.GetPods(cmd.Context())
+		exit.CheckErr(err)
+
+		g := newGraph()
+		for _, e := range endpoints {
+			endpoint := g.node("endpoint", e.Id, e.Name)
+			if e.Template != nil {
+				template := g.node("template", e.Template.Id, e.Template.Name)
+				g.edge(endpoint, template)
+				g.edge(template, g.node("image", e.Template.ImageName, e.Template.ImageName))
+			} else if e.TemplateId != "" {
+				g.edge(endpoint, g.node("template", e.TemplateId, e.TemplateId))
+			}
+			if e.NetworkVolumeId != "" {
+				g.edge(endpoint, g.node("volume", e.NetworkVolumeId, e.NetworkVolumeId))
+			}
+		}
+		for _, p := range pods {
+			pod := g.node("pod", p.Id, p.Name)
+			if p.TemplateId != "" {
+				g.edge(pod, g.node("template", p.TemplateId, p.TemplateId))
+			}
+			g.edge(pod, g.node("image", p.ImageName, p.ImageName))
+			if p.NetworkVolumeId != "" {
+				g.edge(pod, g.node("volume", p.NetworkVolumeId, p.NetworkVolumeId))
+			}
+			if p.Machine != nil && p.Machine.DataCenterId != "" {
+				g.edge(pod, g.node("datacenter", p.Machine.DataCenterId, p.Machine.DataCenterId))
+			}
+		}
+
+		if output == "dot" {
+			fmt.Print(g.dot())
+		} else {
+			fmt.Print(g.mermaid())
+		}
+	},
+}
+
+func init() {
+	GraphCmd.Flags().StringVarP(&output, "output", "o", "mermaid", "diagram format: mermaid or dot")
+}
+
+type node struct {
+	id    string
+	kind  string
+	label string
+}
+
+type graph struct {
+	nodes []*node
+	byKey map[string]*node
+	edges [][2]*node
+	seen  map[[2]*node]bool
+}
+
+func newGraph() *graph {
+	return &graph{byKey: map[string]*node{}, seen: map[[2]*node]bool{}}
+}
+
+// node returns the node for kind/key, creating it on first use. Templates
+// first seen by id get their label upgraded when a name shows up later.
+func (g *graph) node(kind string, key string, label string) *node {
+	if n, ok := g.byKey[kind+"/"+key]; ok {
+		if label != "" && n.label == key {
+			n.label = label
+		}
+		return n
+	}
+	if label == "" {
+		label = key
+	}
+	n := &node{id: fmt.Sprintf("n%d", len(g.nodes)+1), kind: kind, label: label}
+	g.nodes = append(g.nodes, n)
+	g.byKey[kind+"/"+key] = n
+	return n
+}
+
+func (g *graph) edge(from *node, to *node) {
+	e := [2]*node{from, to}
+	if !g.seen[e] {
+		g.seen[e] = true
+		g.edges = append(g.edges, e)
+	}
+}
+
+func (g *graph) mermaid() string {
+	var sb strings.Builder
+	sb.WriteString("graph LR\n")
+	for _, n := range g.nodes {
+		label := strings.ReplaceAll(n.kind+": "+n.label, `"`, "#quot;")
+		fmt.Fprintf(&sb, "  %s[\"%s\"]\n", n.id, label)
+	}
+	for _, e := range g.edges {
+		fmt.Fprintf(&sb, "  %s --> %s\n", e[0].id, e[1].id)
+	}
+	return sb.String()
+}
+
+func (g *graph) dot() string {
+	shapes := map[string]string{
+		"endpoint":   "box",
+		"template":   "note",
+		"image":      "component",
+		"pod":        "box",
+		"volume":     "cylinder",
+		"datacenter": "house",
+	}
+	var sb strings.Builder
+	sb.WriteString("digraph runpod {\n  rankdir=LR;\n")
+	for _, n := range g.nodes {
+		label := strings.ReplaceAll(n.kind+": "+n.label, `"`, `\"`)
+		fmt.Fprintf(&sb, "  %s [label=\"%s\", shape=%s];\n", n.id, label, shapes[n.kind])
+	}
+	for _, e := range g.edges {
+		fmt.Fprintf(&sb, "  %s -> %s;\n", e[0].id, e[1].id)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
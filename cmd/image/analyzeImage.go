@@ -0,0 +1,94 @@
+package image
+
+import (
+	"cli/api"
+	"cli/exit"
+	"cli/format"
+	"cli/registry"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var bandwidthMbps float64
+var top int
+
+var AnalyzeImageCmd = &cobra.Command{
+	Use:   "image [imageName]",
+	Args:  cobra.ExactArgs(1),
+	Short: "analyze a container image",
+	Long:  "inspect the registry manifest of an image for its size and largest layers, and estimate the pull time at an assumed bandwidth, and per datacenter where pullBandwidthMbps.<datacenterId> is set in the config",
+	Run: func(cmd *cobra.Command, args []string) {
+		ref, err := registry.ParseReference(args[0])
+		exit.CheckErr(err)
+		manifest, err := registry.NewClient().GetManifest(ref)
+		exit.CheckErr(err)
+
+		size := manifest.Size()
+		fmt.Printf("image: %s\n", ref)
+		fmt.Printf("compressed size: %s in %d layers\n\n", format.Bytes(size), len(manifest.Layers))
+
+		layers := append([]registry.Layer{}, manifest.Layers...)
+		sort.Slice(layers, func(i, j int) bool { return layers[i].Size > layers[j].Size })
+		if top > 0 && len(layers) > top {
+			layers = layers[:top]
+		}
+		data := make([][]string, len(layers))
+		for i, l := range layers {
+			share := 0.0
+			if size > 0 {
+				share = float64(l.Size) / float64(size) * 100
+			}
+			data[i] = []string{l.Digest, format.Bytes(l.Size), fmt.Sprintf("%.1f%%", share)}
+		}
+		tb := tablewriter.NewWriter(os.Stdout)
+		tb.SetHeader([]string{"Layer", "Size", "Share"})
+		tb.AppendBulk(data)
+		format.TableDefaults(tb)
+		tb.Render()
+
+		// runpod does not report how fast each datacenter pulls from a
+		// registry, so every estimate assumes a bandwidth
+		fmt.Printf("\nest. pull at an assumed %.0f Mbps: %s\n", bandwidthMbps, pullTime(size, bandwidthMbps))
+		configured := viper.GetStringMap("pullBandwidthMbps")
+		if len(configured) == 0 {
+			fmt.Println("set pullBandwidthMbps.<datacenterId> in the config for per datacenter estimates")
+			return
+		}
+		dataCenters, err := api.DefaultClient.GetDataCenters(cmd.Context())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "skipping per datacenter estimates: %s\n", err)
+			return
+		}
+		data = nil
+		for _, dc := range dataCenters {
+			mbps := viper.GetFloat64("pullBandwidthMbps." + dc.Id)
+			if mbps <= 0 {
+				continue
+			}
+			data = append(data, []string{dc.Id, dc.Location, fmt.Sprintf("%.0f", mbps), pullTime(size, mbps)})
+		}
+		fmt.Println()
+		tb = tablewriter.NewWriter(os.Stdout)
+		tb.SetHeader([]string{"Datacenter", "Location", "Configured Mbps", "Est. Pull"})
+		tb.AppendBulk(data)
+		format.TableDefaults(tb)
+		tb.Render()
+	},
+}
+
+// pullTime estimates how long size bytes take to download at mbps.
+func pullTime(size int64, mbps float64) string {
+	seconds := float64(size) * 8 / (mbps * 1e6)
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+func init() {
+	AnalyzeImageCmd.Flags().Float64Var(&bandwidthMbps, "bandwidth", 1000, "assumed registry download Mbps for the pull time estimate; set pullBandwidthMbps.<datacenterId> in config for per datacenter estimates")
+	AnalyzeImageCmd.Flags().IntVar(&top, "top", 10, "number of largest layers to show; 0 for all")
+}
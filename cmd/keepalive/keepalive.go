@@ -0,0 +1,286 @@
+package keepalive
+
+import (
+	"bytes"
+	"cli/api"
+	"cli/complete"
+	"cli/daemon"
+	"cli/exit"
+	"cli/history"
+	"cli/remote"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var checkpointCmd string
+var checkpointTimeout time.Duration
+var bidStep float32
+var daemonName string
+var interval time.Duration
+var maxBid float32
+var onDemandFallback bool
+
+var KeepaliveCmd = &cobra.Command{
+	Use:               "keepalive [podId]",
+	Annotations:       map[string]string{"daemon": "true"},
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: complete.PodId,
+	Short:             "keep a pod running",
+	Long: `watch a pod and resume it whenever it stops, unless it was stopped on purpose with stop pod, a
+schedule or the watchdog; for spot pods, run a checkpoint
+command when the pod is outbid (interruption is imminent) or right after it is interrupted.
+An interrupted spot pod is resumed at its last bid or the current minimum bid; with --max-bid
+the bid is raised by --bid-step until the pod starts or the cap is reached, and with
+--on-demand-fallback the pod then resumes on demand`,
+	Run: func(cmd *cobra.Command, args []string) {
+		exit.CheckErr(history.CheckPods(args[:1]))
+		if daemonName == "" {
+			daemonName = "keepalive-" + args[0]
+		}
+		d, err := daemon.Register("keepalive", daemonName, args[:1])
+		exit.CheckErr(err)
+		defer d.Release() //nolint
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+		k := &keeper{podId: args[0]}
+		for {
+			k.tick(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	},
+}
+
+func init() {
+	KeepaliveCmd.Flags().StringVar(&checkpointCmd, "checkpoint-cmd", "", "command run in the pod over ssh before or right after a spot interruption")
+	KeepaliveCmd.Flags().DurationVar(&checkpointTimeout, "checkpoint-timeout", 5*time.Minute, "time allowed for the checkpoint command")
+	KeepaliveCmd.Flags().StringVar(&daemonName, "name", "", "daemon name for runpodctl daemons; defaults to keepalive-<podId>")
+	KeepaliveCmd.Flags().DurationVar(&interval, "interval", 30*time.Second, "polling interval")
+	KeepaliveCmd.Flags().Float32Var(&maxBid, "max-bid", 0, "highest bid per gpu for resuming a spot pod; 0 keeps the last bid")
+	KeepaliveCmd.Flags().Float32Var(&bidStep, "bid-step", 0.01, "how much to raise the bid per gpu after a failed resume")
+	KeepaliveCmd.Flags().BoolVar(&onDemandFallback, "on-demand-fallback", false, "resume a spot pod on demand when no bid up to --max-bid works")
+}
+
+type keeper struct {
+	podId        string
+	bidPerGpu    float32
+	wasRunning   bool
+	checkpointed bool
+	// held is set while the pod is left stopped because it was stopped on
+	// purpose.
+	held bool
+}
+
+func (k *keeper) tick(ctx context.Context) {
+	pod, err := api.DefaultClient.GetPod(ctx, k.podId)
+	if err != nil {
+		logf("get pod failed: %s", err)
+		return
+	}
+	spot := pod.PodType == "INTERRUPTABLE"
+	if pod.DesiredStatus == "RUNNING" {
+		if bid := pod.BidPerGpu(); bid > 0 {
+			k.bidPerGpu = bid
+		}
+		if !k.wasRunning {
+			logf("pod %s is running", k.podId)
+		}
+		k.wasRunning = true
+		k.held = false
+		// spot prices are only looked up while running to checkpoint in time
+		if spot && checkpointCmd != "" && !k.checkpointed && k.outbid(ctx, pod) {
+			logf("pod %s is outbid at $%.3f / gpu; interruption is imminent", k.podId, k.bidPerGpu)
+			k.checkpoint(ctx, pod)
+		}
+		return
+	}
+
+	if stoppedOnPurpose(k.podId) {
+		if !k.held {
+			logf("pod %s was stopped on purpose; it is resumed once it is started again", k.podId)
+		}
+		k.held = true
+		k.wasRunning = false
+		return
+	}
+	if k.wasRunning {
+		logf("pod %s stopped; status is %s", k.podId, pod.DesiredStatus)
+		if spot && !k.checkpointed {
+			k.checkpoint(ctx, pod)
+		}
+		k.wasRunning = false
+	}
+	k.resume(ctx, pod, spot)
+}
+
+// stoppedOnPurpose reports whether the last start or stop of the pod in the
+// history is a stop, with stop pod, a schedule or the watchdog.
+func stoppedOnPurpose(podId string) bool {
+	entries, err := history.Read()
+	if err != nil {
+		logf("read history failed: %s", err)
+		return false
+	}
+	return history.StoppedOnPurpose(history.Mine(entries))[podId]
+}
+
+// outbid reports whether the current minimum spot bid for the pod's gpu
+// type is above the pod's bid.
+func (k *keeper) outbid(ctx context.Context, pod *api.Pod) bool {
+	if k.bidPerGpu <= 0 {
+		return false
+	}
+	return minimumBid(ctx, pod) > k.bidPerGpu
+}
+
+// minimumBid returns the current minimum spot bid per gpu for the pod's gpu
+// type, or 0 when it is unknown.
+func minimumBid(ctx context.Context, pod *api.Pod) float32 {
+	if pod.Machine == nil {
+		return 0
+	}
+	secure := pod.Machine.SecureCloud
+	gpuTypes, err := api.DefaultClient.GetCloud(ctx, &api.GetCloudInput{GpuCount: pod.GpuCount, SecureCloud: &secure})
+	if err != nil {
+		logf("get spot prices failed: %s", err)
+		return 0
+	}
+	for _, gpu := range gpuTypes {
+		kv := gpu.LowestPrice
+		if kv != nil && kv.GpuTypeId == pod.Machine.GpuTypeId {
+			return float32(kv.MinimumBidPrice)
+		}
+	}
+	return 0
+}
+
+// checkpoint runs the checkpoint command if the pod is still reachable and
+// records the outcome in the local history.
+func (k *keeper) checkpoint(ctx context.Context, pod *api.Pod) {
+	if checkpointCmd == "" {
+		return
+	}
+	k.checkpointed = true
+	status := "completed"
+	target, err := remote.Resolve(pod)
+	if err == nil {
+		cctx, cancel := context.WithTimeout(ctx, checkpointTimeout)
+		var out bytes.Buffer
+		err = target.Run(cctx, checkpointCmd, nil, &out, &out)
+		cancel()
+		if err != nil {
+			err = fmt.Errorf("%w: %s", err, strings.TrimSpace(out.String()))
+		}
+	}
+	if err != nil {
+		status = "failed: " + err.Error()
+	}
+	logf("checkpoint of pod %s %s", k.podId, status)
+	err = history.Append(&history.Entry{Action: "checkpoint", PodId: k.podId, Name: pod.Name, Status: status})
+	if err != nil {
+		logf("could not record history: %s", err)
+	}
+}
+
+func (k *keeper) resume(ctx context.Context, pod *api.Pod, spot bool) {
+	var err error
+	var started *api.Pod
+	if spot {
+		started, err = k.rebid(ctx, pod)
+	} else {
+		started, err = api.DefaultClient.StartOnDemandPod(ctx, k.podId)
+	}
+	if err != nil {
+		logf("resume pod %s failed: %s", k.podId, err)
+		return
+	}
+	if started.DesiredStatus == "RUNNING" {
+		logf("pod %s resumed for $%.3f / hr", k.podId, started.CostPerHr)
+		k.wasRunning = true
+		k.checkpointed = false
+	}
+}
+
+// rebidBackoff is the pause after the first failed bid, doubled after each
+// further one up to maxRebidBackoff.
+const (
+	rebidBackoff    = 5 * time.Second
+	maxRebidBackoff = time.Minute
+)
+
+// rebid resumes a spot pod at its last bid, or at the minimum bid when that
+// is higher. Without a known minimum bid it does not bid at all. When no bid
+// works it resumes the pod on demand only if --on-demand-fallback is set.
+func (k *keeper) rebid(ctx context.Context, pod *api.Pod) (*api.Pod, error) {
+	var err error
+	if min := minimumBid(ctx, pod); min <= 0 {
+		err = fmt.Errorf("the minimum bid is unknown, so no bid is made")
+	} else {
+		bid := k.bidPerGpu
+		if min > bid {
+			bid = min
+		}
+		var started *api.Pod
+		started, err = k.bidUp(ctx, bid)
+		if err == nil || ctx.Err() != nil {
+			return started, err
+		}
+	}
+	if !onDemandFallback {
+		return nil, err
+	}
+	logf("resume pod %s as spot failed: %s; resuming on demand", k.podId, err)
+	return api.DefaultClient.StartOnDemandPod(ctx, k.podId)
+}
+
+// bidUp resumes a spot pod at bid, raising the bid by --bid-step after each
+// failure until --max-bid, with a growing pause between attempts.
+func (k *keeper) bidUp(ctx context.Context, bid float32) (*api.Pod, error) {
+	wait := rebidBackoff
+	var err error
+	for maxBid <= 0 || bid <= maxBid {
+		var started *api.Pod
+		started, err = api.DefaultClient.StartSpotPod(ctx, k.podId, bid)
+		if err == nil {
+			k.bidPerGpu = bid
+			return started, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if maxBid <= 0 || bid >= maxBid || bidStep <= 0 {
+			break
+		}
+		logf("resume pod %s at $%.3f / gpu failed: %s; bidding again in %s", k.podId, bid, err, wait)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > maxRebidBackoff {
+			wait = maxRebidBackoff
+		}
+		bid += bidStep
+		if bid > maxBid {
+			bid = maxBid
+		}
+	}
+	if err == nil {
+		err = fmt.Errorf("the minimum bid $%.3f / gpu is above --max-bid $%.3f", bid, maxBid)
+	}
+	return nil, err
+}
+
+func logf(format string, args ...interface{}) {
+	fmt.Printf("%s %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
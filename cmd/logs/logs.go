@@ -0,0 +1,86 @@
+package logs
+
+import (
+	"cli/api"
+	"cli/complete"
+	"cli/exit"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var follow bool
+var since string
+var system bool
+var tail int
+var timestamps bool
+
+var LogsCmd = &cobra.Command{
+	Use:               "logs [podId]",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: complete.PodId,
+	Short:             "print pod logs",
+	Long:              "print the container or system logs of a pod, optionally following new output",
+	Run: func(cmd *cobra.Command, args []string) {
+		input := &api.PodLogsInput{PodId: args[0], Type: api.LogTypeContainer, Tail: tail}
+		if system {
+			input.Type = api.LogTypeSystem
+		}
+		if since != "" {
+			s, err := parseSince(since)
+			exit.CheckErr(err)
+			input.Since = s
+		}
+
+		if !follow {
+			lines, err := api.DefaultClient.GetPodLogs(cmd.Context(), input)
+			exit.CheckErr(err)
+			for _, line := range lines {
+				printLine(line)
+			}
+			return
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+		out := make(chan *api.PodLogLine)
+		errc := make(chan error, 1)
+		go func() {
+			errc <- api.DefaultClient.StreamPodLogs(ctx, input, 2*time.Second, out)
+			close(out)
+		}()
+		for line := range out {
+			printLine(line)
+		}
+		exit.CheckErr(<-errc)
+	},
+}
+
+func init() {
+	LogsCmd.Flags().BoolVarP(&follow, "follow", "f", false, "keep printing new log lines until interrupted")
+	LogsCmd.Flags().StringVar(&since, "since", "", "only lines newer than a duration (e.g. 10m) or RFC3339 time")
+	LogsCmd.Flags().BoolVar(&system, "system", false, "print system logs (image pull, container start) instead of container logs")
+	LogsCmd.Flags().IntVar(&tail, "tail", 100, "number of most recent lines to print; 0 for all")
+	LogsCmd.Flags().BoolVarP(&timestamps, "timestamps", "t", false, "prefix lines with their timestamp")
+}
+
+func parseSince(s string) (string, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d).UTC().Format(time.RFC3339), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	return "", fmt.Errorf("invalid --since %q: use a duration like 10m or an RFC3339 time", s)
+}
+
+func printLine(line *api.PodLogLine) {
+	if timestamps {
+		fmt.Printf("%s %s\n", line.Timestamp, line.Message)
+	} else {
+		fmt.Println(line.Message)
+	}
+}
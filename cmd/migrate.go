@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"cli/cmd/pod"
+
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [command]",
+	Short: "migrate a resource",
+	Long:  "move a resource in runpod.io to another cloud",
+}
+
+func init() {
+	migrateCmd.AddCommand(pod.MigratePodCmd)
+}
@@ -0,0 +1,63 @@
+package pod
+
+import (
+	"cli/api"
+	"cli/fleet"
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	filter   fleet.Filter
+	parallel int
+)
+
+func bulkFlags(cmd *cobra.Command, verb string) {
+	cmd.Flags().BoolVar(&filter.All, "all", false, verb+" all pods")
+	cmd.Flags().StringVar(&filter.NamePrefix, "name-prefix", "", verb+" pods whose name starts with this prefix")
+	cmd.Flags().StringVarP(&filter.Selector, "selector", "l", "", verb+" pods matching a selector, e.g. job=sweep1,name=train-*")
+	cmd.Flags().IntVar(&parallel, "parallel", 10, "maximum number of pods to "+verb+" concurrently")
+}
+
+// bulkArgs accepts pod ids or filters, but not both, or a manifest alone.
+func bulkArgs(cmd *cobra.Command, args []string) error {
+	if groupFile != "" {
+		if len(args) > 0 || filter.Set() {
+			return fmt.Errorf("-f cannot be combined with pod ids or filters")
+		}
+		return nil
+	}
+	if len(args) > 0 && filter.Set() {
+		return fmt.Errorf("give either pod ids or --all, --name-prefix and --selector, not both")
+	}
+	if len(args) == 0 && !filter.Set() {
+		return fmt.Errorf("requires pod ids, --all, --name-prefix or --selector")
+	}
+	return nil
+}
+
+// bulkTargets returns the pods named in args, or the pods matching the
+// filters for which want is true.
+func bulkTargets(ctx context.Context, args []string, want func(p *api.Pod) bool) ([]*api.Pod, error) {
+	if len(args) > 0 {
+		pods := make([]*api.Pod, len(args))
+		for i, id := range args {
+			pods[i] = &api.Pod{Id: id}
+		}
+		return pods, nil
+	}
+	pods, err := api.DefaultClient.GetPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pods, err = filter.Select(pods, want)
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no pods match")
+	}
+	return pods, nil
+}
@@ -2,28 +2,59 @@ package pod
 
 import (
 	"cli/api"
+	"cli/complete"
+	"cli/dataset"
+	"cli/exit"
+	"cli/hfcache"
+	"cli/history"
+	"cli/manifest"
+	"cli/podenv"
+	"cli/secrets"
+	"cli/sshkey"
+	"cli/startup"
+	"cli/tracking"
+	"cli/watch"
+	"context"
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+var createBid float32
 var communityCloud bool
+var secretEnv []string
 var secureCloud bool
+var sshKeyFile string
 var containerDiskInGb int
+var datasets []string
 var deployCost float32
 var dockerArgs string
 var env []string
+var envFiles []string
+var file string
+var gitMetadata bool
 var gpuCount int
 var gpuTypeId string
+var hfCache string
 var imageName string
 var minMemoryInGb int
 var minVcpuCount int
 var name string
+var networkVolumeId string
+var params []string
 var ports []string
+var registryAuthId string
 var templateId string
+var track string
 var volumeInGb int
 var volumeMountPath string
+var wait bool
+var waitTimeout time.Duration
+
+const datasetTimeout = 30 * time.Minute
 
 var CreatePodCmd = &cobra.Command{
 	Use:   "pod",
@@ -31,7 +62,26 @@ var CreatePodCmd = &cobra.Command{
 	Short: "start a pod",
 	Long:  "start a pod from runpod.io",
 	Run: func(cmd *cobra.Command, args []string) {
+		if file != "" {
+			values, err := manifest.ParseParams(params)
+			exit.CheckErr(err)
+			docs, err := manifest.Load(file, values)
+			exit.CheckErr(err)
+			for _, doc := range docs {
+				p, err := doc.Pod()
+				exit.CheckErr(err)
+				input := p.CreatePodInput()
+				input.DeployCost = deployCost
+				createPod(cmd.Context(), input)
+			}
+			return
+		}
+		if gpuTypeId == "" || imageName == "" {
+			exit.CheckErr(fmt.Errorf(`required flag(s) "gpuType", "imageName" not set`))
+		}
+
 		input := &api.CreatePodInput{
+			BidPerGpu:         createBid,
 			ContainerDiskInGb: containerDiskInGb,
 			DeployCost:        deployCost,
 			DockerArgs:        dockerArgs,
@@ -41,6 +91,7 @@ var CreatePodCmd = &cobra.Command{
 			MinMemoryInGb:     minMemoryInGb,
 			MinVcpuCount:      minVcpuCount,
 			Name:              name,
+			NetworkVolumeId:   networkVolumeId,
 			TemplateId:        templateId,
 			VolumeInGb:        volumeInGb,
 			VolumeMountPath:   volumeMountPath,
@@ -48,49 +99,147 @@ var CreatePodCmd = &cobra.Command{
 		if len(ports) > 0 {
 			input.Ports = strings.Join(ports, ",")
 		}
-		input.Env = make([]*api.PodEnv, len(env))
-		for i, v := range env {
-			e := strings.Split(v, "=")
-			if len(e) != 2 {
-				cobra.CheckErr(fmt.Errorf("wrong env value: %s", e))
-			}
-			input.Env[i] = &api.PodEnv{Key: e[0], Value: e[1]}
+		var err error
+		input.Env, err = podenv.Build(envFiles, env, secretEnv)
+		exit.CheckErr(err)
+		if sshKeyFile != "" {
+			key, err := sshkey.ReadFile(sshKeyFile)
+			exit.CheckErr(err)
+			// runpod images authorize PUBLIC_KEY; SSH_PUBLIC_KEY is for custom images
+			input.Env = append(input.Env,
+				&api.PodEnv{Key: "SSH_PUBLIC_KEY", Value: key.Line},
+				&api.PodEnv{Key: "PUBLIC_KEY", Value: key.Line},
+			)
 		}
 		if secureCloud {
 			input.CloudType = "SECURE"
 		} else {
 			input.CloudType = "COMMUNITY"
 		}
-		pod, err := api.CreatePod(input)
-		cobra.CheckErr(err)
+		createPod(cmd.Context(), input)
+	},
+}
 
-		if pod["desiredStatus"] == "RUNNING" {
-			fmt.Printf(`pod "%s" created for $%.3f / hr`, pod["id"], pod["costPerHr"])
+func createPod(ctx context.Context, input *api.CreatePodInput) {
+	var git *history.GitInfo
+	if gitMetadata {
+		var err error
+		git, err = history.Git()
+		exit.CheckErr(err)
+		input.Env = append(input.Env, git.Env()...)
+	}
+	var trackingEnv []*api.PodEnv
+	var trackingUrl string
+	if track != "" {
+		var err error
+		trackingEnv, trackingUrl, err = tracking.Env(track, input.GpuTypeId)
+		exit.CheckErr(err)
+	}
+	exit.CheckErr(hfcache.Apply(input, hfCache))
+	fetch, err := dataset.Apply(input, datasets)
+	exit.CheckErr(err)
+	exit.CheckErr(secrets.Check(input.Env))
+	input.Env = append(input.Env, trackingEnv...)
+	if registryAuthId != "" {
+		auth, err := api.DefaultClient.GetRegistryAuth(ctx, registryAuthId)
+		exit.CheckErr(err)
+		input.ContainerRegistryAuthId = auth.Id
+	}
+	if input.NetworkVolumeId != "" && input.DataCenterId == "" {
+		// a network volume can only be mounted by pods in its datacenter
+		volume, err := api.DefaultClient.GetNetworkVolume(ctx, input.NetworkVolumeId)
+		exit.CheckErr(err)
+		input.NetworkVolumeId = volume.Id
+		input.DataCenterId = volume.DataCenterId
+	}
+	if dryRun {
+		exit.CheckErr(estimatePod(ctx, input))
+		return
+	}
+	requested := time.Now()
+	pod, err := api.DefaultClient.CreatePod(ctx, input)
+	exit.CheckErr(err)
+
+	if pod.DesiredStatus == "RUNNING" {
+		fmt.Printf(`pod "%s" created for $%.3f / hr`, pod.Id, pod.CostPerHr)
+		fmt.Println()
+		if trackingUrl != "" {
+			fmt.Printf("tracking runs at %s\n", trackingUrl)
+		}
+		podId := pod.Id
+		err = history.Append(&history.Entry{
+			Action:    "create pod",
+			PodId:     podId,
+			Name:      input.Name,
+			ImageName: input.ImageName,
+			GpuType:   input.GpuTypeId,
+			Git:       git,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: could not record history: %s\n", err)
+		}
+		if wait {
+			waitCtx, cancel := context.WithTimeout(ctx, waitTimeout)
+			defer cancel()
+			running, err := watch.Running(waitCtx, podId)
+			exit.CheckErr(err)
+			timing := startup.Measure(ctx, running, requested, time.Now())
+			fmt.Printf(`pod "%s" is running after %s`, podId, startup.Describe(timing))
 			fmt.Println()
-		} else {
-			cobra.CheckErr(fmt.Errorf(`pod "%s" start failed; status is %s`, args[0], pod["desiredStatus"]))
+			err = history.Append(&history.Entry{
+				Action:    startup.Action,
+				PodId:     podId,
+				Name:      input.Name,
+				ImageName: running.ImageName,
+				GpuType:   input.GpuTypeId,
+				Startup:   timing,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: could not record history: %s\n", err)
+			}
 		}
-	},
+		if len(fetch) > 0 {
+			fetchCtx, cancel := context.WithTimeout(ctx, datasetTimeout)
+			defer cancel()
+			exit.CheckErr(dataset.Fetch(fetchCtx, podId, fetch, os.Stdout))
+		}
+	} else {
+		exit.CheckErr(fmt.Errorf(`pod "%s" start failed; status is %s`, pod.Id, pod.DesiredStatus))
+	}
 }
 
 func init() {
+	CreatePodCmd.Flags().Float32Var(&createBid, "bid", 0, "create a spot pod bidding this much $/hr per gpu; it is stopped when outbid")
 	CreatePodCmd.Flags().BoolVar(&communityCloud, "communityCloud", false, "create in community cloud")
 	CreatePodCmd.Flags().BoolVar(&secureCloud, "secureCloud", false, "create in secure cloud")
 	CreatePodCmd.Flags().IntVar(&containerDiskInGb, "containerDiskSize", 20, "container disk size in GB")
+	CreatePodCmd.Flags().StringSliceVar(&datasets, "dataset", nil, "registered dataset to mount (volume) or download into the pod (url); see runpodctl dataset")
 	CreatePodCmd.Flags().Float32Var(&deployCost, "cost", 0, "$/hr price ceiling, if not defined, pod will be created with lowest price available")
 	CreatePodCmd.Flags().StringVar(&dockerArgs, "args", "", "container arguments")
-	CreatePodCmd.Flags().StringSliceVar(&env, "env", nil, "container arguments")
+	CreatePodCmd.Flags().BoolVar(&dryRun, "dry-run", false, "check the pod against the gpu types on offer and print its hourly and daily cost without creating it; exits non-zero when it could not be created")
+	CreatePodCmd.Flags().StringVarP(&dryRunOutput, "output", "o", "", "with --dry-run, output format: json or yaml")
+	CreatePodCmd.Flags().StringArrayVar(&env, "env", nil, "env var as KEY=VALUE; repeat for more, values may contain commas")
+	CreatePodCmd.Flags().StringArrayVar(&envFiles, "env-file", nil, "dotenv file with KEY=VALUE lines; --env overrides its values")
+	CreatePodCmd.Flags().StringArrayVar(&secretEnv, "secret-env", nil, "env var set from a runpod secret as KEY=secretName; the value never leaves runpod")
+	CreatePodCmd.Flags().BoolVar(&gitMetadata, "git-metadata", false, "inject RUNPOD_GIT_COMMIT, RUNPOD_GIT_BRANCH and RUNPOD_GIT_DIRTY from the current git repository")
+	CreatePodCmd.Flags().StringVarP(&file, "file", "f", "", "create the pods described in a yaml manifest ('-' for stdin); spec flags are ignored")
+	CreatePodCmd.Flags().StringArrayVar(&params, "param", nil, "with -f, value of a manifest param as NAME=value; repeat for more")
 	CreatePodCmd.Flags().IntVar(&gpuCount, "gpuCount", 1, "number of GPUs for the pod")
 	CreatePodCmd.Flags().StringVar(&gpuTypeId, "gpuType", "", "gpu type id, e.g. 'NVIDIA GeForce RTX 3090'")
+	CreatePodCmd.RegisterFlagCompletionFunc("gpuType", complete.GpuTypes) //nolint
+	CreatePodCmd.Flags().StringVar(&hfCache, "hf-cache", "", "network volume id to mount as a shared Hugging Face cache; sets HF_HOME to <volumePath>/huggingface")
 	CreatePodCmd.Flags().StringVar(&imageName, "imageName", "", "container image name")
 	CreatePodCmd.Flags().IntVar(&minMemoryInGb, "mem", 20, "minimum system memory needed")
 	CreatePodCmd.Flags().IntVar(&minVcpuCount, "vcpu", 1, "minimum vCPUs needed")
 	CreatePodCmd.Flags().StringVar(&name, "name", "", "any pod name for easy reference")
+	CreatePodCmd.Flags().StringVar(&networkVolumeId, "network-volume-id", "", "network volume to mount at --volumePath; the pod is created in the volume's datacenter")
 	CreatePodCmd.Flags().StringSliceVar(&ports, "ports", nil, "ports to expose; max only 1 http and 1 tcp allowed; e.g. '8888/http'")
+	CreatePodCmd.Flags().StringVar(&registryAuthId, "registry-auth-id", "", "id or name of saved registry credentials to pull a private image; see runpodctl create registry-auth")
+	CreatePodCmd.Flags().StringVar(&sshKeyFile, "ssh-key", "", "public key file to authorize in the pod, e.g. ~/.ssh/id_ed25519.pub")
 	CreatePodCmd.Flags().StringVar(&templateId, "templateId", "", "templateId to use with the pod")
+	CreatePodCmd.Flags().StringVar(&track, "track", "", "experiment tracker to wire into the pod from tracking.<tracker> in config: wandb or mlflow")
 	CreatePodCmd.Flags().IntVar(&volumeInGb, "volumeSize", 1, "persistent volume disk size in GB")
 	CreatePodCmd.Flags().StringVar(&volumeMountPath, "volumePath", "/runpod", "container volume path")
-
-	CreatePodCmd.MarkFlagRequired("gpuType")   //nolint
-	CreatePodCmd.MarkFlagRequired("imageName") //nolint
+	CreatePodCmd.Flags().BoolVar(&wait, "wait", false, "wait until the pod is running; exits non-zero if it fails to start or --timeout passes")
+	CreatePodCmd.Flags().DurationVar(&waitTimeout, "timeout", 10*time.Minute, "how long --wait waits for the pod to run")
 }
@@ -0,0 +1,69 @@
+package pod
+
+import (
+	"bytes"
+	"cli/api"
+	"cli/complete"
+	"cli/doctor"
+	"cli/exit"
+	"cli/format"
+	"cli/remote"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var doctorTimeout time.Duration
+
+var DoctorPodCmd = &cobra.Command{
+	Use:               "pod [podId]",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: complete.PodId,
+	Short:             "diagnose a pod",
+	Long:              "collect gpu, cuda, disk, network and oom diagnostics inside a pod over ssh and summarize likely problems; exits non-zero when a check fails",
+	Run: func(cmd *cobra.Command, args []string) {
+		pod, err := api.DefaultClient.GetPod(cmd.Context(), args[0])
+		exit.CheckErr(err)
+		if pod.DesiredStatus != "RUNNING" {
+			exit.CheckErr(fmt.Errorf(`pod "%s" is not running; status is %s`, pod.Id, pod.DesiredStatus))
+		}
+		target, err := remote.Resolve(pod)
+		exit.CheckErr(err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+		defer cancel()
+		var stdout, stderr bytes.Buffer
+		if err := target.Run(ctx, doctor.Script, nil, &stdout, &stderr); err != nil && stdout.Len() == 0 {
+			exit.CheckErr(fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String())))
+		}
+
+		checks := doctor.Analyze(stdout.String())
+		failed := 0
+		data := make([][]string, len(checks))
+		for i, c := range checks {
+			if c.Status == doctor.Fail {
+				failed++
+			}
+			data[i] = []string{c.Name, c.Status, c.Detail}
+		}
+		header := []string{"Check", "Status", "Detail"}
+		format.Highlight(header, data)
+		tb := tablewriter.NewWriter(os.Stdout)
+		tb.SetHeader(header)
+		format.TableDefaults(tb)
+		tb.AppendBulk(data)
+		tb.Render()
+		if failed > 0 {
+			exit.CheckErr(fmt.Errorf("%d of %d checks failed", failed, len(checks)))
+		}
+	},
+}
+
+func init() {
+	DoctorPodCmd.Flags().DurationVar(&doctorTimeout, "timeout", 2*time.Minute, "time allowed for the diagnostics to run")
+}
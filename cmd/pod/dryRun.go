@@ -0,0 +1,148 @@
+package pod
+
+import (
+	"cli/api"
+	"cli/format"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+var dryRun bool
+var dryRunOutput string
+
+// podEstimate is the result of create pod --dry-run. Env values are left out
+// so the report can be kept as a ci artifact.
+type podEstimate struct {
+	Name      string         `json:"name"`
+	ImageName string         `json:"imageName"`
+	CloudType string         `json:"cloudType"`
+	GpuCount  int            `json:"gpuCount"`
+	Valid     bool           `json:"valid"`
+	Problems  []string       `json:"problems,omitempty"`
+	GpuTypes  []*gpuEstimate `json:"gpuTypes"`
+}
+
+// gpuEstimate is the cost of the pod on one of its allowed gpu types, for
+// all of its gpus.
+type gpuEstimate struct {
+	GpuTypeId      string  `json:"gpuTypeId"`
+	Available      bool    `json:"available"`
+	OnDemandPerHr  float64 `json:"onDemandPerHr"`
+	OnDemandPerDay float64 `json:"onDemandPerDay"`
+	SpotBidPerHr   float64 `json:"spotBidPerHr"`
+	SpotBidPerDay  float64 `json:"spotBidPerDay"`
+}
+
+// estimatePod checks input against the gpu types on offer and prices it,
+// without creating anything. It fails when the pod could not be created.
+func estimatePod(ctx context.Context, input *api.CreatePodInput) error {
+	cloud := &api.GetCloudInput{
+		DataCenterId:  input.DataCenterId,
+		GpuCount:      input.GpuCount,
+		MinMemoryInGb: input.MinMemoryInGb,
+		MinVcpuCount:  input.MinVcpuCount,
+	}
+	secure := input.CloudType == "SECURE"
+	if input.CloudType != "ALL" {
+		cloud.SecureCloud = &secure
+	}
+	gpuTypes, err := api.DefaultClient.GetGpuTypes(ctx, cloud)
+	if err != nil {
+		return err
+	}
+	byId := make(map[string]*api.GpuType, len(gpuTypes))
+	for _, g := range gpuTypes {
+		byId[g.Id] = g
+	}
+
+	e := &podEstimate{
+		Name:      input.Name,
+		ImageName: input.ImageName,
+		CloudType: input.CloudType,
+		GpuCount:  input.GpuCount,
+		GpuTypes:  []*gpuEstimate{},
+	}
+	if input.ImageName == "" && input.TemplateId == "" {
+		e.Problems = append(e.Problems, "no image name or template")
+	}
+	if input.GpuCount < 1 {
+		e.Problems = append(e.Problems, "gpu count must be at least 1")
+	}
+	affordable := false
+	for _, id := range strings.Split(input.GpuTypeId, ",") {
+		id = strings.TrimSpace(id)
+		g, ok := byId[id]
+		if !ok {
+			e.Problems = append(e.Problems, fmt.Sprintf("unknown gpu type %q; see runpodctl get gpu-types", id))
+			continue
+		}
+		if secure && !g.SecureCloud || input.CloudType == "COMMUNITY" && !g.CommunityCloud {
+			e.Problems = append(e.Problems, fmt.Sprintf("%s is not offered in %s cloud", id, strings.ToLower(input.CloudType)))
+			continue
+		}
+		ge := &gpuEstimate{GpuTypeId: id}
+		if onDemand, spot, ok := g.Price(input.GpuCount); ok {
+			ge.Available = true
+			ge.OnDemandPerHr = api.Cents(onDemand)
+			ge.OnDemandPerDay = api.Cents(onDemand * 24)
+			ge.SpotBidPerHr = api.Cents(spot)
+			ge.SpotBidPerDay = api.Cents(spot * 24)
+		}
+		e.GpuTypes = append(e.GpuTypes, ge)
+		if ge.Available && (input.DeployCost <= 0 || ge.OnDemandPerHr <= float64(input.DeployCost)) {
+			affordable = true
+		}
+	}
+	if len(e.GpuTypes) > 0 && !affordable {
+		if input.DeployCost > 0 {
+			e.Problems = append(e.Problems, fmt.Sprintf("no %d x %s available under --cost $%.3f / hr", input.GpuCount, input.GpuTypeId, input.DeployCost))
+		} else {
+			e.Problems = append(e.Problems, fmt.Sprintf("no %d x %s available right now", input.GpuCount, input.GpuTypeId))
+		}
+	}
+	e.Valid = len(e.Problems) == 0
+
+	printed, err := format.Print(os.Stdout, dryRunOutput, "", e)
+	if err != nil {
+		return err
+	}
+	if !printed {
+		renderEstimate(e)
+	}
+	if !e.Valid {
+		return fmt.Errorf("the pod could not be created: %s", strings.Join(e.Problems, "; "))
+	}
+	return nil
+}
+
+func renderEstimate(e *podEstimate) {
+	data := make([][]string, len(e.GpuTypes))
+	for i, g := range e.GpuTypes {
+		row := []string{g.GpuTypeId, "no", "-", "-", "-", "-"}
+		if g.Available {
+			row = []string{
+				g.GpuTypeId,
+				"yes",
+				fmt.Sprintf("%.3f", g.OnDemandPerHr),
+				fmt.Sprintf("%.2f", g.OnDemandPerDay),
+				fmt.Sprintf("%.3f", g.SpotBidPerHr),
+				fmt.Sprintf("%.2f", g.SpotBidPerDay),
+			}
+		}
+		data[i] = row
+	}
+	header := []string{"GPU Type", "Available", "On-demand $/hr", "On-demand $/day", "Spot bid $/hr", "Spot bid $/day"}
+	format.Highlight(header, data)
+	tb := tablewriter.NewWriter(os.Stdout)
+	tb.SetHeader(header)
+	tb.AppendBulk(data)
+	format.TableDefaults(tb)
+	tb.Render()
+	if e.Valid {
+		fmt.Printf("dry run: pod %q is valid; nothing was created\n", e.Name)
+	}
+}
@@ -2,59 +2,471 @@ package pod
 
 import (
 	"cli/api"
+	"cli/complete"
+	"cli/exit"
 	"cli/format"
+	"cli/manifest"
+	"cli/watch"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"strings"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
 
 var AllFields bool
+var columns []string
+var fields []string
+var podFilter api.PodsInput
+var output string
+var outputTemplate string
+var quiet bool
+var watchInterval time.Duration
+var watchPods bool
 
 var GetPodCmd = &cobra.Command{
-	Use:   "pod [podId]",
-	Args:  cobra.MaximumNArgs(1),
-	Short: "get all pods",
-	Long:  "get all pods or specify pod id",
+	Use:               "pod [podId]",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: complete.PodId,
+	Short:             "get all pods",
+	Long:              "get all pods or specify pod id",
 	Run: func(cmd *cobra.Command, args []string) {
-		pods, err := api.GetPods()
-		cobra.CheckErr(err)
+		var err error
+		columns, err = format.ColumnSet(output)
+		exit.CheckErr(err)
+		if watchPods && (outputTemplate != "" || output != "" && output != "wide" && columns == nil) {
+			exit.CheckErr(fmt.Errorf("--watch only works with table output"))
+		}
+		if quiet && (len(fields) > 0 || output != "" || outputTemplate != "") {
+			exit.CheckErr(fmt.Errorf("-q prints only pod ids; it cannot be used with --fields, -o or --template"))
+		}
+		if (quiet || len(fields) > 0) && (watchPods || showMetrics || columns != nil || output == "wide" || output == "command" || output == "manifest") {
+			exit.CheckErr(fmt.Errorf("--fields and -q only work with table, json, yaml, jsonpath or template output"))
+		}
+		pods, err := getPods(cmd.Context(), args)
+		exit.CheckErr(err)
 
-		data := make([][]string, len(pods))
-		for i, p := range pods {
-			if len(args) == 1 && p.Id != strings.ToLower(args[0]) {
-				continue
+		if quiet {
+			for _, p := range pods {
+				fmt.Println(p.Id)
+			}
+			return
+		}
+		if len(fields) > 0 {
+			if output == "" && outputTemplate == "" {
+				exit.CheckErr(format.PrintColumns(os.Stdout, append([]string{"id"}, fields...), pods, nil))
+				return
 			}
-			row := []string{p.Id, p.Name, fmt.Sprintf("%d %s", p.GpuCount, p.Machine.GpuDisplayName), p.ImageName, p.DesiredStatus}
-			if AllFields {
-				row = append(
-					row,
-					p.PodType,
-					fmt.Sprintf("%d", p.VcpuCount),
-					fmt.Sprintf("%d", p.MemoryInGb),
-					fmt.Sprintf("%d", p.ContainerDiskInGb),
-					fmt.Sprintf("%d", p.VolumeInGb),
-					fmt.Sprintf("%.3f", p.CostPerHr),
-				)
+			var v interface{} = pods
+			if len(args) == 1 {
+				v = pods[0]
 			}
-			data[i] = row
+			v, err = selectFields(v, fields)
+			exit.CheckErr(err)
+			_, err = format.Print(os.Stdout, output, outputTemplate, v)
+			exit.CheckErr(err)
+			return
 		}
 
-		header := []string{"ID", "Name", "GPU", "Image Name", "Status"}
-		if AllFields {
-			header = append(header, "Pod Type", "vCPU", "Mem", "Container Disk", "Volume Disk", "$/hr")
+		switch output {
+		case "command":
+			for _, p := range pods {
+				fmt.Println(manifest.FromPod(p).CreateCommand())
+			}
+			return
+		case "manifest":
+			manifests := make([]interface{}, len(pods))
+			for i, p := range pods {
+				manifests[i] = manifest.FromPod(p)
+			}
+			out, err := manifest.Marshal(manifests...)
+			exit.CheckErr(err)
+			fmt.Print(string(out))
+			return
+		case "wide":
+			AllFields = true
+		}
+		setStorageCost(cmd.Context(), pods)
+		if wantsMachineDetails() {
+			setMachineDetails(cmd.Context(), pods)
+		}
+		var v interface{} = pods
+		if len(args) == 1 {
+			v = pods[0]
+		}
+		printed, err := format.Print(os.Stdout, output, outputTemplate, v)
+		exit.CheckErr(err)
+		if printed {
+			return
 		}
 
-		tb := tablewriter.NewWriter(os.Stdout)
-		tb.SetHeader(header)
-		tb.AppendBulk(data)
-		format.TableDefaults(tb)
-		tb.Render()
+		if watchPods {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+			var last string
+			err = watch.Pods(ctx, watchInterval, func(all []*api.Pod, err error) error {
+				if err = format.Partial(err); err == nil {
+					pods, err = filterPods(all, args)
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+					return nil
+				}
+				setStorageCost(ctx, pods)
+				if wantsMachineDetails() {
+					setMachineDetails(ctx, pods)
+				}
+				var sb strings.Builder
+				if err := renderPods(&sb, pods); err != nil {
+					return err
+				}
+				if table := sb.String(); table != last {
+					if last != "" {
+						fmt.Println()
+					}
+					fmt.Print(table)
+					last = table
+				}
+				return nil
+			})
+			if err != context.Canceled {
+				exit.CheckErr(err)
+			}
+			return
+		}
+		exit.CheckErr(renderPods(os.Stdout, pods))
 	},
 }
 
+// tableFields are the pod fields the default table shows.
+var tableFields = []string{"name", "gpuCount", "machine", "imageName", "desiredStatus", "containerDiskInGb", "volumeInGb"}
+
+// setMachineDetails sets the cpu model and gpu interconnect of the pods'
+// machines, which only wide listings show. When they cannot be fetched they
+// are shown as unknown and a warning is printed.
+func setMachineDetails(ctx context.Context, pods []*api.Pod) {
+	machines, err := api.DefaultClient.GetMachineDetails(ctx)
+	if err := format.Partial(err); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: cpu model and gpu interconnect unknown: %s\n", err)
+		return
+	}
+	for _, p := range pods {
+		if m := machines[p.Id]; m != nil && p.Machine != nil {
+			p.Machine.CpuModel = m.CpuModel
+			p.Machine.GpuInterconnect = m.GpuInterconnect
+		}
+	}
+}
+
+// wantsMachineDetails reports whether the output shows the cpu model or gpu
+// interconnect.
+func wantsMachineDetails() bool {
+	if AllFields {
+		return true
+	}
+	for _, c := range columns {
+		if c == "cpu" || c == "interconnect" {
+			return true
+		}
+	}
+	return false
+}
+
+// storageRates are fetched once per command; nil if they could not be.
+var storageRates *api.StorageRates
+var storageRatesFetched bool
+
+// setStorageCost sets what the disks of the pods cost per hour. Without the
+// storage rates the cost stays unknown and a warning is printed.
+func setStorageCost(ctx context.Context, pods []*api.Pod) {
+	if !storageRatesFetched {
+		storageRatesFetched = true
+		rates, err := api.DefaultClient.GetStorageRates(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: storage cost unknown: %s\n", err)
+		}
+		storageRates = rates
+	}
+	if storageRates == nil {
+		return
+	}
+	for _, p := range pods {
+		cost := storageRates.PodPerHr(p)
+		p.StorageCostPerHr = &cost
+	}
+}
+
+// getPods returns the pods matching the filter flags, or the one pod named
+// in args.
+func getPods(ctx context.Context, args []string) ([]*api.Pod, error) {
+	selected := fields
+	if quiet {
+		selected = []string{"id"}
+	}
+	if len(args) == 1 && len(selected) > 0 {
+		input := podFilter
+		input.Fields = selected
+		pod, err := api.DefaultClient.GetPodFields(ctx, args[0], input.FilterFields())
+		if err != nil {
+			return nil, err
+		}
+		return filterPods([]*api.Pod{pod}, args)
+	}
+	if len(args) == 1 {
+		pods, err := api.DefaultClient.GetPods(ctx)
+		if err := format.Partial(err); err != nil {
+			return nil, err
+		}
+		return filterPods(pods, args)
+	}
+	input := podFilter
+	input.DesiredStatus = strings.ToUpper(input.DesiredStatus)
+	if len(selected) > 0 {
+		input.Fields = selected
+	} else if output == "" && outputTemplate == "" && !AllFields && !showMetrics {
+		input.Fields = tableFields
+	}
+	pods, err := api.DefaultClient.ListPods(ctx, &input)
+	if err := format.Partial(err); err != nil {
+		return nil, err
+	}
+	if pods == nil {
+		pods = []*api.Pod{}
+	}
+	return pods, nil
+}
+
+// filterPods returns the pods matching the filter flags, or the one pod
+// named in args.
+func filterPods(pods []*api.Pod, args []string) ([]*api.Pod, error) {
+	var found []*api.Pod
+	for _, p := range pods {
+		if podFilter.Match(p) && (len(args) == 0 || p.Id == strings.ToLower(args[0])) {
+			found = append(found, p)
+		}
+	}
+	if len(args) == 0 {
+		return found, nil
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf(`pod "%s" not found`, args[0])
+	}
+	return found, nil
+}
+
+// selectFields keeps only the id and the named fields of the pods in v, as
+// they encode to json, so fields that were not fetched are not printed as
+// zero values.
+func selectFields(v interface{}, names []string) (interface{}, error) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(out, &generic); err != nil {
+		return nil, err
+	}
+	project := func(pod interface{}) interface{} {
+		src, ok := pod.(map[string]interface{})
+		if !ok {
+			return pod
+		}
+		dst := map[string]interface{}{"id": src["id"]}
+		for _, n := range names {
+			pickField(src, dst, strings.Split(n, "."))
+		}
+		return dst
+	}
+	if list, ok := generic.([]interface{}); ok {
+		for i, pod := range list {
+			list[i] = project(pod)
+		}
+		return list, nil
+	}
+	return project(generic), nil
+}
+
+// pickField copies the field at path from src to dst, through nested
+// objects and lists of objects.
+func pickField(src map[string]interface{}, dst map[string]interface{}, path []string) {
+	v, ok := src[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		dst[path[0]] = v
+		return
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		sub, ok := dst[path[0]].(map[string]interface{})
+		if !ok {
+			sub = make(map[string]interface{})
+			dst[path[0]] = sub
+		}
+		pickField(v, sub, path[1:])
+	case []interface{}:
+		list, ok := dst[path[0]].([]interface{})
+		if !ok || len(list) != len(v) {
+			list = make([]interface{}, len(v))
+			dst[path[0]] = list
+		}
+		for i, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				sub, ok := list[i].(map[string]interface{})
+				if !ok {
+					sub = make(map[string]interface{})
+					list[i] = sub
+				}
+				pickField(m, sub, path[1:])
+			}
+		}
+	default:
+		dst[path[0]] = v
+	}
+}
+
+// podColumns are the -o columns=<set> columns that are not plain json fields.
+var podColumns = map[string]format.Column{
+	"gpu": func(row interface{}) string {
+		p := row.(*api.Pod)
+		if p.Machine == nil {
+			return fmt.Sprintf("%d", p.GpuCount)
+		}
+		return fmt.Sprintf("%d %s", p.GpuCount, p.Machine.GpuDisplayName)
+	},
+	"costPerHr": func(row interface{}) string {
+		return fmt.Sprintf("%.3f", row.(*api.Pod).CostPerHr)
+	},
+	"interconnect": func(row interface{}) string {
+		return interconnect(row.(*api.Pod))
+	},
+	"cpu": func(row interface{}) string {
+		return cpuModel(row.(*api.Pod))
+	},
+	"storageCostPerHr": func(row interface{}) string {
+		return storageCost(row.(*api.Pod))
+	},
+	"status": func(row interface{}) string {
+		return row.(*api.Pod).DesiredStatus
+	},
+	"uptime": func(row interface{}) string {
+		return format.Uptime(row.(*api.Pod).StartedAt)
+	},
+	"gpuUtil": func(row interface{}) string {
+		util, _, ok := row.(*api.Pod).Runtime.GpuUtil()
+		return percent(util, ok)
+	},
+	"gpuMemUtil": func(row interface{}) string {
+		_, mem, ok := row.(*api.Pod).Runtime.GpuUtil()
+		return percent(mem, ok)
+	},
+	"lastStatusChange": func(row interface{}) string {
+		return format.Ago(row.(*api.Pod).StatusChangedAt)
+	},
+}
+
+func renderPods(w io.Writer, pods []*api.Pod) error {
+	if columns != nil {
+		return format.PrintColumns(w, columns, pods, podColumns)
+	}
+	if showMetrics {
+		renderMetrics(w, pods, idleThreshold)
+		return nil
+	}
+	data := make([][]string, len(pods))
+	for i, p := range pods {
+		row := []string{p.Id, p.Name, fmt.Sprintf("%d %s", p.GpuCount, p.Machine.GpuDisplayName), p.ImageName, p.DesiredStatus, storageCost(p)}
+		if AllFields {
+			row = append(
+				row,
+				p.PodType,
+				fmt.Sprintf("%d", p.VcpuCount),
+				fmt.Sprintf("%d", p.MemoryInGb),
+				fmt.Sprintf("%d", p.ContainerDiskInGb),
+				fmt.Sprintf("%d", p.VolumeInGb),
+				fmt.Sprintf("%.3f", p.CostPerHr),
+				format.Uptime(p.StartedAt),
+				format.Ago(p.StatusChangedAt),
+				interconnect(p),
+				cpuModel(p),
+			)
+		}
+		data[i] = row
+	}
+
+	header := []string{"ID", "Name", "GPU", "Image Name", "Status", "Storage $/hr"}
+	if AllFields {
+		header = append(header, "Pod Type", "vCPU", "Mem", "Container Disk", "Volume Disk", "$/hr", "Uptime", "Status Changed", "Interconnect", "CPU")
+	}
+
+	format.Highlight(header, data)
+	tb := tablewriter.NewWriter(w)
+	tb.SetHeader(header)
+	tb.AppendBulk(data)
+	format.TableDefaults(tb)
+	tb.Render()
+
+	// stopped is widely read as free, so say what stopped pods still cost
+	stopped, cost := 0, 0.0
+	for _, p := range pods {
+		if p.DesiredStatus != "RUNNING" && p.StorageCostPerHr != nil && *p.StorageCostPerHr > 0 {
+			stopped++
+			cost += *p.StorageCostPerHr
+		}
+	}
+	switch {
+	case stopped == 1:
+		fmt.Fprintf(w, "1 stopped pod still pays $%.3f/hr ($%.2f/month) for its disks; remove it with runpodctl remove pod\n", cost, cost*api.HoursPerMonth)
+	case stopped > 1:
+		fmt.Fprintf(w, "%d stopped pods still pay $%.3f/hr ($%.2f/month) for their disks; remove them with runpodctl remove pod\n", stopped, cost, cost*api.HoursPerMonth)
+	}
+	return nil
+}
+
+// interconnect is how a multi-gpu pod's gpus are linked, which decides how
+// fast multi-gpu training can be; it does not matter for a single gpu.
+func interconnect(p *api.Pod) string {
+	if p.GpuCount < 2 || p.Machine == nil || p.Machine.GpuInterconnect == "" {
+		return "-"
+	}
+	return p.Machine.GpuInterconnect
+}
+
+func cpuModel(p *api.Pod) string {
+	if p.Machine == nil || p.Machine.CpuModel == "" {
+		return "-"
+	}
+	return p.Machine.CpuModel
+}
+
+// storageCost formats what the pod's disks cost per hour, or - if unknown.
+func storageCost(p *api.Pod) string {
+	if p.StorageCostPerHr == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.3f", *p.StorageCostPerHr)
+}
+
 func init() {
 	GetPodCmd.Flags().BoolVarP(&AllFields, "allfields", "a", false, "include all fields in output")
+	GetPodCmd.Flags().StringVarP(&output, "output", "o", "", "output format: wide, columns=<set>, json, yaml, jsonpath=<expression>, template=<go template>, command (reproducible create command) or manifest (yaml)")
+	GetPodCmd.Flags().BoolVar(&showMetrics, "metrics", false, "show gpu, cpu and memory utilization")
+	GetPodCmd.Flags().IntVar(&idleThreshold, "idle-threshold", 5, "with --metrics, gpu utilization percent below which a pod is idle")
+	GetPodCmd.Flags().BoolVarP(&watchPods, "watch", "w", false, "refresh the table whenever pod status changes, until interrupted")
+	GetPodCmd.Flags().DurationVar(&watchInterval, "interval", watch.PollInterval, "polling interval for --watch")
+	GetPodCmd.Flags().BoolVar(&format.Timestamps, "timestamps", false, "show absolute RFC3339 times instead of relative durations")
+	GetPodCmd.Flags().StringVar(&podFilter.Name, "name", "", "only pods whose name contains this")
+	GetPodCmd.Flags().StringVar(&podFilter.DesiredStatus, "status", "", "only pods with this status, e.g. RUNNING or EXITED")
+	GetPodCmd.Flags().StringVar(&podFilter.GpuTypeId, "gpuType", "", "only pods on this gpu type, e.g. 'NVIDIA GeForce RTX 3090'")
+	GetPodCmd.RegisterFlagCompletionFunc("gpuType", complete.GpuTypes) //nolint
+	GetPodCmd.Flags().StringSliceVar(&fields, "fields", nil, "fetch and print only these fields besides the id, as named in -o json, e.g. name,desiredStatus,machine.gpuTypeId")
+	GetPodCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "print only pod ids, fetching nothing else")
+	GetPodCmd.Flags().StringVar(&outputTemplate, "template", "", "go template for the output; fields are named as in -o json, e.g. '{{.name}}'")
 }
@@ -0,0 +1,122 @@
+package pod
+
+import (
+	"cli/api"
+	"cli/fleet"
+	"cli/manifest"
+	"cli/watch"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var groupFile string
+var groupParams []string
+var readyTimeout time.Duration
+
+func groupFlags(cmd *cobra.Command, verb string) {
+	cmd.Flags().StringVarP(&groupFile, "file", "f", "", verb+" the pods of a yaml or json manifest in dependsOn order")
+	cmd.Flags().StringArrayVar(&groupParams, "param", nil, "with -f, value of a manifest param as NAME=value; repeat for more")
+}
+
+// groupLevels matches the pods of the manifest to the live pods by name,
+// grouped into dependsOn levels: every pod comes after the pods it depends on.
+func groupLevels(ctx context.Context) ([][]*api.Pod, error) {
+	values, err := manifest.ParseParams(groupParams)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := manifest.Load(groupFile, values)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := manifest.Pods(docs)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := manifest.Levels(pods)
+	if err != nil {
+		return nil, err
+	}
+	live, err := api.DefaultClient.GetPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*api.Pod, len(live))
+	for _, p := range live {
+		if _, ok := byName[p.Name]; ok {
+			return nil, fmt.Errorf(`more than one pod is named "%s"; manifests match pods by name`, p.Name)
+		}
+		byName[p.Name] = p
+	}
+	out := make([][]*api.Pod, len(levels))
+	for i, level := range levels {
+		for _, p := range level {
+			lp, ok := byName[p.Name]
+			if !ok {
+				return nil, fmt.Errorf(`pod "%s" of the manifest does not exist; create it with runpodctl apply`, p.Name)
+			}
+			out[i] = append(out[i], lp)
+		}
+	}
+	return out, nil
+}
+
+// startGroup starts the manifest's pods level by level, and waits for each
+// level to run before starting the next.
+func startGroup(ctx context.Context, want func(p *api.Pod) bool, start func(p *api.Pod) (string, error)) error {
+	levels, err := groupLevels(ctx)
+	if err != nil {
+		return err
+	}
+	for i, level := range levels {
+		if err := bulkLevel(level, want, "start", start); err != nil {
+			return err
+		}
+		if i == len(levels)-1 {
+			break
+		}
+		for _, p := range level {
+			fmt.Printf(`waiting for pod "%s" (%s) to run`+"\n", p.Id, p.Name)
+			waitCtx, cancel := context.WithTimeout(ctx, readyTimeout)
+			_, err := watch.Running(waitCtx, p.Id)
+			cancel()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// stopGroup stops the manifest's pods level by level, dependents first.
+func stopGroup(ctx context.Context, want func(p *api.Pod) bool, stop func(p *api.Pod) (string, error)) error {
+	levels, err := groupLevels(ctx)
+	if err != nil {
+		return err
+	}
+	for i := len(levels) - 1; i >= 0; i-- {
+		if err := bulkLevel(levels[i], want, "stop", stop); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bulkLevel runs fn on the pods of one level for which want is true.
+func bulkLevel(level []*api.Pod, want func(p *api.Pod) bool, action string, fn func(p *api.Pod) (string, error)) error {
+	var pods []*api.Pod
+	for _, p := range level {
+		if want(p) {
+			pods = append(pods, p)
+		} else {
+			fmt.Printf(`pod "%s" (%s) is %s; skipped`+"\n", p.Id, p.Name, p.DesiredStatus)
+		}
+	}
+	if len(pods) == 0 {
+		return nil
+	}
+	return fleet.Bulk(pods, parallel, action, fn)
+}
@@ -0,0 +1,61 @@
+package pod
+
+import (
+	"cli/api"
+	"cli/format"
+	"fmt"
+	"io"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+var showMetrics bool
+
+func percent(v int, ok bool) string {
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", v)
+}
+
+// renderMetrics prints the gpu, cpu and memory utilization of each pod. The
+// status of running pods whose gpus are below idleThreshold reads IDLE.
+func renderMetrics(w io.Writer, pods []*api.Pod, idleThreshold int) {
+	data := make([][]string, len(pods))
+	for i, p := range pods {
+		gpuUtil, gpuMem, gpuOk := p.Runtime.GpuUtil()
+		var cpu, ram int
+		containerOk := p.Runtime != nil && p.Runtime.Container != nil
+		if containerOk {
+			cpu, ram = p.Runtime.Container.CpuPercent, p.Runtime.Container.MemoryPercent
+		}
+		status := p.DesiredStatus
+		if p.Idle(idleThreshold) {
+			status = "IDLE"
+		}
+		gpu := fmt.Sprintf("%d", p.GpuCount)
+		if p.Machine != nil {
+			gpu = fmt.Sprintf("%d %s", p.GpuCount, p.Machine.GpuDisplayName)
+		}
+		data[i] = []string{
+			p.Id,
+			p.Name,
+			gpu,
+			status,
+			percent(gpuUtil, gpuOk),
+			percent(gpuMem, gpuOk),
+			percent(cpu, containerOk),
+			percent(ram, containerOk),
+			format.Uptime(p.StartedAt),
+			fmt.Sprintf("%.3f", p.CostPerHr),
+		}
+	}
+
+	header := []string{"ID", "Name", "GPU", "Status", "GPU Util", "GPU Mem", "CPU", "RAM", "Uptime", "$/hr"}
+	format.Highlight(header, data)
+	tb := tablewriter.NewWriter(w)
+	tb.SetHeader(header)
+	tb.AppendBulk(data)
+	format.TableDefaults(tb)
+	tb.Render()
+}
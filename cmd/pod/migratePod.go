@@ -0,0 +1,156 @@
+package pod
+
+import (
+	"cli/api"
+	"cli/complete"
+	"cli/exit"
+	"cli/history"
+	"cli/manifest"
+	"cli/remote"
+	"cli/watch"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateTo         string
+	migrateDataCenter string
+	migrateNoData     bool
+	migrateYes        bool
+)
+
+var MigratePodCmd = &cobra.Command{
+	Use:               "pod [podId]",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: complete.PodId,
+	Short:             "move a pod to secure or community cloud",
+	Long: `recreate a pod with the same spec on an equivalent machine in secure or community cloud, copy its
+pod volume to the new pod directly between the two pods, and remove the original after asking. The
+original must be running to copy its volume; its container disk is not copied`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cloud := strings.ToUpper(migrateTo)
+		if cloud != "SECURE" && cloud != "COMMUNITY" {
+			exit.CheckErr(fmt.Errorf("invalid --to %q: use secure or community", migrateTo))
+		}
+		exit.CheckErr(history.CheckPods(args[:1]))
+		pod, err := api.DefaultClient.GetPod(cmd.Context(), args[0])
+		exit.CheckErr(err)
+		exit.CheckErr(migratePod(cmd.Context(), pod, cloud))
+	},
+}
+
+// migratePod recreates pod in cloud and moves its volume over. A failure
+// after the new pod is created leaves both pods for the user to sort out.
+func migratePod(ctx context.Context, pod *api.Pod, cloud string) error {
+	m := pod.Machine
+	if m == nil || m.GpuTypeId == "" {
+		return fmt.Errorf(`pod "%s" has no machine; only gpu pods can be migrated`, pod.Id)
+	}
+	if m.SecureCloud == (cloud == "SECURE") {
+		return fmt.Errorf(`pod "%s" is already on %s cloud`, pod.Id, strings.ToLower(cloud))
+	}
+	if pod.NetworkVolumeId != "" {
+		return fmt.Errorf(`pod "%s" keeps its data on network volume %s, which cannot move to %s cloud`, pod.Id, pod.NetworkVolumeId, strings.ToLower(cloud))
+	}
+	copyData := pod.VolumeInGb > 0 && !migrateNoData
+	if copyData && pod.Runtime == nil {
+		return fmt.Errorf(`pod "%s" is not running; start it so its volume can be copied, or pass --no-data`, pod.Id)
+	}
+	where := strings.ToLower(cloud) + " cloud"
+	if migrateDataCenter != "" {
+		where += " in " + migrateDataCenter
+	}
+	ok, err := api.DefaultClient.GpuAvailable(ctx, m.GpuTypeId, pod.GpuCount, cloud == "SECURE", migrateDataCenter)
+	if err == nil && !ok {
+		return fmt.Errorf("no %d x %s available on %s; try again later or another datacenter", pod.GpuCount, m.GpuTypeId, where)
+	}
+
+	input := manifest.FromPod(pod).CreatePodInput()
+	input.CloudType = cloud
+	input.DataCenterId = migrateDataCenter
+	created, err := api.DefaultClient.CreatePod(ctx, input)
+	if err != nil {
+		return fmt.Errorf("create on %s failed: %w", where, err)
+	}
+	err = history.Append(&history.Entry{
+		Action:    "migrate pod",
+		PodId:     created.Id,
+		Name:      input.Name,
+		ImageName: input.ImageName,
+		GpuType:   input.GpuTypeId,
+		Status:    "from " + pod.Id,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not record history: %s\n", err)
+	}
+	fmt.Printf(`pod "%s" created on %s; waiting for it to run`+"\n", created.Id, where)
+	leftover := fmt.Sprintf(`pod "%s" is left on %s and pod "%s" is untouched`, created.Id, where, pod.Id)
+
+	waitCtx, cancel := context.WithTimeout(ctx, readyTimeout)
+	created, err = watch.Running(waitCtx, created.Id)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("%w; %s", err, leftover)
+	}
+
+	if copyData {
+		if err := copyVolume(ctx, pod, created); err != nil {
+			return fmt.Errorf("copying the pod volume failed: %w; %s", err, leftover)
+		}
+	}
+
+	fmt.Printf(`pod "%s" migrated to pod "%s" on %s for $%.3f / hr, was $%.3f / hr`+"\n", pod.Id, created.Id, where, created.CostPerHr, pod.CostPerHr)
+	if !migrateYes && !confirm(fmt.Sprintf(`Remove the original pod "%s"?`, pod.Id)) {
+		fmt.Printf(`pod "%s" is kept; remove it with runpodctl remove pod %s`+"\n", pod.Id, pod.Id)
+		return nil
+	}
+	if err := api.DefaultClient.RemovePod(ctx, pod.Id); err != nil {
+		return err
+	}
+	fmt.Printf(`pod "%s" removed`+"\n", pod.Id)
+	return nil
+}
+
+// copyVolume copies the pod volume of src onto dst's. The files are received
+// next to the volume's existing files and then hard linked into place, so
+// folders the new pod's image already created are merged rather than
+// replaced.
+func copyVolume(ctx context.Context, src *api.Pod, dst *api.Pod) error {
+	mount := src.VolumeMountPath
+	if mount == "" {
+		mount = "/workspace"
+	}
+	srcTarget, err := remote.Resolve(src)
+	if err != nil {
+		return err
+	}
+	dstTarget, err := remote.Resolve(dst)
+	if err != nil {
+		return err
+	}
+	staging := path.Join(mount, ".runpodctl-migrate")
+	if err := remote.Transfer(ctx, srcTarget, mount, dstTarget, staging, os.Stdout); err != nil {
+		return err
+	}
+	script := fmt.Sprintf("cp -alf %s/. %s/ && rm -rf %s", remote.Quote(staging), remote.Quote(mount), remote.Quote(staging))
+	var out strings.Builder
+	if err := dstTarget.Run(ctx, script, nil, &out, &out); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(out.String()))
+	}
+	return nil
+}
+
+func init() {
+	MigratePodCmd.Flags().StringVar(&migrateTo, "to", "", "cloud to move the pod to: secure or community")
+	MigratePodCmd.Flags().StringVar(&migrateDataCenter, "dataCenterId", "", "datacenter to create the new pod in, e.g. EU-RO-1; any by default")
+	MigratePodCmd.Flags().BoolVar(&migrateNoData, "no-data", false, "do not copy the pod volume")
+	MigratePodCmd.Flags().BoolVarP(&migrateYes, "yes", "y", false, "remove the original pod without asking")
+	MigratePodCmd.Flags().DurationVar(&readyTimeout, "ready-timeout", 10*time.Minute, "how long to wait for the new pod to run")
+	MigratePodCmd.MarkFlagRequired("to") //nolint
+}
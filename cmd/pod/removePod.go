@@ -2,21 +2,33 @@ package pod
 
 import (
 	"cli/api"
+	"cli/complete"
+	"cli/exit"
+	"cli/fleet"
 	"fmt"
 
 	"github.com/spf13/cobra"
 )
 
 var RemovePodCmd = &cobra.Command{
-	Use:   "pod [podId]",
-	Args:  cobra.ExactArgs(1),
-	Short: "remove a pod",
-	Long:  "remove a pod from runpod.io",
+	Use:               "pod [podId]...",
+	Args:              bulkArgs,
+	ValidArgsFunction: complete.PodIds,
+	Short:             "remove pods",
+	Long:              "remove one or more pods from runpod.io",
 	Run: func(cmd *cobra.Command, args []string) {
-		_, err := api.RemovePod(args[0])
-		cobra.CheckErr(err)
+		pods, err := bulkTargets(cmd.Context(), args, nil)
+		exit.CheckErr(err)
 
-		fmt.Printf(`pod "%s" removed`, args[0])
-		fmt.Println()
+		exit.CheckErr(fleet.Bulk(pods, parallel, "remove", func(p *api.Pod) (string, error) {
+			if err := api.DefaultClient.RemovePod(cmd.Context(), p.Id); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf(`pod "%s" removed`, p.Id), nil
+		}))
 	},
 }
+
+func init() {
+	bulkFlags(RemovePodCmd, "remove")
+}
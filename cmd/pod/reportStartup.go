@@ -0,0 +1,68 @@
+package pod
+
+import (
+	"cli/billing"
+	"cli/exit"
+	"cli/format"
+	"cli/history"
+	"cli/startup"
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var reportOutput string
+var reportSince string
+
+var ReportStartupCmd = &cobra.Command{
+	Use:   "startup",
+	Args:  cobra.ExactArgs(0),
+	Short: "report pod startup times",
+	Long:  "aggregate the time-to-running of pods created with create pod --wait under the active profile by image and datacenter, split into queueing, image pull and container start, slowest first",
+	Run: func(cmd *cobra.Command, args []string) {
+		since, err := billing.ParseSince(reportSince)
+		exit.CheckErr(err)
+		entries, err := history.Read()
+		exit.CheckErr(err)
+		groups := startup.Aggregate(history.Mine(entries), since)
+
+		printed, err := format.Print(os.Stdout, reportOutput, "", groups)
+		exit.CheckErr(err)
+		if printed {
+			return
+		}
+		if len(groups) == 0 {
+			fmt.Printf("no startups recorded since %s; they are recorded by create pod --wait\n", since.Format("2006-01-02 15:04"))
+			return
+		}
+		data := make([][]string, len(groups))
+		for i, g := range groups {
+			data[i] = []string{
+				g.ImageName,
+				g.DataCenterId,
+				fmt.Sprintf("%d", g.Pods),
+				startupSeconds(g.MedianTotal),
+				startupSeconds(g.P90Total),
+				startupSeconds(g.MedianQueue),
+				startupSeconds(g.MedianPull),
+				startupSeconds(g.MedianStart),
+			}
+		}
+		tb := tablewriter.NewWriter(os.Stdout)
+		tb.SetHeader([]string{"Image Name", "Datacenter", "Pods", "Median", "P90", "Queue", "Pull", "Start"})
+		tb.AppendBulk(data)
+		format.TableDefaults(tb)
+		tb.Render()
+	},
+}
+
+func init() {
+	ReportStartupCmd.Flags().StringVarP(&reportOutput, "output", "o", "", "output format: json, yaml, jsonpath=<expression> or template=<go template>")
+	ReportStartupCmd.Flags().StringVar(&reportSince, "since", "7d", "only startups since a duration ago (e.g. 7d, 12h) or an RFC3339 time")
+}
+
+func startupSeconds(s float64) string {
+	return fmt.Sprintf("%.0fs", s)
+}
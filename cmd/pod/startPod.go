@@ -1,38 +1,161 @@
 package pod
 
 import (
+	"bufio"
 	"cli/api"
+	"cli/complete"
+	"cli/exit"
+	"cli/fleet"
+	"cli/history"
+	"cli/manifest"
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
-var bidPerGpu float32
+var (
+	bidPerGpu float32
+	autoClone bool
+)
 
 var StartPodCmd = &cobra.Command{
-	Use:   "pod [podId]",
-	Args:  cobra.ExactArgs(1),
-	Short: "start a pod",
-	Long:  "start a pod from runpod.io",
+	Use:               "pod [podId]...",
+	Args:              bulkArgs,
+	ValidArgsFunction: complete.PodIds,
+	Short:             "start pods",
+	Long: `start one or more pods from runpod.io. With -f, start the pods of a manifest in dependsOn order,
+each group once the pods it depends on are running`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var err error
-		var pod map[string]interface{}
-		if bidPerGpu > 0 {
-			pod, err = api.StartSpotPod(args[0], bidPerGpu)
-		} else {
-			pod, err = api.StartOnDemandPod(args[0])
+		stopped := func(p *api.Pod) bool {
+			return p.DesiredStatus == "EXITED"
 		}
-		cobra.CheckErr(err)
-
-		if pod["desiredStatus"] == "RUNNING" {
-			fmt.Printf(`pod "%s" started with $%.3f / hr`, args[0], pod["costPerHr"])
-			fmt.Println()
-		} else {
-			cobra.CheckErr(fmt.Errorf(`pod "%s" start failed; status is %s`, args[0], pod["desiredStatus"]))
+		start := func(p *api.Pod) (string, error) {
+			if bidPerGpu <= 0 {
+				return startOnDemand(cmd.Context(), p.Id)
+			}
+			pod, err := api.DefaultClient.StartSpotPod(cmd.Context(), p.Id, bidPerGpu)
+			if err != nil {
+				return "", err
+			}
+			if pod.DesiredStatus != "RUNNING" {
+				return "", fmt.Errorf("status is %s", pod.DesiredStatus)
+			}
+			record(history.StartAction, p)
+			return fmt.Sprintf(`pod "%s" started with $%.3f / hr`, p.Id, pod.CostPerHr), nil
+		}
+		if groupFile != "" {
+			exit.CheckErr(startGroup(cmd.Context(), stopped, start))
+			return
 		}
+
+		pods, err := bulkTargets(cmd.Context(), args, stopped)
+		exit.CheckErr(err)
+		exit.CheckErr(fleet.Bulk(pods, parallel, "start", start))
 	},
 }
 
+// startOnDemand resumes a pod after checking that its gpu type is still
+// offered in its datacenter. When its own machine has no free gpus, the pod
+// can be cloned to an equivalent machine in the same datacenter instead.
+func startOnDemand(ctx context.Context, id string) (string, error) {
+	pod, err := api.DefaultClient.GetPod(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	m := pod.Machine
+	if m != nil && m.DataCenterId != "" {
+		ok, err := api.DefaultClient.GpuAvailable(ctx, m.GpuTypeId, pod.GpuCount, m.SecureCloud, m.DataCenterId)
+		if err == nil && !ok {
+			return "", fmt.Errorf("no %d x %s available in %s; try again later or create the pod in another datacenter", pod.GpuCount, m.GpuTypeId, m.DataCenterId)
+		}
+	}
+
+	started, err := api.DefaultClient.StartOnDemandPod(ctx, id)
+	if errors.Is(err, api.ErrNoFreeGpus) && m != nil && m.DataCenterId != "" {
+		return clonePod(ctx, pod)
+	}
+	if err != nil {
+		return "", err
+	}
+	if started.DesiredStatus != "RUNNING" {
+		return "", fmt.Errorf("status is %s", started.DesiredStatus)
+	}
+	record(history.StartAction, pod)
+	return fmt.Sprintf(`pod "%s" started with $%.3f / hr`, id, started.CostPerHr), nil
+}
+
+// record notes a start or stop in the history, so keepalive and track can
+// tell it from a preemption.
+func record(action string, p *api.Pod) {
+	err := history.Append(&history.Entry{Action: action, PodId: p.Id, Name: p.Name})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not record history: %s\n", err)
+	}
+}
+
+// clonePod creates a copy of pod on another machine in its datacenter, after
+// asking unless --auto is set. The original pod is left stopped.
+func clonePod(ctx context.Context, pod *api.Pod) (string, error) {
+	dataCenter := pod.Machine.DataCenterId
+	if !autoClone {
+		question := fmt.Sprintf(`pod "%s" has no free gpus on its machine. Clone it to an equivalent machine in %s?`, pod.Id, dataCenter)
+		if pod.NetworkVolumeId == "" && pod.VolumeInGb > 0 {
+			question += " Files on its pod volume are not copied."
+		}
+		if !confirm(question) {
+			return "", fmt.Errorf("%w; use --auto to clone it to another machine in %s", api.ErrNoFreeGpus, dataCenter)
+		}
+	}
+
+	input := manifest.FromPod(pod).CreatePodInput()
+	input.DataCenterId = dataCenter
+	clone, err := api.DefaultClient.CreatePod(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("clone failed: %w", err)
+	}
+	if clone.DesiredStatus != "RUNNING" {
+		return "", fmt.Errorf(`clone "%s" start failed; status is %s`, clone.Id, clone.DesiredStatus)
+	}
+	err = history.Append(&history.Entry{
+		Action:    "clone pod",
+		PodId:     clone.Id,
+		Name:      input.Name,
+		ImageName: input.ImageName,
+		GpuType:   input.GpuTypeId,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not record history: %s\n", err)
+	}
+	return fmt.Sprintf(`pod "%s" has no free gpus on its machine; cloned to pod "%s" in %s for $%.3f / hr`, pod.Id, clone.Id, dataCenter, clone.CostPerHr), nil
+}
+
+var confirmMu sync.Mutex
+
+// confirm asks a yes/no question on the terminal. It is false when stdin is
+// not a terminal.
+func confirm(question string) bool {
+	confirmMu.Lock()
+	defer confirmMu.Unlock()
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
 func init() {
 	StartPodCmd.Flags().Float32Var(&bidPerGpu, "bid", 0, "bid per gpu for spot price")
+	StartPodCmd.Flags().BoolVar(&autoClone, "auto", false, "clone the pod to an equivalent machine in the same datacenter when its machine has no free gpus")
+	bulkFlags(StartPodCmd, "start")
+	groupFlags(StartPodCmd, "start")
+	StartPodCmd.Flags().DurationVar(&readyTimeout, "ready-timeout", 10*time.Minute, "with -f, how long to wait for pods to run before starting the pods that depend on them")
 }
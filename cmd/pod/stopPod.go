@@ -2,25 +2,48 @@ package pod
 
 import (
 	"cli/api"
+	"cli/complete"
+	"cli/exit"
+	"cli/fleet"
+	"cli/history"
 	"fmt"
 
 	"github.com/spf13/cobra"
 )
 
 var StopPodCmd = &cobra.Command{
-	Use:   "pod [podId]",
-	Args:  cobra.ExactArgs(1),
-	Short: "stop a pod",
-	Long:  "stop a pod from runpod.io",
+	Use:               "pod [podId]...",
+	Args:              bulkArgs,
+	ValidArgsFunction: complete.PodIds,
+	Short:             "stop pods",
+	Long:              "stop one or more pods from runpod.io. With -f, stop the pods of a manifest before the pods they depend on",
 	Run: func(cmd *cobra.Command, args []string) {
-		pod, err := api.StopPod(args[0])
-		cobra.CheckErr(err)
-
-		if pod["desiredStatus"] == "EXITED" {
-			fmt.Printf(`pod "%s" stopped`, args[0])
-		} else {
-			fmt.Printf(`pod "%s" stop failed; status is %s`, args[0], pod["desiredStatus"])
+		running := func(p *api.Pod) bool {
+			return p.DesiredStatus == "RUNNING"
+		}
+		stop := func(p *api.Pod) (string, error) {
+			pod, err := api.DefaultClient.StopPod(cmd.Context(), p.Id)
+			if err != nil {
+				return "", err
+			}
+			if pod.DesiredStatus != "EXITED" {
+				return "", fmt.Errorf("status is %s", pod.DesiredStatus)
+			}
+			record(history.StopAction, p)
+			return fmt.Sprintf(`pod "%s" stopped`, p.Id), nil
+		}
+		if groupFile != "" {
+			exit.CheckErr(stopGroup(cmd.Context(), running, stop))
+			return
 		}
-		fmt.Println()
+
+		pods, err := bulkTargets(cmd.Context(), args, running)
+		exit.CheckErr(err)
+		exit.CheckErr(fleet.Bulk(pods, parallel, "stop", stop))
 	},
 }
+
+func init() {
+	bulkFlags(StopPodCmd, "stop")
+	groupFlags(StopPodCmd, "stop")
+}
@@ -0,0 +1,77 @@
+package pod
+
+import (
+	"cli/api"
+	"cli/exit"
+	"cli/format"
+	"cli/watch"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var idleThreshold int
+var topInterval time.Duration
+
+var TopPodsCmd = &cobra.Command{
+	Use:   "pods",
+	Args:  cobra.ExactArgs(0),
+	Short: "show pod utilization",
+	Long:  "show the gpu, cpu and memory utilization of running pods, refreshed every interval until interrupted; pods whose gpus sit below --idle-threshold are marked IDLE and listed first",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+		tty := term.IsTerminal(int(os.Stdout.Fd()))
+		first := true
+		err := watch.Poll(ctx, topInterval, func() (bool, error) {
+			all, err := api.DefaultClient.GetPods(ctx)
+			if err = format.Partial(err); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+				return false, nil
+			}
+			var pods []*api.Pod
+			var idleCost float32
+			idleCount := 0
+			for _, p := range all {
+				if p.DesiredStatus != "RUNNING" {
+					continue
+				}
+				pods = append(pods, p)
+				if p.Idle(idleThreshold) {
+					idleCount++
+					idleCost += p.CostPerHr
+				}
+			}
+			sort.SliceStable(pods, func(i, j int) bool {
+				a, _, _ := pods[i].Runtime.GpuUtil()
+				b, _, _ := pods[j].Runtime.GpuUtil()
+				return a < b
+			})
+
+			if tty {
+				fmt.Print("\033[H\033[2J")
+			} else if !first {
+				fmt.Println()
+			}
+			first = false
+			fmt.Printf("%s  %d running pods, %d idle costing $%.3f/hr\n", time.Now().Format("15:04:05"), len(pods), idleCount, idleCost)
+			renderMetrics(os.Stdout, pods, idleThreshold)
+			return false, nil
+		})
+		if err != context.Canceled {
+			exit.CheckErr(err)
+		}
+	},
+}
+
+func init() {
+	TopPodsCmd.Flags().DurationVar(&topInterval, "interval", watch.PollInterval, "refresh interval")
+	TopPodsCmd.Flags().IntVar(&idleThreshold, "idle-threshold", 5, "gpu utilization percent below which a pod is idle")
+	TopPodsCmd.Flags().BoolVar(&format.Timestamps, "timestamps", false, "show absolute RFC3339 times instead of relative durations")
+}
@@ -0,0 +1,394 @@
+package pod
+
+import (
+	"cli/api"
+	"cli/exit"
+	"cli/format"
+	"cli/history"
+	"cli/remote"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var uiInterval time.Duration
+
+var UiCmd = &cobra.Command{
+	Use:   "ui",
+	Args:  cobra.ExactArgs(0),
+	Short: "interactive pod dashboard",
+	Long: `a live-refreshing table of pods with status, gpu and cost in the terminal. keys:
+  up/down or k/j  select a pod
+  s  start         x  stop          d  terminate (asks to confirm)
+  l  follow logs   e  open a shell  r  refresh   q  quit
+ctrl-c returns from logs to the dashboard`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+			exit.CheckErr(fmt.Errorf("ui needs a terminal; use runpodctl get pod --watch in scripts"))
+		}
+		d := &dashboard{ctx: cmd.Context()}
+		exit.CheckErr(d.run())
+	},
+}
+
+func init() {
+	UiCmd.Flags().DurationVar(&uiInterval, "interval", 5*time.Second, "refresh interval")
+}
+
+// dashboard is the state of the ui. The selection is kept by pod id so it
+// follows the pod when a refresh reorders the list.
+type dashboard struct {
+	ctx      context.Context
+	pods     []*api.Pod
+	selected string
+	offset   int
+	message  string
+	confirm  string
+	updated  time.Time
+	raw      *term.State
+}
+
+type podsResult struct {
+	pods []*api.Pod
+	err  error
+}
+
+func (d *dashboard) run() error {
+	if err := d.enter(); err != nil {
+		return err
+	}
+	defer d.leave()
+
+	// the reader waits for an ack after every key, so it never reads stdin
+	// while a shell or the logs have the terminal
+	keys := make(chan []byte)
+	ack := make(chan struct{})
+	go func() {
+		buf := make([]byte, 16)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- append([]byte(nil), buf[:n]...)
+			<-ack
+		}
+	}()
+
+	results := make(chan podsResult, 1)
+	fetching := false
+	fetch := func() {
+		if fetching {
+			return
+		}
+		fetching = true
+		go func() {
+			pods, err := api.DefaultClient.GetPods(d.ctx)
+			results <- podsResult{pods, err}
+		}()
+	}
+	fetch()
+	ticker := time.NewTicker(uiInterval)
+	defer ticker.Stop()
+	d.draw()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return nil
+		case <-ticker.C:
+			fetch()
+		case r := <-results:
+			fetching = false
+			if r.err == nil || api.IsPartial(r.err) {
+				d.pods = r.pods
+				d.updated = time.Now()
+			}
+			if r.err != nil {
+				d.message = r.err.Error()
+			}
+			d.draw()
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			quit, refresh := d.handle(string(key))
+			ack <- struct{}{}
+			if quit {
+				return nil
+			}
+			if refresh {
+				fetch()
+			}
+			d.draw()
+		}
+	}
+}
+
+// handle acts on a key press and reports whether to quit and whether the
+// pods changed and should be fetched again.
+func (d *dashboard) handle(key string) (quit bool, refresh bool) {
+	pod := d.current()
+	if d.confirm != "" {
+		id := d.confirm
+		d.confirm = ""
+		if key != "y" {
+			d.message = "terminate cancelled"
+			return false, false
+		}
+		d.status(fmt.Sprintf(`terminating pod "%s"...`, id))
+		if err := api.DefaultClient.RemovePod(d.ctx, id); err != nil {
+			d.message = err.Error()
+		} else {
+			d.message = fmt.Sprintf(`pod "%s" removed`, id)
+		}
+		return false, true
+	}
+
+	d.message = ""
+	switch key {
+	case "q", "\x03":
+		return true, false
+	case "r":
+		return false, true
+	case "k", "\x1b[A":
+		d.move(-1)
+	case "j", "\x1b[B":
+		d.move(1)
+	}
+	if pod == nil {
+		return false, false
+	}
+	switch key {
+	case "s":
+		if pod.PodType == "INTERRUPTABLE" {
+			d.message = fmt.Sprintf("spot pods need a bid: runpodctl start pod %s --bid <price>", pod.Id)
+			return false, false
+		}
+		d.status(fmt.Sprintf(`starting pod "%s"...`, pod.Id))
+		msg, err := startOnDemand(d.ctx, pod.Id)
+		d.message = msg
+		if err != nil {
+			d.message = err.Error()
+		}
+		return false, true
+	case "x":
+		d.status(fmt.Sprintf(`stopping pod "%s"...`, pod.Id))
+		stopped, err := api.DefaultClient.StopPod(d.ctx, pod.Id)
+		switch {
+		case err != nil:
+			d.message = err.Error()
+		case stopped.DesiredStatus != "EXITED":
+			d.message = fmt.Sprintf("status is %s", stopped.DesiredStatus)
+		default:
+			record(history.StopAction, pod)
+			d.message = fmt.Sprintf(`pod "%s" stopped`, pod.Id)
+		}
+		return false, true
+	case "d":
+		d.confirm = pod.Id
+		d.message = fmt.Sprintf(`terminate pod "%s" (%s)? y to confirm, any other key to cancel`, pod.Id, pod.Name)
+	case "l":
+		d.suspend(func() error { return d.logs(pod) })
+	case "e":
+		d.suspend(func() error { return d.shell(pod) })
+		return false, true
+	}
+	return false, false
+}
+
+// move changes the selection by delta rows.
+func (d *dashboard) move(delta int) {
+	if len(d.pods) == 0 {
+		return
+	}
+	i := d.index() + delta
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(d.pods) {
+		i = len(d.pods) - 1
+	}
+	d.selected = d.pods[i].Id
+}
+
+func (d *dashboard) index() int {
+	for i, p := range d.pods {
+		if p.Id == d.selected {
+			return i
+		}
+	}
+	return 0
+}
+
+func (d *dashboard) current() *api.Pod {
+	if len(d.pods) == 0 {
+		return nil
+	}
+	return d.pods[d.index()]
+}
+
+// status shows a message right away, before a slow action runs.
+func (d *dashboard) status(msg string) {
+	d.message = msg
+	d.draw()
+}
+
+func (d *dashboard) draw() {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+	var cost float32
+	running := 0
+	for _, p := range d.pods {
+		if p.DesiredStatus == "RUNNING" {
+			running++
+			cost += p.CostPerHr
+		}
+	}
+	updated := "loading..."
+	if !d.updated.IsZero() {
+		updated = "updated " + d.updated.Format("15:04:05")
+	}
+
+	// keep the selection in view; the header, table header and the two
+	// footer lines take four rows
+	rows := height - 4
+	if rows < 1 {
+		rows = 1
+	}
+	i := d.index()
+	if i < d.offset {
+		d.offset = i
+	}
+	if i >= d.offset+rows {
+		d.offset = i - rows + 1
+	}
+	if d.offset > len(d.pods) {
+		d.offset = 0
+	}
+	end := d.offset + rows
+	if end > len(d.pods) {
+		end = len(d.pods)
+	}
+
+	data := make([][]string, 0, end-d.offset)
+	for _, p := range d.pods[d.offset:end] {
+		marker := ""
+		if p.Id == d.pods[i].Id {
+			marker = ">"
+		}
+		gpu := ""
+		if p.Machine != nil {
+			gpu = fmt.Sprintf("%d %s", p.GpuCount, p.Machine.GpuDisplayName)
+		}
+		data = append(data, []string{marker, p.Id, p.Name, p.DesiredStatus, gpu, fmt.Sprintf("%.3f", p.CostPerHr), format.Uptime(p.StartedAt)})
+	}
+	header := []string{"", "ID", "Name", "Status", "GPU", "$/hr", "Uptime"}
+	format.Highlight(header, data)
+	var sb strings.Builder
+	tb := tablewriter.NewWriter(&sb)
+	tb.SetHeader(header)
+	tb.AppendBulk(data)
+	format.TableDefaults(tb)
+	tb.Render()
+
+	var out strings.Builder
+	out.WriteString("\033[H\033[2J")
+	fmt.Fprintf(&out, "%d pods, %d running for $%.3f/hr  %s\n", len(d.pods), running, cost, updated)
+	out.WriteString(sb.String())
+	fmt.Fprintf(&out, "\033[%d;1H", height-1)
+	out.WriteString(truncate(d.message, width) + "\n")
+	out.WriteString(truncate("up/down select  s start  x stop  d terminate  l logs  e shell  r refresh  q quit", width))
+	// raw mode does not turn \n into \r\n
+	fmt.Print(strings.ReplaceAll(out.String(), "\n", "\r\n"))
+}
+
+func truncate(s string, width int) string {
+	if len(s) > width {
+		return s[:width]
+	}
+	return s
+}
+
+// enter puts the terminal in raw mode on the alternate screen.
+func (d *dashboard) enter() error {
+	raw, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return err
+	}
+	d.raw = raw
+	fmt.Print("\033[?1049h\033[?25l")
+	return nil
+}
+
+// leave restores the terminal.
+func (d *dashboard) leave() {
+	fmt.Print("\033[?25h\033[?1049l")
+	term.Restore(int(os.Stdin.Fd()), d.raw) //nolint
+}
+
+// suspend hands the terminal to fn and takes it back when fn returns.
+func (d *dashboard) suspend(fn func() error) {
+	d.leave()
+	if err := fn(); err != nil {
+		d.message = err.Error()
+	}
+	if err := d.enter(); err != nil {
+		d.message = err.Error()
+	}
+}
+
+// logs follows the container logs of pod until ctrl-c.
+func (d *dashboard) logs(pod *api.Pod) error {
+	ctx, stop := signal.NotifyContext(d.ctx, os.Interrupt)
+	defer stop()
+	fmt.Printf("logs of pod %s (%s); ctrl-c to return\n", pod.Id, pod.Name)
+	input := &api.PodLogsInput{PodId: pod.Id, Type: api.LogTypeContainer, Tail: 100}
+	out := make(chan *api.PodLogLine)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- api.DefaultClient.StreamPodLogs(ctx, input, 2*time.Second, out)
+		close(out)
+	}()
+	for line := range out {
+		fmt.Println(line.Message)
+	}
+	if err := <-errc; err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// shell opens an interactive shell in pod over ssh.
+func (d *dashboard) shell(pod *api.Pod) error {
+	if pod.DesiredStatus != "RUNNING" {
+		return fmt.Errorf(`pod "%s" is not running; status is %s`, pod.Id, pod.DesiredStatus)
+	}
+	target, err := remote.Resolve(pod)
+	if err != nil {
+		return err
+	}
+	// ctrl-c belongs to the remote shell, not to the dashboard
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	c, err := target.Command(d.ctx, "", true)
+	if err != nil {
+		return err
+	}
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Run() //nolint
+	return nil
+}
@@ -0,0 +1,86 @@
+package pod
+
+import (
+	"cli/api"
+	"cli/complete"
+	"cli/exit"
+	"cli/history"
+	"cli/manifest"
+	"cli/podenv"
+	"cli/secrets"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateContainerDiskInGb int
+	updateDockerArgs        string
+	updateEnv               []string
+	updateImageName         string
+	updatePorts             []string
+	updateVolumeInGb        int
+	updateVolumeMountPath   string
+)
+
+var UpdatePodCmd = &cobra.Command{
+	Use:               "pod [podId]",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: complete.PodId,
+	Short:             "update a pod",
+	Long:              "update a pod in place, keeping its volume; only the flags given are changed and a running pod is restarted with the new settings",
+	Run: func(cmd *cobra.Command, args []string) {
+		exit.CheckErr(history.CheckPods(args[:1]))
+		current, err := api.DefaultClient.GetPod(cmd.Context(), args[0])
+		exit.CheckErr(err)
+
+		spec := manifest.FromPod(current).Spec
+		flags := cmd.Flags()
+		if flags.Changed("containerDiskSize") {
+			spec.ContainerDiskInGb = updateContainerDiskInGb
+		}
+		if flags.Changed("args") {
+			spec.DockerArgs = updateDockerArgs
+		}
+		if flags.Changed("imageName") {
+			spec.ImageName = updateImageName
+		}
+		if flags.Changed("ports") {
+			spec.Ports = updatePorts
+		}
+		if flags.Changed("volumeSize") {
+			if updateVolumeInGb < current.VolumeInGb {
+				exit.CheckErr(fmt.Errorf("volume can only grow; it is %d GB", current.VolumeInGb))
+			}
+			spec.VolumeInGb = updateVolumeInGb
+		}
+		if flags.Changed("volumePath") {
+			spec.VolumeMountPath = updateVolumeMountPath
+		}
+		if spec.Env == nil {
+			spec.Env = make(map[string]string)
+		}
+		for _, pair := range updateEnv {
+			e, err := podenv.Parse(pair)
+			exit.CheckErr(err)
+			spec.Env[e.Key] = e.Value
+		}
+
+		input := (&manifest.Pod{Spec: spec}).UpdatePodInput(current)
+		exit.CheckErr(secrets.Check(input.Env))
+		pod, err := api.DefaultClient.UpdatePodRestarting(cmd.Context(), input)
+		exit.CheckErr(err)
+		fmt.Printf(`pod "%s" updated; status is %s`, args[0], pod.DesiredStatus)
+		fmt.Println()
+	},
+}
+
+func init() {
+	UpdatePodCmd.Flags().IntVar(&updateContainerDiskInGb, "containerDiskSize", 0, "container disk size in GB")
+	UpdatePodCmd.Flags().StringVar(&updateDockerArgs, "args", "", "container arguments")
+	UpdatePodCmd.Flags().StringArrayVar(&updateEnv, "env", nil, "env var to set or change as KEY=VALUE; repeat for more, values may contain commas; other variables are kept")
+	UpdatePodCmd.Flags().StringVar(&updateImageName, "imageName", "", "container image name")
+	UpdatePodCmd.Flags().StringSliceVar(&updatePorts, "ports", nil, "ports to expose, replacing the current ones; e.g. '8888/http'")
+	UpdatePodCmd.Flags().IntVar(&updateVolumeInGb, "volumeSize", 0, "persistent volume disk size in GB; can only grow")
+	UpdatePodCmd.Flags().StringVar(&updateVolumeMountPath, "volumePath", "", "container volume path")
+}
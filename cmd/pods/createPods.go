@@ -2,29 +2,51 @@ package pods
 
 import (
 	"cli/api"
+	"cli/complete"
+	"cli/dataset"
+	"cli/exit"
+	"cli/fleet"
+	"cli/hfcache"
+	"cli/history"
+	"cli/podenv"
+	"cli/secrets"
+	"cli/tracking"
+	"context"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 var communityCloud bool
 var containerDiskInGb int
+var datasets []string
 var deployCost float32
 var dockerArgs string
 var env []string
+var envFiles []string
+var gitMetadata bool
 var gpuCount int
 var gpuTypeId string
+var hfCache string
 var imageName string
 var minMemoryInGb int
 var minVcpuCount int
 var name string
 var podCount int
 var ports []string
+var registryAuthId string
+var secretEnv []string
 var secureCloud bool
+var track string
 var volumeInGb int
 var volumeMountPath string
 
+const datasetTimeout = 30 * time.Minute
+
 var CreatePodsCmd = &cobra.Command{
 	Use:   "pods",
 	Args:  cobra.ExactArgs(0),
@@ -48,35 +70,92 @@ var CreatePodsCmd = &cobra.Command{
 		if len(ports) > 0 {
 			input.Ports = strings.Join(ports, ",")
 		}
-		input.Env = make([]*api.PodEnv, len(env))
-		for i, v := range env {
-			e := strings.Split(v, "=")
-			if len(e) != 2 {
-				cobra.CheckErr(fmt.Errorf("wrong env value: %s", e))
-			}
-			input.Env[i] = &api.PodEnv{Key: e[0], Value: e[1]}
+		var err error
+		input.Env, err = podenv.Build(envFiles, env, secretEnv)
+		exit.CheckErr(err)
+		if registryAuthId != "" {
+			auth, err := api.DefaultClient.GetRegistryAuth(cmd.Context(), registryAuthId)
+			exit.CheckErr(err)
+			input.ContainerRegistryAuthId = auth.Id
 		}
 		if secureCloud {
 			input.CloudType = "SECURE"
 		} else {
 			input.CloudType = "COMMUNITY"
 		}
+		var git *history.GitInfo
+		if gitMetadata {
+			var err error
+			git, err = history.Git()
+			exit.CheckErr(err)
+			input.Env = append(input.Env, git.Env()...)
+		}
+		var trackingUrl string
+		if track != "" {
+			_, trackingUrl, err = tracking.Env(track, gpus[0])
+			exit.CheckErr(err)
+		}
+		exit.CheckErr(hfcache.Apply(input, hfCache))
+		fetch, err := dataset.Apply(input, datasets)
+		exit.CheckErr(err)
+		exit.CheckErr(secrets.Check(input.Env))
+		podEnv := input.Env
 
+		if trackingUrl != "" {
+			fmt.Printf("tracking runs at %s\n", trackingUrl)
+		}
+		var created []*api.Pod
 		for x := 0; x < podCount; x++ {
 			input.GpuTypeId = gpus[gpusIndex]
-			pod, err := api.CreatePod(input)
+			if track != "" {
+				// runs are tagged with the gpu type each pod gets
+				trackingEnv, _, err := tracking.Env(track, input.GpuTypeId)
+				exit.CheckErr(err)
+				input.Env = append(podEnv[:len(podEnv):len(podEnv)], trackingEnv...)
+			}
+			pod, err := api.DefaultClient.CreatePod(cmd.Context(), input)
 			if err != nil && len(gpus) > gpusIndex+1 && strings.Contains(err.Error(), "no longer any instances available") {
 				gpusIndex++
 				x--
 				continue
 			}
-			cobra.CheckErr(err)
+			exit.CheckErr(err)
 
-			if pod["desiredStatus"] == "RUNNING" {
-				fmt.Printf(`pod "%s" created for $%.3f / hr`, pod["id"], pod["costPerHr"])
+			if pod.DesiredStatus == "RUNNING" {
+				fmt.Printf(`pod "%s" created for $%.3f / hr`, pod.Id, pod.CostPerHr)
 				fmt.Println()
+				podId := pod.Id
+				created = append(created, pod)
+				err = history.Append(&history.Entry{
+					Action:    "create pods",
+					PodId:     podId,
+					Name:      input.Name,
+					ImageName: input.ImageName,
+					GpuType:   input.GpuTypeId,
+					Git:       git,
+				})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "warning: could not record history: %s\n", err)
+				}
 			} else {
-				cobra.CheckErr(fmt.Errorf(`pod "%s" start failed; status is %s`, args[0], pod["desiredStatus"]))
+				exit.CheckErr(fmt.Errorf(`pod "%s" start failed; status is %s`, args[0], pod.DesiredStatus))
+			}
+		}
+
+		if len(fetch) > 0 {
+			ctx, cancel := context.WithTimeout(cmd.Context(), datasetTimeout)
+			defer cancel()
+			failed := fleet.Run(created, len(created), func(p *api.Pod) error {
+				return dataset.Fetch(ctx, p.Id, fetch, io.Discard)
+			}, func(r fleet.Result) {
+				if r.Err != nil {
+					fmt.Printf("%s: dataset download failed: %s\n", r.Pod.Id, r.Err)
+				} else {
+					fmt.Printf("%s: datasets ready\n", r.Pod.Id)
+				}
+			})
+			if failed > 0 {
+				exit.CheckErr(fmt.Errorf("dataset download failed on %d of %d pods", failed, len(created)))
 			}
 		}
 	},
@@ -84,6 +163,7 @@ var CreatePodsCmd = &cobra.Command{
 
 func init() {
 	CreatePodsCmd.Flags().BoolVar(&communityCloud, "communityCloud", false, "create in community cloud")
+	CreatePodsCmd.Flags().BoolVar(&gitMetadata, "git-metadata", false, "inject RUNPOD_GIT_COMMIT, RUNPOD_GIT_BRANCH and RUNPOD_GIT_DIRTY from the current git repository")
 	CreatePodsCmd.Flags().BoolVar(&secureCloud, "secureCloud", false, "create in secure cloud")
 	CreatePodsCmd.Flags().Float32Var(&deployCost, "cost", 0, "$/hr price ceiling, if not defined, pod will be created with lowest price available")
 	CreatePodsCmd.Flags().IntVar(&containerDiskInGb, "containerDiskSize", 20, "container disk size in GB")
@@ -92,12 +172,19 @@ func init() {
 	CreatePodsCmd.Flags().IntVar(&minVcpuCount, "vcpu", 1, "minimum vCPUs needed")
 	CreatePodsCmd.Flags().IntVar(&podCount, "podCount", 1, "number of pods to create with the same name")
 	CreatePodsCmd.Flags().IntVar(&volumeInGb, "volumeSize", 1, "persistent volume disk size in GB")
-	CreatePodsCmd.Flags().StringSliceVar(&env, "env", nil, "container arguments")
+	CreatePodsCmd.Flags().StringSliceVar(&datasets, "dataset", nil, "registered dataset to mount (volume) or download into the pods (url); see runpodctl dataset")
+	CreatePodsCmd.Flags().StringArrayVar(&env, "env", nil, "env var as KEY=VALUE; repeat for more, values may contain commas")
+	CreatePodsCmd.Flags().StringArrayVar(&envFiles, "env-file", nil, "dotenv file with KEY=VALUE lines; --env overrides its values")
+	CreatePodsCmd.Flags().StringArrayVar(&secretEnv, "secret-env", nil, "env var set from a runpod secret as KEY=secretName; the value never leaves runpod")
 	CreatePodsCmd.Flags().StringSliceVar(&ports, "ports", nil, "ports to expose; max only 1 http and 1 tcp allowed; e.g. '8888/http'")
 	CreatePodsCmd.Flags().StringVar(&dockerArgs, "args", "", "container arguments")
 	CreatePodsCmd.Flags().StringVar(&gpuTypeId, "gpuType", "", "gpu type id, e.g. 'NVIDIA GeForce RTX 3090'")
+	CreatePodsCmd.RegisterFlagCompletionFunc("gpuType", complete.GpuTypes) //nolint
+	CreatePodsCmd.Flags().StringVar(&hfCache, "hf-cache", "", "network volume id to mount as a shared Hugging Face cache; sets HF_HOME to <volumePath>/huggingface")
 	CreatePodsCmd.Flags().StringVar(&imageName, "imageName", "", "container image name")
 	CreatePodsCmd.Flags().StringVar(&name, "name", "", "any pod name for easy reference")
+	CreatePodsCmd.Flags().StringVar(&registryAuthId, "registry-auth-id", "", "id or name of saved registry credentials to pull a private image; see runpodctl create registry-auth")
+	CreatePodsCmd.Flags().StringVar(&track, "track", "", "experiment tracker to wire into the pods from tracking.<tracker> in config: wandb or mlflow")
 	CreatePodsCmd.Flags().StringVar(&volumeMountPath, "volumePath", "/runpod", "container volume path")
 
 	CreatePodsCmd.MarkFlagRequired("gpuType")   //nolint
@@ -2,36 +2,64 @@ package pods
 
 import (
 	"cli/api"
+	"cli/exit"
+	"cli/fleet"
 	"fmt"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	namePrefix     string
+	removeParallel int
+)
+
 var RemovePodsCmd = &cobra.Command{
 	Use:   "pods [name]",
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.RangeArgs(0, 1),
 	Short: "remove all pods using name",
-	Long:  "remove all pods using name from runpod.io",
+	Long:  "remove all pods using name, or all pods whose name starts with --name-prefix, from runpod.io",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if (len(args) == 1) == (namePrefix != "") {
+			return fmt.Errorf("requires either a name or --name-prefix")
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		mypods, err := api.GetPods()
-		cobra.CheckErr(err)
+		mypods, err := api.DefaultClient.GetPods(cmd.Context())
+		exit.CheckErr(err)
 
-		removed := 0
-		for _, pod := range mypods {
-			if pod.Name == args[0] && removed < podCount {
-				_, err := api.RemovePod(pod.Id)
-				if err == nil {
-					removed++
+		var targets []*api.Pod
+		if namePrefix != "" {
+			f := fleet.Filter{NamePrefix: namePrefix}
+			targets, err = f.Select(mypods, nil)
+			exit.CheckErr(err)
+		} else {
+			for _, pod := range mypods {
+				if pod.Name == args[0] && len(targets) < podCount {
+					targets = append(targets, pod)
 				}
-				cobra.CheckErr(err)
 			}
 		}
 
-		fmt.Printf(`%d pods removed with name "%s"`, removed, args[0])
+		err = fleet.Bulk(targets, removeParallel, "remove", func(p *api.Pod) (string, error) {
+			if err := api.DefaultClient.RemovePod(cmd.Context(), p.Id); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf(`pod "%s" removed`, p.Id), nil
+		})
+		if namePrefix != "" {
+			fmt.Printf(`%d pods matched name prefix "%s"`, len(targets), namePrefix)
+		} else {
+			fmt.Printf(`%d pods matched name "%s"`, len(targets), args[0])
+		}
 		fmt.Println()
+		exit.CheckErr(err)
 	},
 }
 
 func init() {
 	RemovePodsCmd.Flags().IntVar(&podCount, "podCount", 1, "number of pods to remove with the same name")
+	RemovePodsCmd.Flags().StringVar(&namePrefix, "name-prefix", "", "remove all pods whose name starts with this prefix; ignores --podCount")
+	RemovePodsCmd.Flags().IntVar(&removeParallel, "parallel", 10, "maximum number of pods to remove concurrently")
 }
@@ -0,0 +1,68 @@
+package portforward
+
+import (
+	"cli/api"
+	"cli/complete"
+	"cli/exit"
+	"cli/format"
+	"cli/tunnel"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	address   string
+	localPort int
+	output    string
+)
+
+var PortForwardCmd = &cobra.Command{
+	Use:               "port-forward [podId] [localPort:]remotePort...",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: complete.PodId,
+	Short:             "forward local ports to a pod",
+	Long:              "forward local ports to ports inside a pod over its public tcp ports, ssh or the runpod https proxy, until interrupted. Local port 0 picks a free port",
+	Run: func(cmd *cobra.Command, args []string) {
+		forwards := make([]*tunnel.Forward, len(args)-1)
+		for i, spec := range args[1:] {
+			f, err := tunnel.ParseForward(spec)
+			exit.CheckErr(err)
+			forwards[i] = f
+		}
+		if cmd.Flags().Changed("port") {
+			if len(forwards) != 1 {
+				exit.CheckErr(fmt.Errorf("--port needs exactly one remote port"))
+			}
+			forwards[0].Local = localPort
+		}
+		pod, err := api.DefaultClient.GetPod(cmd.Context(), args[0])
+		exit.CheckErr(err)
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+		err = tunnel.Run(ctx, pod, address, forwards, func() {
+			// with -o the chosen ports go to stdout for scripts and the rest to stderr
+			var w io.Writer = os.Stdout
+			if output != "" {
+				_, err := format.Print(os.Stdout, output, "", forwards)
+				exit.CheckErr(err)
+				w = os.Stderr
+			}
+			for _, f := range forwards {
+				fmt.Fprintf(w, "forwarding %s:%d -> %s:%d via %s\n", address, f.Local, pod.Id, f.Remote, f.Via)
+			}
+			fmt.Fprintln(w, "press Ctrl-C to stop")
+		})
+		exit.CheckErr(err)
+	},
+}
+
+func init() {
+	PortForwardCmd.Flags().StringVar(&address, "address", "127.0.0.1", "local address to listen on")
+	PortForwardCmd.Flags().IntVar(&localPort, "port", 0, "local port for a single remote port; 0 picks a free port")
+	PortForwardCmd.Flags().StringVarP(&output, "output", "o", "", "print the forwarded ports as json, yaml, jsonpath=<expression> or template=<go template> once listening")
+}
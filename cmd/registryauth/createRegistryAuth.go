@@ -0,0 +1,72 @@
+package registryauth
+
+import (
+	"cli/api"
+	"cli/exit"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var name string
+var passwordStdin bool
+var username string
+
+var CreateRegistryAuthCmd = &cobra.Command{
+	Use:   "registry-auth",
+	Args:  cobra.ExactArgs(0),
+	Short: "save container registry credentials",
+	Long: `save credentials for a private container registry (Docker Hub, GHCR or any other) so pods created
+with --registry-auth-id can pull private images. The password or access token is read from stdin
+with --password-stdin, or prompted for on a terminal, so it never lands in the shell history`,
+	Run: func(cmd *cobra.Command, args []string) {
+		password, err := readPassword()
+		exit.CheckErr(err)
+		auth, err := api.DefaultClient.CreateRegistryAuth(cmd.Context(), &api.CreateRegistryAuthInput{
+			Name:     name,
+			Username: username,
+			Password: password,
+		})
+		exit.CheckErr(err)
+		fmt.Printf(`registry auth "%s" created with id "%s"`, auth.Name, auth.Id)
+		fmt.Println()
+	},
+}
+
+func readPassword() (string, error) {
+	var password string
+	if passwordStdin {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		password = strings.TrimRight(string(b), "\r\n")
+	} else {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return "", fmt.Errorf("no terminal to ask for the password; use --password-stdin")
+		}
+		fmt.Fprint(os.Stderr, "password or access token: ")
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		password = string(b)
+	}
+	if password == "" {
+		return "", fmt.Errorf("empty password")
+	}
+	return password, nil
+}
+
+func init() {
+	CreateRegistryAuthCmd.Flags().StringVar(&name, "name", "", "credential name, e.g. ghcr-team")
+	CreateRegistryAuthCmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "read the password or access token from stdin")
+	CreateRegistryAuthCmd.Flags().StringVar(&username, "username", "", "registry username")
+	CreateRegistryAuthCmd.MarkFlagRequired("name")     //nolint
+	CreateRegistryAuthCmd.MarkFlagRequired("username") //nolint
+}
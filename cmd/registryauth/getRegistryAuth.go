@@ -0,0 +1,46 @@
+package registryauth
+
+import (
+	"cli/api"
+	"cli/exit"
+	"cli/format"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var output string
+var outputTemplate string
+
+var GetRegistryAuthCmd = &cobra.Command{
+	Use:     "registry-auth",
+	Aliases: []string{"registry-auths"},
+	Args:    cobra.ExactArgs(0),
+	Short:   "get container registry credentials",
+	Long:    "get my saved container registry credentials; passwords are never shown",
+	Run: func(cmd *cobra.Command, args []string) {
+		auths, err := api.DefaultClient.GetRegistryAuths(cmd.Context())
+		exit.CheckErr(format.Partial(err))
+		printed, err := format.Print(os.Stdout, output, outputTemplate, auths)
+		exit.CheckErr(err)
+		if printed {
+			return
+		}
+
+		data := make([][]string, len(auths))
+		for i, a := range auths {
+			data[i] = []string{a.Id, a.Name}
+		}
+		tb := tablewriter.NewWriter(os.Stdout)
+		tb.SetHeader([]string{"ID", "Name"})
+		tb.AppendBulk(data)
+		format.TableDefaults(tb)
+		tb.Render()
+	},
+}
+
+func init() {
+	GetRegistryAuthCmd.Flags().StringVarP(&output, "output", "o", "", "output format: json, yaml, jsonpath=<expression> or template=<go template>")
+	GetRegistryAuthCmd.Flags().StringVar(&outputTemplate, "template", "", "go template for the output; fields are named as in -o json, e.g. '{{.name}}'")
+}
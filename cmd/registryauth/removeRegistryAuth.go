@@ -0,0 +1,24 @@
+package registryauth
+
+import (
+	"cli/api"
+	"cli/exit"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var RemoveRegistryAuthCmd = &cobra.Command{
+	Use:   "registry-auth [registryAuthId]",
+	Args:  cobra.ExactArgs(1),
+	Short: "remove container registry credentials",
+	Long:  "remove saved container registry credentials by id or name",
+	Run: func(cmd *cobra.Command, args []string) {
+		auth, err := api.DefaultClient.GetRegistryAuth(cmd.Context(), args[0])
+		exit.CheckErr(err)
+		err = api.DefaultClient.DeleteRegistryAuth(cmd.Context(), auth.Id)
+		exit.CheckErr(err)
+		fmt.Printf(`registry auth "%s" removed`, auth.Id)
+		fmt.Println()
+	},
+}
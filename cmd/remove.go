@@ -3,6 +3,9 @@ package cmd
 import (
 	"cli/cmd/pod"
 	"cli/cmd/pods"
+	"cli/cmd/registryauth"
+	"cli/cmd/template"
+	"cli/cmd/volume"
 
 	"github.com/spf13/cobra"
 )
@@ -16,4 +19,7 @@ var removeCmd = &cobra.Command{
 func init() {
 	removeCmd.AddCommand(pod.RemovePodCmd)
 	removeCmd.AddCommand(pods.RemovePodsCmd)
+	removeCmd.AddCommand(registryauth.RemoveRegistryAuthCmd)
+	removeCmd.AddCommand(template.RemoveTemplateCmd)
+	removeCmd.AddCommand(volume.RemoveNetworkVolumeCmd)
 }
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"cli/cmd/pod"
+	"cli/cmd/track"
+
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report [command]",
+	Short: "report on past activity",
+	Long:  "aggregate statistics recorded by this machine's runpodctl",
+}
+
+func init() {
+	reportCmd.AddCommand(track.ReportAvailabilityCmd)
+	reportCmd.AddCommand(pod.ReportStartupCmd)
+}
@@ -1,45 +1,140 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"cli/api"
+	"cli/cmd/apply"
+	"cli/cmd/compare"
 	"cli/cmd/config"
+	"cli/cmd/console"
+	"cli/cmd/cp"
 	"cli/cmd/croc"
+	"cli/cmd/daemons"
+	"cli/cmd/dataset"
+	"cli/cmd/du"
+	"cli/cmd/env"
+	"cli/cmd/exec"
+	"cli/cmd/graph"
+	"cli/cmd/keepalive"
+	"cli/cmd/logs"
+	"cli/cmd/pod"
+	"cli/cmd/portforward"
+	"cli/cmd/schedule"
+	"cli/cmd/schema"
+	"cli/cmd/selftest"
+	"cli/cmd/speedtest"
+	"cli/cmd/ssh"
+	"cli/cmd/track"
+	"cli/cmd/transfers"
+	"cli/cmd/watchdog"
+	"cli/daemon"
+	"cli/exit"
+	"cli/format"
+	"cli/profile"
+	"cli/store"
+	"cli/warnings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var version string
+var profileName string
+var showStats bool
 
 // rootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
 	Use:   "runpodctl",
 	Short: "runpodctl for runpod.io",
 	Long:  "runpodctl is a CLI tool to manage your pods for runpod.io",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		format.Command = strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute(ver string) {
 	version = ver
-	err := RootCmd.Execute()
+	start := time.Now()
+	// registered first so it runs last, also when the command fails
+	exit.Defer(func() {
+		if showStats {
+			printStats(time.Since(start))
+		}
+	})
+	var err error
+	if daemon.IsService() {
+		err = daemon.RunService(RootCmd.ExecuteContext)
+	} else {
+		err = RootCmd.Execute()
+	}
 	if err != nil {
-		os.Exit(1)
+		exit.With(1)
 	}
+	exit.RunHooks()
+}
+
+// printWarnings has the pod warnings printed once the command ends, also
+// when it fails. Commands with their own PersistentPreRun skip the root's,
+// so it is run here.
+func printWarnings(cmd *cobra.Command, args []string) {
+	RootCmd.PersistentPreRun(cmd, args)
+	ctx := cmd.Context()
+	exit.Defer(func() { warnings.Print(ctx, os.Stderr) })
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
+	RootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "config profile to use; defaults to RUNPOD_PROFILE or the one set by config use-profile")
+	RootCmd.PersistentFlags().BoolVar(&profile.CrossProfile, "cross-profile", false, "act on pods, schedules and datasets recorded under another profile")
+	RootCmd.PersistentFlags().BoolVar(&format.Bare, "bare", false, "print -o json without the schemaVersion envelope")
+	RootCmd.PersistentFlags().BoolVar(&showStats, "stats", false, "print timing and api call summary after the command")
+
+	RootCmd.AddCommand(analyzeCmd)
+	RootCmd.AddCommand(apply.ApplyCmd)
+	RootCmd.AddCommand(chaosCmd)
+	RootCmd.AddCommand(compare.CompareCmd)
 	RootCmd.AddCommand(config.ConfigCmd)
+	RootCmd.AddCommand(console.ConsoleCmd)
 	// RootCmd.AddCommand(connectCmd)
 	// RootCmd.AddCommand(copyCmd)
+	RootCmd.AddCommand(cp.CpCmd)
 	RootCmd.AddCommand(createCmd)
+	RootCmd.AddCommand(daemons.DaemonsCmd)
+	RootCmd.AddCommand(dataset.DatasetCmd)
+	RootCmd.AddCommand(doctorCmd)
+	RootCmd.AddCommand(du.DuCmd)
+	RootCmd.AddCommand(env.EnvCmd)
+	RootCmd.AddCommand(exec.ExecCmd)
 	RootCmd.AddCommand(getCmd)
+	RootCmd.AddCommand(graph.GraphCmd)
+	RootCmd.AddCommand(keepalive.KeepaliveCmd)
+	RootCmd.AddCommand(logs.LogsCmd)
+	RootCmd.AddCommand(migrateCmd)
+	RootCmd.AddCommand(portforward.PortForwardCmd)
 	RootCmd.AddCommand(removeCmd)
+	RootCmd.AddCommand(reportCmd)
+	RootCmd.AddCommand(schedule.ScheduleCmd)
+	RootCmd.AddCommand(schema.SchemaCmd)
+	RootCmd.AddCommand(selftest.SelftestCmd)
+	RootCmd.AddCommand(sloCmd)
+	RootCmd.AddCommand(speedtest.SpeedtestCmd)
+	RootCmd.AddCommand(ssh.SshCmd)
 	RootCmd.AddCommand(startCmd)
 	RootCmd.AddCommand(stopCmd)
+	RootCmd.AddCommand(testCmd)
+	RootCmd.AddCommand(topCmd)
+	RootCmd.AddCommand(track.TrackCmd)
+	RootCmd.AddCommand(transfers.TransfersCmd)
+	RootCmd.AddCommand(pod.UiCmd)
+	RootCmd.AddCommand(updateCmd)
 	RootCmd.AddCommand(versionCmd)
+	RootCmd.AddCommand(watchdog.WatchdogCmd)
 
 	RootCmd.AddCommand(croc.ReceiveCmd)
 	RootCmd.AddCommand(croc.SendCmd)
@@ -48,7 +143,7 @@ func init() {
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
 	home, err := os.UserHomeDir()
-	cobra.CheckErr(err)
+	exit.CheckErr(err)
 
 	viper.AddConfigPath(home)
 	viper.SetConfigType("yaml")
@@ -57,11 +152,22 @@ func initConfig() {
 
 	viper.AutomaticEnv() // read in environment variables that match
 
-	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
-		// fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
-	} else {
-		err := viper.WriteConfigAs(config.ConfigFile)
-		cobra.CheckErr(err)
-	}
+	exit.CheckErr(store.ReadConfig(config.ConfigFile))
+	exit.CheckErr(profile.Select(profileName))
+}
+
+// printStats writes the api call summary to stderr so it never mixes with command output.
+func printStats(elapsed time.Duration) {
+	s := api.GetStats()
+	fmt.Fprintf(
+		os.Stderr,
+		"stats: %d api calls, %d bytes sent, %d bytes received, %d cache hits, %d retries, %s api latency, %s total\n",
+		s.Calls,
+		s.BytesSent,
+		s.BytesReceived,
+		s.CacheHits,
+		s.Retries,
+		s.Latency.Round(time.Millisecond),
+		elapsed.Round(time.Millisecond),
+	)
 }
@@ -0,0 +1,252 @@
+package schedule
+
+import (
+	"cli/api"
+	"cli/complete"
+	"cli/daemon"
+	"cli/exit"
+	"cli/format"
+	"cli/history"
+	"cli/profile"
+	"cli/schedule"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var daemonName string
+var dryRun bool
+var podId string
+var start string
+var stop string
+var timezone string
+
+var ScheduleCmd = &cobra.Command{
+	Use:   "schedule [command]",
+	Short: "start and stop pods on a schedule",
+	Long: `start and stop pods at times given as cron expressions (minute hour day-of-month month day-of-week),
+so dev pods only run during working hours; schedule run executes them`,
+}
+
+var addCmd = &cobra.Command{
+	Use:   "add",
+	Args:  cobra.ExactArgs(0),
+	Short: "add or replace a pod's schedule",
+	Long:  `add or replace a pod's schedule, e.g. --start "0 8 * * 1-5" --stop "0 20 * * 1-5" for weekdays 8:00 to 20:00`,
+	Run: func(cmd *cobra.Command, args []string) {
+		s := &schedule.Schedule{PodId: podId, Start: start, Stop: stop, Timezone: timezone}
+		exit.CheckErr(schedule.Add(s))
+		if other, err := daemon.Manager(s.PodId); err == nil && other != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s daemon \"%s\" manages the pod; the scheduler leaves it alone until it is stopped\n", other.Kind, other.Name)
+		}
+		nextStart, nextStop := next(s, time.Now())
+		fmt.Printf(`schedule for pod "%s" saved; next start %s, next stop %s`+"\n", s.PodId, nextStart, nextStop)
+	},
+}
+
+var listCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Args:    cobra.ExactArgs(0),
+	Short:   "list schedules",
+	Long:    "list pod schedules with their next start and stop",
+	Run: func(cmd *cobra.Command, args []string) {
+		schedules := schedule.List()
+		data := make([][]string, len(schedules))
+		for i, s := range schedules {
+			zone := s.Timezone
+			if zone == "" {
+				zone = "local"
+			}
+			nextStart, nextStop := next(s, time.Now())
+			data[i] = []string{s.PodId, s.Start, s.Stop, zone, nextStart, nextStop, s.Profile}
+		}
+		tb := tablewriter.NewWriter(os.Stdout)
+		tb.SetHeader([]string{"Pod", "Start", "Stop", "Timezone", "Next Start", "Next Stop", "Profile"})
+		tb.AppendBulk(data)
+		format.TableDefaults(tb)
+		tb.Render()
+	},
+}
+
+var removeCmd = &cobra.Command{
+	Use:  "remove [podId]",
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var ids []string
+		for _, s := range schedule.List() {
+			ids = append(ids, s.PodId)
+		}
+		return ids, cobra.ShellCompDirectiveNoFileComp
+	},
+	Short: "remove a pod's schedule",
+	Long:  "remove a pod's schedule; the pod itself is not touched",
+	Run: func(cmd *cobra.Command, args []string) {
+		exit.CheckErr(schedule.Remove(args[0]))
+		fmt.Printf(`schedule for pod "%s" removed`+"\n", args[0])
+	},
+}
+
+var runCmd = &cobra.Command{
+	Use:         "run",
+	Annotations: map[string]string{"daemon": "true"},
+	Args:        cobra.ExactArgs(0),
+	Short:       "execute the schedules",
+	Long: `start and stop pods as their schedules say, until interrupted. Schedules are read from the
+config every minute, so ones added or removed while it runs take effect. Missed times are not caught up.
+Only the schedules added under the scheduler's profile run, unless --cross-profile is given`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var pods []string
+		for _, s := range schedule.List() {
+			if s.Check() == nil {
+				pods = append(pods, s.PodId)
+			}
+		}
+		d, err := daemon.Register("schedule", daemonName, pods)
+		exit.CheckErr(err)
+		defer d.Release() //nolint
+
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer cancel()
+		logf("running %d schedules of profile %s", len(pods), profile.Name())
+		minute := time.Now().Truncate(time.Minute)
+		for {
+			minute = minute.Add(time.Minute)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Until(minute)):
+			}
+			if err := viper.ReadInConfig(); err != nil {
+				logf("could not read the config: %s", err)
+			}
+			for _, s := range schedule.List() {
+				tick(ctx, s, minute)
+			}
+		}
+	},
+}
+
+func init() {
+	addCmd.Flags().StringVar(&podId, "pod", "", "pod id")
+	addCmd.Flags().StringVar(&start, "start", "", "cron expression for when to start the pod")
+	addCmd.Flags().StringVar(&stop, "stop", "", "cron expression for when to stop the pod")
+	addCmd.Flags().StringVar(&timezone, "timezone", "", "IANA timezone the expressions are in, e.g. Europe/Berlin (default the scheduler's local time)")
+	addCmd.MarkFlagRequired("pod")                           //nolint
+	addCmd.RegisterFlagCompletionFunc("pod", complete.PodId) //nolint
+
+	runCmd.Flags().StringVar(&daemonName, "name", "schedule", "daemon name for runpodctl daemons")
+	runCmd.Flags().BoolVar(&dryRun, "dry-run", false, "only log the pods that would be started or stopped")
+
+	ScheduleCmd.AddCommand(addCmd)
+	ScheduleCmd.AddCommand(listCmd)
+	ScheduleCmd.AddCommand(removeCmd)
+	ScheduleCmd.AddCommand(runCmd)
+}
+
+// tick starts or stops the pod when minute is one of its schedule's times.
+// When both fall on the same minute, the stop wins.
+func tick(ctx context.Context, s *schedule.Schedule, minute time.Time) {
+	if s.Check() != nil {
+		return
+	}
+	startCron, stopCron, err := s.Crons()
+	if err != nil {
+		logf("schedule of pod %s: %s", s.PodId, err)
+		return
+	}
+	loc, err := s.Location()
+	if err != nil {
+		logf("schedule of pod %s: %s", s.PodId, err)
+		return
+	}
+	t := minute.In(loc)
+	switch {
+	case stopCron != nil && stopCron.Matches(t):
+		act(ctx, s.PodId, "stop")
+	case startCron != nil && startCron.Matches(t):
+		act(ctx, s.PodId, "start")
+	}
+}
+
+// act starts or stops a pod unless it already is, and records it in the
+// local history.
+func act(ctx context.Context, id string, action string) {
+	pod, err := api.DefaultClient.GetPod(ctx, id)
+	if err != nil {
+		logf("%s pod %s failed: %s", action, id, err)
+		return
+	}
+	running := pod.DesiredStatus == "RUNNING"
+	switch {
+	case action == "start" && running, action == "stop" && !running:
+		logf("pod %s (%s) is already %s", id, pod.Name, pod.DesiredStatus)
+		return
+	case action == "start" && pod.PodType == "INTERRUPTABLE":
+		logf("pod %s (%s) is a spot pod; scheduled starts only resume on-demand pods", id, pod.Name)
+		return
+	case dryRun:
+		logf("would %s pod %s (%s)", action, id, pod.Name)
+		return
+	}
+	// a schedule added after the scheduler started may be for a pod that a
+	// keepalive resumes whenever it stops
+	other, err := daemon.Manager(id)
+	if err != nil {
+		logf("%s pod %s failed: %s", action, id, err)
+		return
+	}
+	if other != nil {
+		logf("leaving pod %s (%s) alone: %s daemon %q manages it", id, pod.Name, other.Kind, other.Name)
+		return
+	}
+	name, done := pod.Name, "stopped"
+	if action == "start" {
+		done = "started"
+		pod, err = api.DefaultClient.StartOnDemandPod(ctx, id)
+	} else {
+		pod, err = api.DefaultClient.StopPod(ctx, id)
+	}
+	if err != nil {
+		logf("%s pod %s failed: %s", action, id, err)
+		return
+	}
+	logf("pod %s (%s) %s; status is %s", id, name, done, pod.DesiredStatus)
+	err = history.Append(&history.Entry{Action: "schedule " + action, PodId: id, Name: name, Status: pod.DesiredStatus})
+	if err != nil {
+		logf("could not record history: %s", err)
+	}
+}
+
+// next formats the schedule's next start and stop after t, in its timezone.
+func next(s *schedule.Schedule, t time.Time) (string, string) {
+	startCron, stopCron, err := s.Crons()
+	loc, locErr := s.Location()
+	if err != nil || locErr != nil {
+		return "invalid", "invalid"
+	}
+	when := func(c *schedule.Cron) string {
+		if c == nil {
+			return "-"
+		}
+		n := c.Next(t.In(loc))
+		if n.IsZero() {
+			return "never"
+		}
+		return n.Format("Mon 2006-01-02 15:04 MST")
+	}
+	return when(startCron), when(stopCron)
+}
+
+func logf(format string, args ...interface{}) {
+	fmt.Printf("%s %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
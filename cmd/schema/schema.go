@@ -0,0 +1,37 @@
+package schema
+
+import (
+	"cli/exit"
+	"cli/manifest"
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var SchemaCmd = &cobra.Command{
+	Use:   "schema [command]",
+	Short: "manifest json schemas",
+	Long:  "print the json schemas of manifests, e.g. for editor autocomplete with yaml-language-server",
+}
+
+var printCmd = &cobra.Command{
+	Use:       "print [kind]",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: append(manifest.Kinds(), manifest.KindAll),
+	Short:     "print the json schema of a manifest kind",
+	Long: `print the json schema of a manifest kind (pod, service or template), or of any manifest with "manifest".
+Point yaml-language-server at it with a first line such as
+  # yaml-language-server: $schema=./pod.schema.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := manifest.SchemaFor(args[0])
+		exit.CheckErr(err)
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		exit.CheckErr(enc.Encode(s))
+	},
+}
+
+func init() {
+	SchemaCmd.AddCommand(printCmd)
+}
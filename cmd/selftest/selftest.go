@@ -0,0 +1,280 @@
+package selftest
+
+import (
+	"bytes"
+	"cli/api"
+	"cli/complete"
+	"cli/doctor"
+	"cli/exit"
+	"cli/format"
+	"cli/remote"
+	"cli/watch"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// marker is echoed in the pod to prove exec works end to end.
+const marker = "runpodctl-selftest-ok"
+
+var gpuTypeId string
+var imageName string
+var live bool
+var maxPrice float64
+var secureCloud bool
+var timeout time.Duration
+
+var SelftestCmd = &cobra.Command{
+	Use:    "selftest",
+	Args:   cobra.ExactArgs(0),
+	Hidden: true,
+	Short:  "check that runpodctl works against the api",
+	Long: `check the api key, its permissions and gpu availability. With --live, also run a pod through
+create, wait, exec, logs, stop and terminate on the cheapest gpu. The pod is terminated whatever
+happens, and spend is capped: no gpu above --max-price $/hr is rented and everything must finish
+within --timeout`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		t := &tester{}
+		err := t.step("api key", func() (string, error) {
+			pods, err := api.DefaultClient.GetPods(ctx)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d pods visible", len(pods)), nil
+		})
+		var gpu *api.LowestPrice
+		if err == nil {
+			err = t.step("gpu", func() (string, error) {
+				var err error
+				gpu, err = cheapestGpu(ctx)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%s for $%.3f / hr", gpu.GpuTypeId, gpu.UninterruptablePrice), nil
+			})
+		}
+		if err == nil && live {
+			fmt.Fprintf(os.Stderr, "running a live pod; spend is capped at $%.3f\n", maxPrice*timeout.Hours())
+			t.live(ctx, gpu)
+		}
+
+		t.render()
+		if t.failed > 0 {
+			exit.CheckErr(fmt.Errorf("%d of %d steps failed", t.failed, len(t.checks)))
+		}
+		if !live {
+			fmt.Println("pass --live to also create, exec in and terminate a pod")
+		}
+	},
+}
+
+func init() {
+	SelftestCmd.Flags().StringVar(&gpuTypeId, "gpuType", "", "gpu type id to use instead of the cheapest available")
+	SelftestCmd.RegisterFlagCompletionFunc("gpuType", complete.GpuTypes) //nolint
+	SelftestCmd.Flags().StringVar(&imageName, "imageName", "runpod/base:0.4.0-cuda11.8.0", "container image of the live pod; it must run sshd")
+	SelftestCmd.Flags().BoolVar(&live, "live", false, "run a real pod through its whole lifecycle; costs a few cents")
+	SelftestCmd.Flags().Float64Var(&maxPrice, "max-price", 0.5, "highest $/hr the live pod may cost")
+	SelftestCmd.Flags().BoolVar(&secureCloud, "secureCloud", false, "run the live pod in secure cloud")
+	SelftestCmd.Flags().DurationVar(&timeout, "timeout", 15*time.Minute, "time allowed for the live run before the pod is terminated")
+}
+
+// tester runs the steps in order and records their results.
+type tester struct {
+	checks []*doctor.Check
+	failed int
+}
+
+// step runs fn, records its result and returns its error. Progress goes to
+// stderr so the table on stdout stays clean.
+func (t *tester) step(name string, fn func() (string, error)) error {
+	start := time.Now()
+	detail, err := fn()
+	c := &doctor.Check{Name: name, Status: doctor.Ok, Detail: detail}
+	if err != nil {
+		c.Status = doctor.Fail
+		c.Detail = err.Error()
+		t.failed++
+	}
+	c.Detail = fmt.Sprintf("%s (%s)", c.Detail, time.Since(start).Round(100*time.Millisecond))
+	t.checks = append(t.checks, c)
+	fmt.Fprintf(os.Stderr, "%s: %s\n", name, c.Status)
+	return err
+}
+
+// live creates a pod and walks it through its lifecycle. The pod is
+// terminated on every path out, including failures and interrupts.
+func (t *tester) live(ctx context.Context, gpu *api.LowestPrice) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var pod *api.Pod
+	var created time.Time
+	defer func() {
+		if pod == nil {
+			return
+		}
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := api.DefaultClient.RemovePod(cleanupCtx, pod.Id); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to remove pod %s, remove it with runpodctl remove pod %s: %s\n", pod.Id, pod.Id, err)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "pod %s removed\n", pod.Id)
+	}()
+
+	err := t.step("create", func() (string, error) {
+		input := &api.CreatePodInput{
+			CloudType:         "COMMUNITY",
+			ContainerDiskInGb: 5,
+			DeployCost:        float32(maxPrice),
+			GpuCount:          1,
+			GpuTypeId:         gpu.GpuTypeId,
+			ImageName:         imageName,
+			Name:              "runpodctl-selftest",
+			Ports:             "22/tcp",
+		}
+		if secureCloud {
+			input.CloudType = "SECURE"
+		}
+		var err error
+		pod, err = api.DefaultClient.CreatePod(ctx, input)
+		if err != nil {
+			return "", err
+		}
+		created = time.Now()
+		if pod.CostPerHr > float32(maxPrice) {
+			return "", fmt.Errorf("pod %s costs $%.3f / hr, above --max-price", pod.Id, pod.CostPerHr)
+		}
+		return fmt.Sprintf("pod %s for $%.3f / hr", pod.Id, pod.CostPerHr), nil
+	})
+	if err == nil {
+		err = t.step("wait", func() (string, error) {
+			running, err := watch.Running(ctx, pod.Id)
+			if err != nil {
+				return "", err
+			}
+			pod = running
+			return "running", nil
+		})
+	}
+	if err == nil {
+		err = t.step("exec", func() (string, error) {
+			return execMarker(ctx, pod)
+		})
+	}
+	if err == nil {
+		err = t.step("logs", func() (string, error) {
+			lines, err := api.DefaultClient.GetPodLogs(ctx, &api.PodLogsInput{PodId: pod.Id, Type: api.LogTypeSystem, Tail: 20})
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d system log lines", len(lines)), nil
+		})
+	}
+	if err == nil {
+		err = t.step("stop", func() (string, error) {
+			stopped, err := api.DefaultClient.StopPod(ctx, pod.Id)
+			if err != nil {
+				return "", err
+			}
+			if stopped.DesiredStatus != "EXITED" {
+				return "", fmt.Errorf("status is %s", stopped.DesiredStatus)
+			}
+			return "exited", nil
+		})
+	}
+	if err == nil {
+		t.step("terminate", func() (string, error) { //nolint
+			if err := api.DefaultClient.RemovePod(ctx, pod.Id); err != nil {
+				return "", err
+			}
+			cost := float64(pod.CostPerHr) * time.Since(created).Hours()
+			pod = nil
+			return fmt.Sprintf("removed; spent about $%.3f", cost), nil
+		})
+	}
+}
+
+// execMarker echoes the marker over ssh, retrying while sshd is not up yet.
+func execMarker(ctx context.Context, pod *api.Pod) (string, error) {
+	target, err := remote.Resolve(pod)
+	if err != nil {
+		return "", err
+	}
+	for {
+		var out bytes.Buffer
+		err := target.Run(ctx, "echo "+marker, nil, &out, &out)
+		var exitErr *exec.ExitError
+		if err == nil || !errors.As(err, &exitErr) || exitErr.ExitCode() != 255 {
+			if err == nil && !strings.Contains(out.String(), marker) {
+				err = fmt.Errorf("unexpected output: %s", strings.TrimSpace(out.String()))
+			}
+			if err != nil {
+				return "", err
+			}
+			return "ssh works", nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("ssh not reachable: %s", strings.TrimSpace(out.String()))
+		case <-time.After(watch.PollInterval):
+		}
+	}
+}
+
+// cheapestGpu picks --gpuType, or else the cheapest available gpu type, and
+// fails when it costs more than --max-price.
+func cheapestGpu(ctx context.Context) (*api.LowestPrice, error) {
+	gpus, err := api.DefaultClient.GetCloud(ctx, &api.GetCloudInput{GpuCount: 1, SecureCloud: &secureCloud})
+	if err != nil {
+		return nil, err
+	}
+	var best *api.LowestPrice
+	for _, gpu := range gpus {
+		kv := gpu.LowestPrice
+		if kv == nil || kv.MinMemory == 0 || kv.UninterruptablePrice <= 0 {
+			continue
+		}
+		if gpuTypeId != "" && kv.GpuTypeId != gpuTypeId {
+			continue
+		}
+		if best == nil || kv.UninterruptablePrice < best.UninterruptablePrice {
+			best = kv
+		}
+	}
+	if best == nil {
+		if gpuTypeId != "" {
+			return nil, fmt.Errorf("%s is not available", gpuTypeId)
+		}
+		return nil, fmt.Errorf("no gpu is available")
+	}
+	if best.UninterruptablePrice > maxPrice {
+		return nil, fmt.Errorf("%s costs $%.3f / hr, above --max-price $%.3f", best.GpuTypeId, best.UninterruptablePrice, maxPrice)
+	}
+	return best, nil
+}
+
+func (t *tester) render() {
+	data := make([][]string, len(t.checks))
+	for i, c := range t.checks {
+		data[i] = []string{c.Name, c.Status, c.Detail}
+	}
+	header := []string{"Step", "Status", "Detail"}
+	format.Highlight(header, data)
+	tb := tablewriter.NewWriter(os.Stdout)
+	tb.SetHeader(header)
+	format.TableDefaults(tb)
+	tb.AppendBulk(data)
+	tb.Render()
+}
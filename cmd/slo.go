@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"cli/cmd/endpoint"
+
+	"github.com/spf13/cobra"
+)
+
+var sloCmd = &cobra.Command{
+	Use:   "slo [command]",
+	Short: "service level objectives",
+	Long:  "evaluate service level objectives of resources in runpod.io",
+}
+
+var sloCheckCmd = &cobra.Command{
+	Use:   "check [command]",
+	Short: "check a resource against slo thresholds",
+	Long:  "check a resource against slo thresholds and exit non-zero on breach",
+}
+
+func init() {
+	sloCmd.AddCommand(sloCheckCmd)
+	sloCheckCmd.AddCommand(endpoint.SloCheckEndpointCmd)
+}
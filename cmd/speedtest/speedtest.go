@@ -0,0 +1,177 @@
+package speedtest
+
+import (
+	"cli/api"
+	"cli/exit"
+	"cli/format"
+	"cli/speedtest"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var dataCenters string
+var duration time.Duration
+var output string
+var uploadPath string
+
+// result is the speedtest of one datacenter.
+type result struct {
+	DataCenterId string  `json:"dataCenterId"`
+	Location     string  `json:"location"`
+	LatencyMs    float64 `json:"latencyMs,omitempty"`
+	DownloadMbps float64 `json:"downloadMbps,omitempty"`
+	UploadMbps   float64 `json:"uploadMbps,omitempty"`
+	// UploadSeconds is how long uploading --path would take.
+	UploadSeconds float64 `json:"uploadSeconds,omitempty"`
+	Error         string  `json:"error,omitempty"`
+}
+
+var SpeedtestCmd = &cobra.Command{
+	Use:   "speedtest",
+	Args:  cobra.ExactArgs(0),
+	Short: "measure the network to each datacenter",
+	Long: `measure latency, download and upload throughput from this machine to each datacenter, one at a time,
+to pick where to place pods. With --path, also estimate how long uploading that file or folder would take`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var size int64
+		if uploadPath != "" {
+			var err error
+			size, err = pathSize(uploadPath)
+			exit.CheckErr(err)
+		}
+		all, err := api.DefaultClient.GetDataCenterEndpoints(cmd.Context())
+		exit.CheckErr(err)
+		targets, err := selectDataCenters(all)
+		exit.CheckErr(err)
+
+		results := make([]*result, len(targets))
+		for i, dc := range targets {
+			fmt.Fprintf(os.Stderr, "testing %s (%d of %d)\n", dc.Id, i+1, len(targets))
+			r := &result{DataCenterId: dc.Id, Location: dc.Location}
+			results[i] = r
+			m, err := speedtest.Measure(cmd.Context(), dc.SpeedtestUrl, duration)
+			if err != nil {
+				r.Error = err.Error()
+				continue
+			}
+			r.LatencyMs, r.DownloadMbps, r.UploadMbps = m.LatencyMs, m.DownloadMbps, m.UploadMbps
+			if size > 0 && m.UploadMbps > 0 {
+				r.UploadSeconds = float64(size) * 8 / (m.UploadMbps * 1e6)
+			}
+		}
+		// closest first; the ones that failed last
+		sort.SliceStable(results, func(i, j int) bool {
+			if (results[i].Error == "") != (results[j].Error == "") {
+				return results[i].Error == ""
+			}
+			return results[i].LatencyMs < results[j].LatencyMs
+		})
+
+		printed, err := format.Print(os.Stdout, output, "", results)
+		exit.CheckErr(err)
+		if printed {
+			return
+		}
+		render(results, size)
+	},
+}
+
+// selectDataCenters returns the datacenters named by --datacenter that have
+// a speedtest endpoint.
+func selectDataCenters(all []*api.DataCenter) ([]*api.DataCenter, error) {
+	byId := make(map[string]*api.DataCenter, len(all))
+	var targets []*api.DataCenter
+	for _, dc := range all {
+		byId[strings.ToUpper(dc.Id)] = dc
+		if dc.SpeedtestUrl != "" {
+			targets = append(targets, dc)
+		}
+	}
+	if dataCenters != "all" {
+		targets = nil
+		for _, id := range strings.Split(dataCenters, ",") {
+			dc, ok := byId[strings.ToUpper(strings.TrimSpace(id))]
+			if !ok {
+				return nil, fmt.Errorf("unknown datacenter %q", strings.TrimSpace(id))
+			}
+			if dc.SpeedtestUrl == "" {
+				return nil, fmt.Errorf("datacenter %s has no speedtest endpoint", dc.Id)
+			}
+			targets = append(targets, dc)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no datacenter has a speedtest endpoint")
+	}
+	return targets, nil
+}
+
+func render(results []*result, size int64) {
+	header := []string{"Datacenter", "Location", "Latency ms", "Download Mbit/s", "Upload Mbit/s"}
+	if size > 0 {
+		header = append(header, "Upload "+format.Bytes(size))
+	}
+	data := make([][]string, len(results))
+	var failed []*result
+	for i, r := range results {
+		row := []string{r.DataCenterId, r.Location, "-", "-", "-"}
+		if r.Error == "" {
+			row = []string{
+				r.DataCenterId,
+				r.Location,
+				fmt.Sprintf("%.0f", r.LatencyMs),
+				fmt.Sprintf("%.1f", r.DownloadMbps),
+				fmt.Sprintf("%.1f", r.UploadMbps),
+			}
+		} else {
+			failed = append(failed, r)
+		}
+		if size > 0 {
+			eta := "-"
+			if d := time.Duration(r.UploadSeconds * float64(time.Second)); d >= time.Second {
+				eta = d.Round(time.Second).String()
+			} else if r.UploadSeconds > 0 {
+				eta = "< 1s"
+			}
+			row = append(row, eta)
+		}
+		data[i] = row
+	}
+	tb := tablewriter.NewWriter(os.Stdout)
+	tb.SetHeader(header)
+	tb.AppendBulk(data)
+	format.TableDefaults(tb)
+	tb.Render()
+	for _, r := range failed {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", r.DataCenterId, r.Error)
+	}
+}
+
+// pathSize is the size of a file, or of the files in a folder.
+func pathSize(root string) (int64, error) {
+	var size int64
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+func init() {
+	SpeedtestCmd.Flags().StringVar(&dataCenters, "datacenter", "all", "datacenter ids to test, e.g. EU-RO-1,US-TX-3, or all")
+	SpeedtestCmd.Flags().DurationVar(&duration, "duration", 3*time.Second, "how long to measure download and upload to each datacenter")
+	SpeedtestCmd.Flags().StringVar(&uploadPath, "path", "", "file or folder to estimate the upload time of")
+	SpeedtestCmd.Flags().StringVarP(&output, "output", "o", "", "output format: json, yaml, jsonpath=<expression> or template=<go template>")
+}
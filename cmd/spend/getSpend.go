@@ -0,0 +1,121 @@
+package spend
+
+import (
+	"bytes"
+	"cli/billing"
+	"cli/exit"
+	"cli/format"
+	"cli/sink"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var granularity string
+var output string
+var outputTemplate string
+var since string
+var sinks []string
+
+var GetSpendCmd = &cobra.Command{
+	Use:   "spend",
+	Args:  cobra.ExactArgs(0),
+	Short: "get account spend",
+	Long:  "get the hourly burn rate across running pods, each pod's cost since it started and, with --since, the account's charges over a window",
+	Run: func(cmd *cobra.Command, args []string) {
+		var start time.Time
+		if since != "" {
+			var err error
+			start, err = billing.ParseSince(since)
+			exit.CheckErr(err)
+		}
+		if granularity != "DAILY" && granularity != "HOURLY" {
+			exit.CheckErr(fmt.Errorf("invalid --granularity %q: use DAILY or HOURLY", granularity))
+		}
+		report, err := billing.Build(cmd.Context(), start, granularity)
+		exit.CheckErr(err)
+
+		if len(sinks) > 0 && output == "" && outputTemplate == "" {
+			output = "json"
+		}
+		var w io.Writer = os.Stdout
+		var buf bytes.Buffer
+		if len(sinks) > 0 {
+			w = &buf
+		}
+		exit.CheckErr(render(w, report))
+		if len(sinks) > 0 {
+			exit.CheckErr(sink.Deliver(cmd.Context(), sinks, sink.ContentType(output), buf.Bytes()))
+		}
+	},
+}
+
+// render prints the report in the -o format, or as tables.
+func render(w io.Writer, report *billing.Report) error {
+	printed, err := format.Print(w, output, outputTemplate, report)
+	if printed || err != nil {
+		return err
+	}
+
+	data := make([][]string, len(report.Pods))
+	running := 0
+	for i, p := range report.Pods {
+		uptime, spent := "", ""
+		if p.Status == "RUNNING" {
+			running++
+			uptime = format.Duration(time.Duration(p.UptimeInSeconds) * time.Second)
+			spent = fmt.Sprintf("%.2f", p.SpentSinceStart)
+		}
+		data[i] = []string{p.Id, p.Name, p.Status, fmt.Sprintf("%.3f", p.CostPerHr), uptime, spent}
+	}
+	header := []string{"ID", "Name", "Status", "$/hr", "Uptime", "Spent $"}
+	format.Highlight(header, data)
+	tb := tablewriter.NewWriter(w)
+	tb.SetHeader(header)
+	tb.AppendBulk(data)
+	format.TableDefaults(tb)
+	tb.Render()
+
+	fmt.Fprintf(w, "burn rate: $%.3f / hr across %d running pods\n", report.BurnRatePerHr, running)
+	if b := report.Balance; b != nil {
+		fmt.Fprintf(w, "balance: $%.2f, account spend $%.3f / hr", b.ClientBalance, b.CurrentSpendPerHr)
+		if b.CurrentSpendPerHr > 0 {
+			fmt.Fprintf(w, ", about %s left", format.Duration(time.Duration(b.ClientBalance/b.CurrentSpendPerHr*float64(time.Hour))))
+		}
+		fmt.Fprintln(w)
+	}
+	if report.Since == "" {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	data = make([][]string, len(report.History))
+	for i, h := range report.History {
+		data[i] = []string{
+			h.Time,
+			fmt.Sprintf("%.2f", h.GpuCloudAmount),
+			fmt.Sprintf("%.2f", h.ServerlessAmount),
+			fmt.Sprintf("%.2f", h.StorageAmount),
+			fmt.Sprintf("%.2f", h.Total()),
+		}
+	}
+	tb = tablewriter.NewWriter(w)
+	tb.SetHeader([]string{"Time", "Gpu Cloud $", "Serverless $", "Storage $", "Total $"})
+	tb.AppendBulk(data)
+	format.TableDefaults(tb)
+	tb.Render()
+	fmt.Fprintf(w, "total since %s: $%.2f\n", report.Since, report.HistoryTotal)
+	return nil
+}
+
+func init() {
+	GetSpendCmd.Flags().StringVar(&granularity, "granularity", "DAILY", "history period: DAILY or HOURLY")
+	GetSpendCmd.Flags().StringVarP(&output, "output", "o", "", "output format: json, yaml, jsonpath=<expression> or template=<go template>")
+	GetSpendCmd.Flags().StringVar(&outputTemplate, "template", "", "go template for the output; fields are named as in -o json, e.g. '{{.burnRatePerHr}}'")
+	GetSpendCmd.Flags().StringArrayVar(&sinks, "sink", nil, "also deliver the report (json unless -o is given) to a file, an http(s) webhook or s3://bucket/key instead of printing it; {date} and {time} expand")
+	GetSpendCmd.Flags().StringVar(&since, "since", "", "also show charges since a duration ago (e.g. 7d, 12h) or an RFC3339 time")
+}
@@ -0,0 +1,120 @@
+package ssh
+
+import (
+	"cli/api"
+	"cli/exit"
+	"cli/format"
+	"cli/sshkey"
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var keyFile string
+var keyText string
+var output string
+
+var SshCmd = &cobra.Command{
+	Use:   "ssh [command]",
+	Short: "manage ssh keys",
+	Long:  "manage the ssh public keys of your account; new pods authorize them for ssh",
+}
+
+var addKeyCmd = &cobra.Command{
+	Use:   "add-key",
+	Args:  cobra.ExactArgs(0),
+	Short: "add an ssh public key",
+	Long:  "add an ssh public key to your account, from --key, --key-file or the first of ~/.ssh/id_ed25519.pub, id_ecdsa.pub and id_rsa.pub",
+	Run: func(cmd *cobra.Command, args []string) {
+		var key *sshkey.Key
+		var err error
+		switch {
+		case keyText != "":
+			key, err = sshkey.Parse(keyText)
+		case keyFile != "":
+			key, err = sshkey.ReadFile(keyFile)
+		default:
+			key, err = sshkey.Default()
+		}
+		exit.CheckErr(err)
+
+		current, err := api.DefaultClient.GetPublicKeys(cmd.Context())
+		exit.CheckErr(err)
+		keys := sshkey.ParseList(current)
+		for _, k := range keys {
+			if k.Fingerprint == key.Fingerprint {
+				fmt.Printf(`key "%s" is already added`, key.Fingerprint)
+				fmt.Println()
+				return
+			}
+		}
+		exit.CheckErr(api.DefaultClient.UpdatePublicKeys(cmd.Context(), sshkey.Append(current, key)))
+		fmt.Printf(`key "%s" added`, key.Fingerprint)
+		fmt.Println()
+	},
+}
+
+var listKeysCmd = &cobra.Command{
+	Use:   "list-keys",
+	Args:  cobra.ExactArgs(0),
+	Short: "list ssh public keys",
+	Long:  "list the ssh public keys of your account",
+	Run: func(cmd *cobra.Command, args []string) {
+		current, err := api.DefaultClient.GetPublicKeys(cmd.Context())
+		exit.CheckErr(err)
+		keys := sshkey.ParseList(current)
+
+		printed, err := format.Print(os.Stdout, output, "", keys)
+		exit.CheckErr(err)
+		if printed {
+			return
+		}
+		data := make([][]string, len(keys))
+		for i, k := range keys {
+			data[i] = []string{k.Fingerprint, k.Type, k.Comment}
+		}
+		tb := tablewriter.NewWriter(os.Stdout)
+		tb.SetHeader([]string{"Fingerprint", "Type", "Comment"})
+		tb.AppendBulk(data)
+		format.TableDefaults(tb)
+		tb.Render()
+	},
+}
+
+var removeKeyCmd = &cobra.Command{
+	Use:   "remove-key [fingerprint or comment]",
+	Args:  cobra.ExactArgs(1),
+	Short: "remove an ssh public key",
+	Long:  "remove an ssh public key from your account by its fingerprint or comment; pods that already have it keep it",
+	Run: func(cmd *cobra.Command, args []string) {
+		current, err := api.DefaultClient.GetPublicKeys(cmd.Context())
+		exit.CheckErr(err)
+		var removed []*sshkey.Key
+		for _, k := range sshkey.ParseList(current) {
+			if k.Match(args[0]) {
+				removed = append(removed, k)
+			}
+		}
+		if len(removed) == 0 {
+			exit.CheckErr(fmt.Errorf(`no key matches "%s"`, args[0]))
+		}
+		if len(removed) > 1 {
+			exit.CheckErr(fmt.Errorf(`%d keys match "%s"; remove one by fingerprint`, len(removed), args[0]))
+		}
+		exit.CheckErr(api.DefaultClient.UpdatePublicKeys(cmd.Context(), sshkey.Remove(current, removed[0])))
+		fmt.Printf(`key "%s" removed`, removed[0].Fingerprint)
+		fmt.Println()
+	},
+}
+
+func init() {
+	addKeyCmd.Flags().StringVar(&keyFile, "key-file", "", "public key file, e.g. ~/.ssh/id_ed25519.pub")
+	addKeyCmd.Flags().StringVar(&keyText, "key", "", "public key, e.g. 'ssh-ed25519 AAAA... me@laptop'")
+	listKeysCmd.Flags().StringVarP(&output, "output", "o", "", "output format: json, yaml, jsonpath=<expression> or template=<go template>")
+
+	SshCmd.AddCommand(addKeyCmd)
+	SshCmd.AddCommand(listKeysCmd)
+	SshCmd.AddCommand(removeKeyCmd)
+}
@@ -0,0 +1,106 @@
+package template
+
+import (
+	"cli/api"
+	"cli/exit"
+	"cli/manifest"
+	"cli/secrets"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var containerDiskInGb int
+var dockerArgs string
+var env []string
+var file string
+var imageName string
+var name string
+var params []string
+var ports []string
+var readme string
+var serverless bool
+var volumeInGb int
+var volumeMountPath string
+
+var CreateTemplateCmd = &cobra.Command{
+	Use:   "template",
+	Args:  cobra.ExactArgs(0),
+	Short: "create a template",
+	Long:  "create a pod template from flags or a yaml manifest",
+	Run: func(cmd *cobra.Command, args []string) {
+		if file != "" {
+			values, err := manifest.ParseParams(params)
+			exit.CheckErr(err)
+			docs, err := manifest.Load(file, values)
+			exit.CheckErr(err)
+			for _, doc := range docs {
+				t, err := doc.Template()
+				exit.CheckErr(err)
+				createTemplate(cmd.Context(), t.SaveTemplateInput())
+			}
+			return
+		}
+		if name == "" || imageName == "" {
+			exit.CheckErr(fmt.Errorf(`required flag(s) "name", "imageName" not set`))
+		}
+
+		input := &api.SaveTemplateInput{
+			ContainerDiskInGb: containerDiskInGb,
+			DockerArgs:        dockerArgs,
+			ImageName:         imageName,
+			IsServerless:      serverless,
+			Name:              name,
+			Readme:            readme,
+			VolumeInGb:        volumeInGb,
+			VolumeMountPath:   volumeMountPath,
+		}
+		input.Ports = strings.Join(ports, ",")
+		var err error
+		input.Env, err = parseEnv(env)
+		exit.CheckErr(err)
+		createTemplate(cmd.Context(), input)
+	},
+}
+
+func createTemplate(ctx context.Context, input *api.SaveTemplateInput) {
+	exit.CheckErr(secrets.Check(input.Env))
+	template, err := api.DefaultClient.CreateTemplate(ctx, input)
+	exit.CheckErr(err)
+	fmt.Printf(`template "%s" created with id "%s"`, template.Name, template.Id)
+	fmt.Println()
+}
+
+func parseEnv(env []string) ([]*api.PodEnv, error) {
+	podEnv := make([]*api.PodEnv, len(env))
+	for i, v := range env {
+		e := strings.Split(v, "=")
+		if len(e) != 2 {
+			return nil, fmt.Errorf("wrong env value: %s", e)
+		}
+		podEnv[i] = &api.PodEnv{Key: e[0], Value: e[1]}
+	}
+	return podEnv, nil
+}
+
+// templateFlags registers the template spec flags shared by create and update.
+func templateFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&containerDiskInGb, "containerDiskSize", 20, "container disk size in GB")
+	cmd.Flags().StringVar(&dockerArgs, "args", "", "container arguments")
+	cmd.Flags().StringSliceVar(&env, "env", nil, "container environment variables, e.g. 'KEY=value'")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "read the template from a yaml manifest ('-' for stdin); spec flags are ignored")
+	cmd.Flags().StringVar(&imageName, "imageName", "", "container image name")
+	cmd.Flags().StringVar(&name, "name", "", "template name")
+	cmd.Flags().StringArrayVar(&params, "param", nil, "with -f, value of a manifest param as NAME=value; repeat for more")
+	cmd.Flags().StringSliceVar(&ports, "ports", nil, "ports to expose; max only 1 http and 1 tcp allowed; e.g. '8888/http'")
+	cmd.Flags().StringVar(&readme, "readme", "", "template readme in markdown")
+	cmd.Flags().BoolVar(&serverless, "serverless", false, "create a serverless template")
+	cmd.Flags().IntVar(&volumeInGb, "volumeSize", 1, "persistent volume disk size in GB")
+	cmd.Flags().StringVar(&volumeMountPath, "volumePath", "/runpod", "container volume path")
+}
+
+func init() {
+	templateFlags(CreateTemplateCmd)
+}
@@ -0,0 +1,94 @@
+package template
+
+import (
+	"cli/api"
+	"cli/exit"
+	"cli/format"
+	"cli/manifest"
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var output string
+var outputTemplate string
+
+var GetTemplateCmd = &cobra.Command{
+	Use:     "template [templateId]",
+	Aliases: []string{"templates"},
+	Args:    cobra.MaximumNArgs(1),
+	Short:   "get all templates",
+	Long:    "get all my pod templates or specify template id",
+	Run: func(cmd *cobra.Command, args []string) {
+		var templates []*api.Template
+		if len(args) == 1 {
+			t, err := api.DefaultClient.GetTemplate(cmd.Context(), args[0])
+			exit.CheckErr(err)
+			templates = []*api.Template{t}
+		} else {
+			var err error
+			templates, err = api.DefaultClient.GetTemplates(cmd.Context())
+			exit.CheckErr(format.Partial(err))
+		}
+
+		switch output {
+		case "manifest":
+			manifests := make([]interface{}, len(templates))
+			for i, t := range templates {
+				manifests[i] = manifest.FromTemplate(t)
+			}
+			out, err := manifest.Marshal(manifests...)
+			exit.CheckErr(err)
+			fmt.Print(string(out))
+			return
+		}
+		var v interface{} = templates
+		if len(args) == 1 {
+			v = templates[0]
+		}
+		printed, err := format.Print(os.Stdout, output, outputTemplate, v)
+		exit.CheckErr(err)
+		if printed {
+			return
+		}
+
+		columns, err := format.ColumnSet(output)
+		exit.CheckErr(err)
+		if columns != nil {
+			exit.CheckErr(format.PrintColumns(os.Stdout, columns, templates, templateColumns))
+			return
+		}
+
+		data := make([][]string, len(templates))
+		for i, t := range templates {
+			data[i] = []string{t.Id, t.Name, t.ImageName, t.Ports, templateKind(t)}
+		}
+
+		tb := tablewriter.NewWriter(os.Stdout)
+		tb.SetHeader([]string{"ID", "Name", "Image Name", "Ports", "Type"})
+		tb.AppendBulk(data)
+		format.TableDefaults(tb)
+		tb.Render()
+	},
+}
+
+// templateColumns are the -o columns=<set> columns that are not plain json fields.
+var templateColumns = map[string]format.Column{
+	"type": func(row interface{}) string {
+		return templateKind(row.(*api.Template))
+	},
+}
+
+func templateKind(t *api.Template) string {
+	if t.IsServerless {
+		return "serverless"
+	}
+	return "pod"
+}
+
+func init() {
+	GetTemplateCmd.Flags().StringVarP(&output, "output", "o", "", "output format: columns=<set>, json, yaml, jsonpath=<expression>, template=<go template> or manifest (yaml)")
+	GetTemplateCmd.Flags().StringVar(&outputTemplate, "template", "", "go template for the output; fields are named as in -o json, e.g. '{{.name}}'")
+}
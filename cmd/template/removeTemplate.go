@@ -0,0 +1,24 @@
+package template
+
+import (
+	"cli/api"
+	"cli/exit"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var RemoveTemplateCmd = &cobra.Command{
+	Use:   "template [templateId]",
+	Args:  cobra.ExactArgs(1),
+	Short: "remove a template",
+	Long:  "remove a pod template by id or name",
+	Run: func(cmd *cobra.Command, args []string) {
+		template, err := api.DefaultClient.GetTemplate(cmd.Context(), args[0])
+		exit.CheckErr(err)
+		err = api.DefaultClient.DeleteTemplate(cmd.Context(), template.Name)
+		exit.CheckErr(err)
+		fmt.Printf(`template "%s" removed`, template.Id)
+		fmt.Println()
+	},
+}
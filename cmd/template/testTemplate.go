@@ -0,0 +1,184 @@
+package template
+
+import (
+	"bytes"
+	"cli/api"
+	"cli/complete"
+	"cli/exit"
+	"cli/remote"
+	"cli/watch"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var command string
+var expectContains string
+var expectStatus int
+var gpuCount int
+var gpuTypeId string
+var inputFile string
+var keep bool
+var probePath string
+var probePort int
+var secureCloud bool
+var timeout time.Duration
+
+var TestTemplateCmd = &cobra.Command{
+	Use:   "template [templateId]",
+	Args:  cobra.ExactArgs(1),
+	Short: "test a pod template",
+	Long:  "deploy an ephemeral pod from a template, probe it with an http request or a command, assert on the result and terminate the pod",
+	Run: func(cmd *cobra.Command, args []string) {
+		if (command == "") == (inputFile == "") {
+			exit.CheckErr(fmt.Errorf("exactly one of --command or --input is required"))
+		}
+		var body []byte
+		if inputFile != "" {
+			var err error
+			body, err = os.ReadFile(inputFile)
+			exit.CheckErr(err)
+		}
+
+		input := &api.CreatePodInput{
+			CloudType:  "COMMUNITY",
+			GpuCount:   gpuCount,
+			GpuTypeId:  gpuTypeId,
+			Name:       "template-test-" + args[0],
+			TemplateId: args[0],
+		}
+		if secureCloud {
+			input.CloudType = "SECURE"
+		}
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+		exit.CheckErr(testTemplate(ctx, input, body))
+		fmt.Printf(`template "%s" passed`, args[0])
+		fmt.Println()
+	},
+}
+
+// testTemplate creates a pod from the template and probes it. The pod is
+// removed on the way out, also when the test is interrupted, unless the
+// probe failed and --keep is set.
+func testTemplate(ctx context.Context, input *api.CreatePodInput, body []byte) (err error) {
+	created, err := api.DefaultClient.CreatePod(ctx, input)
+	if err != nil {
+		return err
+	}
+	podId := created.Id
+	fmt.Printf(`pod "%s" created from template "%s"`, podId, input.TemplateId)
+	fmt.Println()
+	defer func() {
+		if keep && err != nil && ctx.Err() == nil {
+			fmt.Printf(`keeping pod "%s" for inspection`, podId)
+			fmt.Println()
+			return
+		}
+		// ctx is done after an interrupt, and the pod must go all the same
+		rmErr := api.DefaultClient.RemovePod(context.Background(), podId)
+		if rmErr != nil {
+			fmt.Fprintf(os.Stderr, "failed to remove pod %s: %s\n", podId, rmErr)
+		} else {
+			fmt.Printf(`pod "%s" removed`, podId)
+			fmt.Println()
+		}
+	}()
+
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var output string
+	pod, err := watch.Running(probeCtx, podId)
+	if err == nil {
+		if command != "" {
+			output, err = probeCommand(probeCtx, pod)
+		} else {
+			output, err = probeHttp(probeCtx, pod, body)
+		}
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("interrupted")
+	}
+	if err == nil && !strings.Contains(output, expectContains) {
+		err = fmt.Errorf("output does not contain %q:\n%s", expectContains, output)
+	}
+	return err
+}
+
+func init() {
+	TestTemplateCmd.Flags().StringVar(&command, "command", "", "command to run in the pod over ssh as the probe")
+	TestTemplateCmd.Flags().StringVar(&expectContains, "expect-contains", "", "text the probe output must contain")
+	TestTemplateCmd.Flags().IntVar(&expectStatus, "expect-status", 200, "http status the probe must return")
+	TestTemplateCmd.Flags().IntVar(&gpuCount, "gpuCount", 1, "number of GPUs for the pod")
+	TestTemplateCmd.Flags().StringVar(&gpuTypeId, "gpuType", "", "gpu type id, e.g. 'NVIDIA GeForce RTX 3090'")
+	TestTemplateCmd.RegisterFlagCompletionFunc("gpuType", complete.GpuTypes) //nolint
+	TestTemplateCmd.Flags().StringVar(&inputFile, "input", "", "json file to POST to the pod as the probe")
+	TestTemplateCmd.Flags().BoolVar(&keep, "keep", false, "keep the pod when the test fails")
+	TestTemplateCmd.Flags().StringVar(&probePath, "path", "/", "http path of the probe request")
+	TestTemplateCmd.Flags().IntVar(&probePort, "port", 8000, "http port of the probe request")
+	TestTemplateCmd.Flags().BoolVar(&secureCloud, "secureCloud", false, "deploy in secure cloud")
+	TestTemplateCmd.Flags().DurationVar(&timeout, "timeout", 15*time.Minute, "time allowed for the pod to start and the probe to pass")
+
+	TestTemplateCmd.MarkFlagRequired("gpuType") //nolint
+}
+
+// probeCommand runs the command over ssh, retrying while sshd is not up yet.
+func probeCommand(ctx context.Context, pod *api.Pod) (string, error) {
+	target, err := remote.Resolve(pod)
+	if err != nil {
+		return "", err
+	}
+	for {
+		var out bytes.Buffer
+		err := target.Run(ctx, command, nil, &out, &out)
+		var exitErr *exec.ExitError
+		if err == nil || !errors.As(err, &exitErr) || exitErr.ExitCode() != 255 {
+			return out.String(), err
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("ssh not reachable: %s", strings.TrimSpace(out.String()))
+		case <-time.After(watch.PollInterval):
+		}
+	}
+}
+
+// probeHttp posts body to the pod through the runpod http proxy, retrying
+// until the expected status is returned.
+func probeHttp(ctx context.Context, pod *api.Pod, body []byte) (string, error) {
+	url := fmt.Sprintf("https://%s-%d.proxy.runpod.net%s", pod.Id, probePort, probePath)
+	client := &http.Client{Timeout: time.Minute}
+	var last string
+	for {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Add("Content-Type", "application/json")
+		res, err := client.Do(req)
+		if err == nil {
+			out, _ := io.ReadAll(res.Body)
+			res.Body.Close()
+			if res.StatusCode == expectStatus {
+				return string(out), nil
+			}
+			last = fmt.Sprintf("statuscode %d: %s", res.StatusCode, out)
+		} else {
+			last = err.Error()
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("probe %s failed: %s", url, last)
+		case <-time.After(watch.PollInterval):
+		}
+	}
+}
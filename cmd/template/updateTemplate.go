@@ -0,0 +1,81 @@
+package template
+
+import (
+	"cli/api"
+	"cli/exit"
+	"cli/manifest"
+	"cli/secrets"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var UpdateTemplateCmd = &cobra.Command{
+	Use:   "template [templateId]",
+	Args:  cobra.ExactArgs(1),
+	Short: "update a template",
+	Long:  "update a pod template; only the flags given are changed, or the whole spec is replaced with --file",
+	Run: func(cmd *cobra.Command, args []string) {
+		current, err := api.DefaultClient.GetTemplate(cmd.Context(), args[0])
+		exit.CheckErr(err)
+
+		var input *api.SaveTemplateInput
+		if file != "" {
+			values, err := manifest.ParseParams(params)
+			exit.CheckErr(err)
+			docs, err := manifest.Load(file, values)
+			exit.CheckErr(err)
+			if len(docs) != 1 {
+				exit.CheckErr(fmt.Errorf("%s has %d documents; expected one template", file, len(docs)))
+			}
+			t, err := docs[0].Template()
+			exit.CheckErr(err)
+			input = t.SaveTemplateInput()
+		} else {
+			input = manifest.FromTemplate(current).SaveTemplateInput()
+			flags := cmd.Flags()
+			if flags.Changed("containerDiskSize") {
+				input.ContainerDiskInGb = containerDiskInGb
+			}
+			if flags.Changed("args") {
+				input.DockerArgs = dockerArgs
+			}
+			if flags.Changed("env") {
+				input.Env, err = parseEnv(env)
+				exit.CheckErr(err)
+			}
+			if flags.Changed("imageName") {
+				input.ImageName = imageName
+			}
+			if flags.Changed("name") {
+				input.Name = name
+			}
+			if flags.Changed("ports") {
+				input.Ports = strings.Join(ports, ",")
+			}
+			if flags.Changed("readme") {
+				input.Readme = readme
+			}
+			if flags.Changed("serverless") {
+				input.IsServerless = serverless
+			}
+			if flags.Changed("volumeSize") {
+				input.VolumeInGb = volumeInGb
+			}
+			if flags.Changed("volumePath") {
+				input.VolumeMountPath = volumeMountPath
+			}
+		}
+		input.Id = current.Id
+		exit.CheckErr(secrets.Check(input.Env))
+		template, err := api.DefaultClient.UpdateTemplate(cmd.Context(), input)
+		exit.CheckErr(err)
+		fmt.Printf(`template "%s" updated`, template.Id)
+		fmt.Println()
+	},
+}
+
+func init() {
+	templateFlags(UpdateTemplateCmd)
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"cli/cmd/template"
+
+	"github.com/spf13/cobra"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test [command]",
+	Short: "test a resource",
+	Long:  "test a resource in runpod.io",
+}
+
+func init() {
+	testCmd.AddCommand(template.TestTemplateCmd)
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"cli/cmd/pod"
+
+	"github.com/spf13/cobra"
+)
+
+var topCmd = &cobra.Command{
+	Use:   "top [command]",
+	Short: "show resource utilization",
+	Long:  "show live resource utilization",
+}
+
+func init() {
+	topCmd.AddCommand(pod.TopPodsCmd)
+}
@@ -0,0 +1,164 @@
+package track
+
+import (
+	"cli/api"
+	"cli/availability"
+	"cli/daemon"
+	"cli/exit"
+	"cli/format"
+	"cli/history"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var daemonName string
+var endpoints bool
+var interval time.Duration
+var month string
+var reportOutput string
+
+var TrackCmd = &cobra.Command{
+	Use:         "track",
+	Annotations: map[string]string{"daemon": "true"},
+	Args:        cobra.ExactArgs(0),
+	Short:       "record pod and endpoint availability",
+	Long: `poll pods and serverless endpoints every --interval and record in the local history when one
+goes up or down, is preempted, restarts or is stopped, for report availability. Run it as a daemon
+so the record has no gaps; time while it is not running counts in the last recorded state`,
+	Run: func(cmd *cobra.Command, args []string) {
+		d, err := daemon.Register("track", daemonName, nil)
+		exit.CheckErr(err)
+		defer d.Release() //nolint
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+		t := availability.NewTracker()
+		logf("tracking availability every %s", interval)
+		for {
+			tick(ctx, t)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	},
+}
+
+var ReportAvailabilityCmd = &cobra.Command{
+	Use:   "availability",
+	Args:  cobra.ExactArgs(0),
+	Short: "report pod and endpoint availability",
+	Long: `report the uptime percentage, outages, spot preemptions and restarts of every pod and endpoint
+recorded by track in a month, least available first. Stopped time is left out`,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, err := time.ParseInLocation("2006-01", month, time.Local)
+		if err != nil {
+			exit.CheckErr(fmt.Errorf("invalid --month %q: use YYYY-MM, e.g. 2024-06", month))
+		}
+		to := from.AddDate(0, 1, 0)
+		if now := time.Now(); to.After(now) {
+			to = now
+		}
+		entries, err := history.Read()
+		exit.CheckErr(err)
+		resources := availability.Report(history.Mine(entries), from, to)
+
+		printed, err := format.Print(os.Stdout, reportOutput, "", resources)
+		exit.CheckErr(err)
+		if printed {
+			return
+		}
+		if len(resources) == 0 {
+			fmt.Printf("no availability recorded in %s; it is recorded by runpodctl track\n", from.Format("January 2006"))
+			return
+		}
+		data := make([][]string, len(resources))
+		for i, r := range resources {
+			data[i] = []string{
+				r.Kind,
+				r.Id,
+				r.Name,
+				fmt.Sprintf("%.3f%%", r.Availability),
+				seconds(r.UpSeconds),
+				seconds(r.DownSeconds),
+				fmt.Sprint(r.Outages),
+				fmt.Sprint(r.Preemptions),
+				fmt.Sprint(r.Restarts),
+			}
+		}
+		tb := tablewriter.NewWriter(os.Stdout)
+		tb.SetHeader([]string{"Kind", "Id", "Name", "Availability", "Up", "Down", "Outages", "Preemptions", "Restarts"})
+		tb.AppendBulk(data)
+		format.TableDefaults(tb)
+		tb.Render()
+	},
+}
+
+func init() {
+	TrackCmd.Flags().StringVar(&daemonName, "name", "track", "daemon name for runpodctl daemons")
+	TrackCmd.Flags().BoolVar(&endpoints, "endpoints", true, "also track serverless endpoints")
+	TrackCmd.Flags().DurationVar(&interval, "interval", time.Minute, "polling interval")
+
+	ReportAvailabilityCmd.Flags().StringVar(&month, "month", time.Now().Format("2006-01"), "month to report, as YYYY-MM")
+	ReportAvailabilityCmd.Flags().StringVarP(&reportOutput, "output", "o", "", "output format: json, yaml, jsonpath=<expression> or template=<go template>")
+}
+
+// tick observes the pods and endpoints once and records what changed.
+func tick(ctx context.Context, t *availability.Tracker) {
+	pods, err := api.DefaultClient.GetPods(ctx)
+	if err != nil {
+		logf("get pods failed: %s", err)
+		return
+	}
+	// spot pods stopped with runpodctl are not preempted
+	recorded, err := history.Read()
+	if err != nil {
+		logf("read history failed: %s", err)
+	}
+	entries := t.Pods(pods, history.StoppedOnPurpose(history.Mine(recorded)))
+	if endpoints {
+		list, err := api.DefaultClient.GetEndpoints(ctx)
+		if err != nil {
+			logf("get endpoints failed: %s", err)
+		} else {
+			health := make(map[string]*api.EndpointHealth, len(list))
+			for _, e := range list {
+				h, err := api.DefaultClient.GetEndpointHealth(ctx, e.Id)
+				if err != nil {
+					logf("endpoint %s health failed: %s", e.Id, err)
+					continue
+				}
+				health[e.Id] = h
+			}
+			entries = append(entries, t.Endpoints(list, health)...)
+		}
+	}
+	for _, e := range entries {
+		logf("%s %s (%s) is %s", kind(e), e.PodId+e.EndpointId, e.Name, e.Status)
+		if err := history.Append(e); err != nil {
+			logf("could not record history: %s", err)
+		}
+	}
+}
+
+func kind(e *history.Entry) string {
+	if e.EndpointId != "" {
+		return "endpoint"
+	}
+	return "pod"
+}
+
+func seconds(s float64) string {
+	return time.Duration(s * float64(time.Second)).Round(time.Second).String()
+}
+
+func logf(format string, args ...interface{}) {
+	fmt.Printf("%s %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,45 @@
+package transfers
+
+import (
+	"cli/exit"
+	"cli/format"
+	"cli/remote"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var olderThan time.Duration
+
+var TransfersCmd = &cobra.Command{
+	Use:   "transfers [command]",
+	Short: "manage file transfers",
+	Long:  "manage what cp leaves on this machine",
+}
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Args:  cobra.ExactArgs(0),
+	Short: "remove stale partial downloads",
+	Long: `remove the partial downloads of cp that were not written to for --older-than, left by copies that
+crashed or were given up on, and report the space reclaimed. A partial download that is removed can
+no longer be resumed; cp starts that file over`,
+	Run: func(cmd *cobra.Command, args []string) {
+		removed, freed, err := remote.CleanPartials(olderThan)
+		for _, p := range removed {
+			fmt.Printf("removed %s (%s from pod %s)\n", p.Path, p.Source, p.PodId)
+		}
+		exit.CheckErr(err)
+		if len(removed) == 0 {
+			fmt.Println("no stale partial downloads")
+			return
+		}
+		fmt.Printf("reclaimed %s\n", format.Bytes(freed))
+	},
+}
+
+func init() {
+	TransfersCmd.AddCommand(gcCmd)
+	gcCmd.Flags().DurationVar(&olderThan, "older-than", 24*time.Hour, "remove partial downloads not written to for this long; 0 removes all, also those of copies still running")
+}
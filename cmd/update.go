@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"cli/cmd/pod"
+	"cli/cmd/template"
+	"cli/cmd/volume"
+
+	"github.com/spf13/cobra"
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update [command]",
+	Short: "update a resource",
+	Long:  "update a resource in runpod.io",
+}
+
+func init() {
+	updateCmd.AddCommand(pod.UpdatePodCmd)
+	updateCmd.AddCommand(template.UpdateTemplateCmd)
+	updateCmd.AddCommand(volume.UpdateNetworkVolumeCmd)
+}
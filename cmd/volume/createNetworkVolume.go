@@ -0,0 +1,38 @@
+package volume
+
+import (
+	"cli/api"
+	"cli/exit"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var dataCenterId string
+var name string
+var size int
+
+var CreateNetworkVolumeCmd = &cobra.Command{
+	Use:   "network-volume",
+	Args:  cobra.ExactArgs(0),
+	Short: "create a network volume",
+	Long:  "create a network volume in a datacenter; pods in the same datacenter can mount it with --network-volume-id",
+	Run: func(cmd *cobra.Command, args []string) {
+		volume, err := api.DefaultClient.CreateNetworkVolume(cmd.Context(), &api.CreateNetworkVolumeInput{
+			Name:         name,
+			Size:         size,
+			DataCenterId: dataCenterId,
+		})
+		exit.CheckErr(err)
+		fmt.Printf(`network volume "%s" created with id "%s"`, volume.Name, volume.Id)
+		fmt.Println()
+	},
+}
+
+func init() {
+	CreateNetworkVolumeCmd.Flags().StringVar(&dataCenterId, "dataCenterId", "", "datacenter to create the volume in, e.g. EU-RO-1")
+	CreateNetworkVolumeCmd.Flags().StringVar(&name, "name", "", "network volume name")
+	CreateNetworkVolumeCmd.Flags().IntVar(&size, "size", 10, "size in GB")
+	CreateNetworkVolumeCmd.MarkFlagRequired("dataCenterId") //nolint
+	CreateNetworkVolumeCmd.MarkFlagRequired("name")         //nolint
+}
@@ -0,0 +1,68 @@
+package volume
+
+import (
+	"cli/api"
+	"cli/exit"
+	"cli/format"
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var output string
+var outputTemplate string
+
+var GetNetworkVolumeCmd = &cobra.Command{
+	Use:     "network-volume [volumeId]",
+	Aliases: []string{"network-volumes"},
+	Args:    cobra.MaximumNArgs(1),
+	Short:   "get all network volumes",
+	Long:    "get all my network volumes or specify volume id",
+	Run: func(cmd *cobra.Command, args []string) {
+		var volumes []*api.NetworkVolume
+		if len(args) == 1 {
+			v, err := api.DefaultClient.GetNetworkVolume(cmd.Context(), args[0])
+			exit.CheckErr(err)
+			volumes = []*api.NetworkVolume{v}
+		} else {
+			var err error
+			volumes, err = api.DefaultClient.GetNetworkVolumes(cmd.Context())
+			exit.CheckErr(format.Partial(err))
+		}
+
+		var v interface{} = volumes
+		if len(args) == 1 {
+			v = volumes[0]
+		}
+		printed, err := format.Print(os.Stdout, output, outputTemplate, v)
+		exit.CheckErr(err)
+		if printed {
+			return
+		}
+
+		columns, err := format.ColumnSet(output)
+		exit.CheckErr(err)
+		if columns != nil {
+			exit.CheckErr(format.PrintColumns(os.Stdout, columns, volumes, nil))
+			return
+		}
+
+		data := make([][]string, len(volumes))
+		for i, v := range volumes {
+			data[i] = []string{v.Id, v.Name, fmt.Sprint(v.Size), v.DataCenterId}
+		}
+
+		tb := tablewriter.NewWriter(os.Stdout)
+		tb.SetHeader([]string{"ID", "Name", "Size GB", "Data Center"})
+		tb.AppendBulk(data)
+		format.TableDefaults(tb)
+		tb.Render()
+	},
+}
+
+func init() {
+	GetNetworkVolumeCmd.Flags().StringVarP(&output, "output", "o", "", "output format: columns=<set>, json, yaml, jsonpath=<expression> or template=<go template>")
+	GetNetworkVolumeCmd.Flags().StringVar(&outputTemplate, "template", "", "go template for the output; fields are named as in -o json, e.g. '{{.name}}'")
+}
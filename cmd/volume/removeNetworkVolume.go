@@ -0,0 +1,24 @@
+package volume
+
+import (
+	"cli/api"
+	"cli/exit"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var RemoveNetworkVolumeCmd = &cobra.Command{
+	Use:   "network-volume [volumeId]",
+	Args:  cobra.ExactArgs(1),
+	Short: "remove a network volume",
+	Long:  "remove a network volume by id or name; its data is deleted",
+	Run: func(cmd *cobra.Command, args []string) {
+		volume, err := api.DefaultClient.GetNetworkVolume(cmd.Context(), args[0])
+		exit.CheckErr(err)
+		err = api.DefaultClient.DeleteNetworkVolume(cmd.Context(), volume.Id)
+		exit.CheckErr(err)
+		fmt.Printf(`network volume "%s" removed`, volume.Id)
+		fmt.Println()
+	},
+}
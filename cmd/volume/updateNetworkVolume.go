@@ -0,0 +1,46 @@
+package volume
+
+import (
+	"cli/api"
+	"cli/exit"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var newName string
+var newSize int
+
+var UpdateNetworkVolumeCmd = &cobra.Command{
+	Use:   "network-volume [volumeId]",
+	Args:  cobra.ExactArgs(1),
+	Short: "rename or resize a network volume",
+	Long:  "rename or resize a network volume; volumes can only grow",
+	Run: func(cmd *cobra.Command, args []string) {
+		volume, err := api.DefaultClient.GetNetworkVolume(cmd.Context(), args[0])
+		exit.CheckErr(err)
+
+		input := &api.UpdateNetworkVolumeInput{Id: volume.Id}
+		if cmd.Flags().Changed("name") {
+			input.Name = newName
+		}
+		if cmd.Flags().Changed("size") {
+			if newSize < volume.Size {
+				exit.CheckErr(fmt.Errorf("network volume can only grow; it is %d GB", volume.Size))
+			}
+			input.Size = newSize
+		}
+		if input.Name == "" && input.Size == 0 {
+			exit.CheckErr(fmt.Errorf("nothing to update; set --name or --size"))
+		}
+		volume, err = api.DefaultClient.UpdateNetworkVolume(cmd.Context(), input)
+		exit.CheckErr(err)
+		fmt.Printf(`network volume "%s" updated; size is %d GB`, volume.Id, volume.Size)
+		fmt.Println()
+	},
+}
+
+func init() {
+	UpdateNetworkVolumeCmd.Flags().StringVar(&newName, "name", "", "new network volume name")
+	UpdateNetworkVolumeCmd.Flags().IntVar(&newSize, "size", 0, "new size in GB")
+}
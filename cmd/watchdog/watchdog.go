@@ -0,0 +1,233 @@
+package watchdog
+
+import (
+	"cli/api"
+	"cli/daemon"
+	"cli/exit"
+	"cli/history"
+	"cli/store"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var action string
+var daemonName string
+var dryRun bool
+var exclude []string
+var idleThreshold int
+var idleTimeout time.Duration
+var include []string
+var interval time.Duration
+
+var WatchdogCmd = &cobra.Command{
+	Use:         "watchdog",
+	Annotations: map[string]string{"daemon": "true"},
+	Args:        cobra.ExactArgs(0),
+	Short:       "stop idle pods",
+	Long: `watch the gpu utilization of running pods and stop or terminate the ones that stay idle
+for --idle-timeout; --include and --exclude pick pods by name with glob patterns such as 'train-*'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if action != "stop" && action != "terminate" {
+			exit.CheckErr(fmt.Errorf("invalid --action %q: use stop or terminate", action))
+		}
+		for _, pattern := range append(include, exclude...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				exit.CheckErr(fmt.Errorf("invalid pattern %q: %w", pattern, err))
+			}
+		}
+		d, err := daemon.Register("watchdog", daemonName, nil)
+		exit.CheckErr(err)
+		defer d.Release() //nolint
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+		w := newWatcher(d)
+		logf("watching pods idle below %d%% gpu for %s", idleThreshold, idleTimeout)
+		for {
+			w.tick(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	},
+}
+
+func init() {
+	WatchdogCmd.Flags().StringVar(&action, "action", "stop", "what to do with idle pods: stop or terminate")
+	WatchdogCmd.Flags().StringVar(&daemonName, "name", "watchdog", "daemon name for runpodctl daemons")
+	WatchdogCmd.Flags().BoolVar(&dryRun, "dry-run", false, "only log the pods that would be stopped")
+	WatchdogCmd.Flags().StringSliceVar(&exclude, "exclude", nil, "never touch pods whose name matches one of these patterns")
+	WatchdogCmd.Flags().IntVar(&idleThreshold, "idle-threshold", 5, "gpu utilization percent below which a pod is idle")
+	WatchdogCmd.Flags().DurationVar(&idleTimeout, "idle-timeout", 30*time.Minute, "how long a pod may stay idle")
+	WatchdogCmd.Flags().StringSliceVar(&include, "include", nil, "only watch pods whose name matches one of these patterns")
+	WatchdogCmd.Flags().DurationVar(&interval, "interval", time.Minute, "polling interval")
+}
+
+// staleChecks is how many polling intervals old the saved idle times may be.
+// After a longer gap the pods may have worked meanwhile, so counting starts
+// over.
+const staleChecks = 5
+
+// watcher remembers since when each running pod has been idle. The times are
+// saved after every check, so a restarted watchdog does not start counting
+// again.
+type watcher struct {
+	path      string
+	idleSince map[string]time.Time
+}
+
+// watchState is what the watchdog saves between checks.
+type watchState struct {
+	Checked   time.Time            `json:"checked"`
+	IdleSince map[string]time.Time `json:"idleSince"`
+}
+
+func newWatcher(d *daemon.Daemon) *watcher {
+	w := &watcher{idleSince: make(map[string]time.Time)}
+	path, err := d.StatePath()
+	if err != nil {
+		logf("idle times will not be saved: %s", err)
+		return w
+	}
+	w.path = path
+	var saved watchState
+	b, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(b, &saved) != nil || saved.IdleSince == nil {
+		return w
+	}
+	if time.Since(saved.Checked) > staleChecks*interval {
+		logf("saved idle times are from %s; counting again", saved.Checked.Format(time.RFC3339))
+		return w
+	}
+	w.idleSince = saved.IdleSince
+	if len(w.idleSince) > 0 {
+		logf("resuming the idle times of %d pods", len(w.idleSince))
+	}
+	return w
+}
+
+func (w *watcher) save() {
+	if w.path == "" {
+		return
+	}
+	b, err := json.MarshalIndent(watchState{Checked: time.Now().UTC(), IdleSince: w.idleSince}, "", "  ")
+	if err == nil {
+		err = store.WriteFile(w.path, b, 0600)
+	}
+	if err != nil {
+		logf("could not save idle times: %s", err)
+	}
+}
+
+func (w *watcher) tick(ctx context.Context) {
+	pods, err := api.DefaultClient.GetPods(ctx)
+	if err != nil {
+		logf("get pods failed: %s", err)
+		return
+	}
+	seen := make(map[string]bool, len(pods))
+	for _, p := range pods {
+		if !watched(p.Name) {
+			continue
+		}
+		if !p.Idle(idleThreshold) {
+			if _, ok := w.idleSince[p.Id]; ok {
+				logf("pod %s (%s) is busy again", p.Id, p.Name)
+			}
+			continue
+		}
+		seen[p.Id] = true
+		since, ok := w.idleSince[p.Id]
+		if !ok {
+			since = time.Now()
+			w.idleSince[p.Id] = since
+			logf("pod %s (%s) is idle", p.Id, p.Name)
+		}
+		if time.Since(since) >= idleTimeout {
+			w.act(ctx, p, time.Since(since))
+		}
+	}
+	for id := range w.idleSince {
+		if !seen[id] {
+			delete(w.idleSince, id)
+		}
+	}
+	w.save()
+}
+
+// act stops or terminates a pod that stayed idle and records it in the
+// local history.
+func (w *watcher) act(ctx context.Context, p *api.Pod, idleFor time.Duration) {
+	idleFor = idleFor.Round(time.Second)
+	if err := history.CheckPods([]string{p.Id}); err != nil {
+		logf("leaving pod %s (%s) alone: %s", p.Id, p.Name, err)
+		return
+	}
+	// a keepalive would resume the pod right after it is stopped
+	other, err := daemon.Manager(p.Id)
+	if err != nil {
+		logf("%s pod %s failed: %s", action, p.Id, err)
+		return
+	}
+	if other != nil {
+		logf("leaving pod %s (%s) alone: %s daemon %q manages it", p.Id, p.Name, other.Kind, other.Name)
+		return
+	}
+	if dryRun {
+		logf("would %s pod %s (%s), idle for %s at $%.3f / hr", action, p.Id, p.Name, idleFor, p.CostPerHr)
+		return
+	}
+	if action == "terminate" {
+		err = api.DefaultClient.RemovePod(ctx, p.Id)
+	} else {
+		_, err = api.DefaultClient.StopPod(ctx, p.Id)
+	}
+	if err != nil {
+		logf("%s pod %s failed: %s", action, p.Id, err)
+		return
+	}
+	delete(w.idleSince, p.Id)
+	logf("pod %s (%s) idle for %s; %s saves $%.3f / hr", p.Id, p.Name, idleFor, actionDone(), p.CostPerHr)
+	err = history.Append(&history.Entry{Action: "watchdog " + action, PodId: p.Id, Name: p.Name, Status: "idle for " + idleFor.String()})
+	if err != nil {
+		logf("could not record history: %s", err)
+	}
+}
+
+func actionDone() string {
+	if action == "terminate" {
+		return "terminated"
+	}
+	return "stopped"
+}
+
+// watched applies the include and exclude patterns to a pod name.
+func watched(name string) bool {
+	for _, pattern := range exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, pattern := range include {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func logf(format string, args ...interface{}) {
+	fmt.Printf("%s %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,73 @@
+package complete
+
+import (
+	"cli/api"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// cacheFor is how long shell completion reuses the pods and gpu types it
+// fetched, so pressing tab repeatedly makes one api call.
+const cacheFor = 30 * time.Second
+
+// timeout bounds the api calls of a completion; the shell waits for them.
+const timeout = 5 * time.Second
+
+// PodIds completes pod ids, described by name and status, leaving out the
+// ones already on the command line.
+func PodIds(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ctx, cancel := bounded()
+	defer cancel()
+	used := make(map[string]bool, len(args))
+	for _, arg := range args {
+		used[arg] = true
+	}
+	pods, err := api.DefaultClient.ListPods(ctx, &api.PodsInput{Fields: []string{"name", "desiredStatus"}})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var ids []string
+	for _, p := range pods {
+		if used[p.Id] || !strings.HasPrefix(p.Id, toComplete) {
+			continue
+		}
+		ids = append(ids, fmt.Sprintf("%s\t%s (%s)", p.Id, p.Name, p.DesiredStatus))
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// PodId completes the only pod id argument of a command.
+func PodId(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return PodIds(cmd, args, toComplete)
+}
+
+// GpuTypes completes gpu type ids, described by their memory.
+func GpuTypes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ctx, cancel := bounded()
+	defer cancel()
+	gpus, err := api.DefaultClient.GetGpuTypes(ctx, &api.GetCloudInput{GpuCount: 1})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var ids []string
+	for _, g := range gpus {
+		if strings.HasPrefix(strings.ToLower(g.Id), strings.ToLower(toComplete)) {
+			ids = append(ids, fmt.Sprintf("%s\t%d GB", g.Id, g.MemoryInGb))
+		}
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// bounded bounds the completion's api calls and lets them reuse responses
+// of the last cacheFor, which are shared between runpodctl processes.
+func bounded() (context.Context, context.CancelFunc) {
+	api.DefaultClient.DedupWindow = cacheFor
+	return context.WithTimeout(context.Background(), timeout)
+}
@@ -0,0 +1,285 @@
+package daemon
+
+import (
+	"cli/history"
+	"cli/profile"
+	"cli/store"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Daemon is a long running runpodctl process recorded in the registry of its
+// profile, so it can be listed, stopped and restarted by name.
+type Daemon struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	Pid  int    `json:"pid"`
+	// PidStart is when the process started, as store.StartTime tells it, so
+	// a process that later reuses the pid is not taken for the daemon.
+	PidStart string    `json:"pidStart,omitempty"`
+	Args     []string  `json:"args"`
+	Started  time.Time `json:"started"`
+	Log      string    `json:"log,omitempty"`
+	Service  string    `json:"service,omitempty"`
+	// Pods are the ids of the pods the daemon starts, stops or removes. Two
+	// daemons of a profile never manage the same pod.
+	Pods []string `json:"pods,omitempty"`
+}
+
+// serviceVar names the windows service a daemon runs as, so that it reports
+// to the service manager and is stopped and started through it.
+const serviceVar = "RUNPOD_SERVICE"
+
+// stopTimeout is how long Stop waits for a daemon to exit.
+const stopTimeout = 10 * time.Second
+
+// Dir is the directory of the registry, its lock and daemon logs.
+func Dir() (string, error) {
+	dir, err := history.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "daemons"), nil
+}
+
+func registryPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, profile.Name()+".json"), nil
+}
+
+// StatePath is the file the daemon keeps what it must remember across
+// restarts in.
+func (d *Daemon) StatePath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, profile.Name()+"."+d.Name+".state.json"), nil
+}
+
+// Register records the current process as daemon name, managing pods. It
+// fails if a live process is already registered under that name in the
+// active profile, or another daemon manages one of the pods, so two daemons
+// never manage the same thing.
+func Register(kind string, name string, pods []string) (*Daemon, error) {
+	d := &Daemon{
+		Name:     name,
+		Kind:     kind,
+		Pid:      os.Getpid(),
+		PidStart: store.StartTime(os.Getpid()),
+		Args:     os.Args[1:],
+		Started:  time.Now().UTC().Truncate(time.Second),
+		Log:      os.Getenv("RUNPOD_DAEMON_LOG"),
+		Service:  os.Getenv(serviceVar),
+		Pods:     pods,
+	}
+	err := update(func(daemons map[string]*Daemon) error {
+		if other, ok := daemons[name]; ok && other.Pid != d.Pid {
+			return fmt.Errorf(`daemon "%s" is already running (pid %d); stop it with runpodctl daemons stop %s`, name, other.Pid, name)
+		}
+		for _, id := range pods {
+			if other := manager(daemons, id); other != nil && other.Name != name {
+				return fmt.Errorf(`pod %s is already managed by %s daemon "%s"; stop it with runpodctl daemons stop %s`, id, other.Kind, other.Name, other.Name)
+			}
+		}
+		daemons[name] = d
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Manager returns the daemon, other than the current process, that manages
+// the pod, or nil when there is none.
+func Manager(podId string) (*Daemon, error) {
+	var found *Daemon
+	err := update(func(daemons map[string]*Daemon) error {
+		if d := manager(daemons, podId); d != nil && d.Pid != os.Getpid() {
+			found = d
+		}
+		return nil
+	})
+	return found, err
+}
+
+func manager(daemons map[string]*Daemon, podId string) *Daemon {
+	for _, d := range daemons {
+		for _, id := range d.Pods {
+			if id == podId {
+				return d
+			}
+		}
+	}
+	return nil
+}
+
+// alive reports whether the daemon's process still runs, and is not another
+// process that reused its pid.
+func (d *Daemon) alive() bool {
+	if !store.Alive(d.Pid) {
+		return false
+	}
+	if d.PidStart == "" {
+		return true
+	}
+	start := store.StartTime(d.Pid)
+	return start == "" || start == d.PidStart
+}
+
+// Release removes the daemon from the registry when it exits.
+func (d *Daemon) Release() error {
+	return update(func(daemons map[string]*Daemon) error {
+		if other, ok := daemons[d.Name]; ok && other.Pid == d.Pid {
+			delete(daemons, d.Name)
+		}
+		return nil
+	})
+}
+
+// List returns the running daemons of the active profile by name. Entries of
+// processes that are gone are dropped.
+func List() ([]*Daemon, error) {
+	var list []*Daemon
+	err := update(func(daemons map[string]*Daemon) error {
+		for _, d := range daemons {
+			list = append(list, d)
+		}
+		return nil
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, err
+}
+
+// Get returns the running daemon called name.
+func Get(name string) (*Daemon, error) {
+	list, err := List()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range list {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf(`daemon "%s" is not running`, name)
+}
+
+// Stop asks the daemon to exit and waits for it, killing it if it does not
+// exit within stopTimeout. Windows services are stopped by the service
+// manager.
+func (d *Daemon) Stop() error {
+	if d.Service != "" {
+		if err := stopService(d.Service); err != nil {
+			return err
+		}
+		return d.Release()
+	}
+	if !d.alive() {
+		return d.Release()
+	}
+	p, err := os.FindProcess(d.Pid)
+	if err != nil {
+		return err
+	}
+	if err := interrupt(p); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(stopTimeout)
+	for d.alive() {
+		if time.Now().After(deadline) {
+			if err := p.Kill(); err != nil {
+				return err
+			}
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return d.Release()
+}
+
+// Start runs the daemon's command again in the background, logging to its
+// log file, and returns the new process id. Windows services are started by
+// the service manager.
+func (d *Daemon) Start() (int, error) {
+	if d.Service != "" {
+		return startService(d.Service)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+	if d.Log == "" {
+		dir, err := Dir()
+		if err != nil {
+			return 0, err
+		}
+		d.Log = filepath.Join(dir, d.Name+".log")
+	}
+	log, err := os.OpenFile(d.Log, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer log.Close()
+	cmd := exec.Command(exe, d.Args...)
+	cmd.Stdout = log
+	cmd.Stderr = log
+	cmd.Env = append(os.Environ(), "RUNPOD_DAEMON_LOG="+d.Log)
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	pid := cmd.Process.Pid
+	return pid, cmd.Process.Release()
+}
+
+// update runs fn on the registry while holding its lock, after dropping the
+// entries of dead processes, and saves the result.
+func update(fn func(daemons map[string]*Daemon) error) error {
+	path, err := registryPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	unlock, err := store.Lock(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	daemons := make(map[string]*Daemon)
+	b, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &daemons); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	for name, d := range daemons {
+		if !d.alive() {
+			delete(daemons, name)
+		}
+	}
+	if err := fn(daemons); err != nil {
+		return err
+	}
+	b, err = json.MarshalIndent(daemons, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.WriteFile(path, b, 0600)
+}
@@ -0,0 +1,335 @@
+package daemon
+
+import (
+	"bytes"
+	"cli/profile"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Unit is a daemon installed to run at login under systemd or launchd, or
+// at boot as a windows service.
+type Unit struct {
+	Name    string
+	Exe     string
+	Args    []string
+	Service bool
+}
+
+// NewUnit builds the unit running `runpodctl <args>` with the active profile.
+func NewUnit(name string, args []string) (*Unit, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return nil, err
+	}
+	if profile.Active != "" {
+		args = append(args, "--profile", profile.Active)
+	}
+	return &Unit{Name: name, Exe: exe, Args: args}, nil
+}
+
+// Path is where the unit file is installed on this system, or the name of
+// the windows service.
+func (u *Unit) Path() (string, error) {
+	if u.Service {
+		return u.serviceName(), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	switch runtime.GOOS {
+	case "linux":
+		dir := os.Getenv("XDG_CONFIG_HOME")
+		if dir == "" {
+			dir = filepath.Join(home, ".config")
+		}
+		return filepath.Join(dir, "systemd", "user", "runpodctl-"+u.Name+".service"), nil
+	case "darwin":
+		return filepath.Join(home, "Library", "LaunchAgents", u.label()+".plist"), nil
+	}
+	if runtime.GOOS == "windows" {
+		return "", errors.New("on windows, install daemons as services with --windows-service")
+	}
+	return "", fmt.Errorf("installing daemons is supported on linux (systemd), macos (launchd) and windows (services), not %s", runtime.GOOS)
+}
+
+func (u *Unit) label() string {
+	return "io.runpod.runpodctl." + u.Name
+}
+
+func (u *Unit) serviceName() string {
+	return "runpodctl-" + u.Name
+}
+
+// Render returns the unit file for this system, or a description of the
+// windows service.
+func (u *Unit) Render() (string, error) {
+	if u.Service {
+		return u.windowsService()
+	}
+	switch runtime.GOOS {
+	case "linux":
+		return u.systemd(), nil
+	case "darwin":
+		return u.launchd()
+	}
+	_, err := u.Path()
+	return "", err
+}
+
+// systemd renders a user service that restarts the daemon when it fails.
+func (u *Unit) systemd() string {
+	words := []string{systemdQuote(u.Exe)}
+	for _, a := range u.Args {
+		words = append(words, systemdQuote(a))
+	}
+	return fmt.Sprintf(`[Unit]
+Description=runpodctl daemon %s
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+ExecStart=%s
+Restart=on-failure
+RestartSec=30
+
+[Install]
+WantedBy=default.target
+`, u.Name, strings.Join(words, " "))
+}
+
+func systemdQuote(s string) string {
+	s = strings.NewReplacer("%", "%%", "$", "$$").Replace(s)
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// launchd renders a launch agent that runs at login, is restarted when it
+// fails and logs to the daemon log.
+func (u *Unit) launchd() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	log := filepath.Join(dir, u.Name+".log")
+	var b bytes.Buffer
+	esc := func(s string) string {
+		var e bytes.Buffer
+		xml.EscapeText(&e, []byte(s)) //nolint
+		return e.String()
+	}
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+`, esc(u.label()))
+	for _, a := range append([]string{u.Exe}, u.Args...) {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", esc(a))
+	}
+	fmt.Fprintf(&b, `	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>StandardOutPath</key>
+	<string>%s</string>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+	<key>EnvironmentVariables</key>
+	<dict>
+		<key>RUNPOD_DAEMON_LOG</key>
+		<string>%s</string>
+	</dict>
+</dict>
+</plist>
+`, esc(log), esc(log), esc(log))
+	return b.String(), nil
+}
+
+// windowsService describes the service installService creates.
+func (u *Unit) windowsService() (string, error) {
+	env, err := u.serviceEnv()
+	if err != nil {
+		return "", err
+	}
+	words := []string{windowsQuote(u.Exe)}
+	for _, a := range u.Args {
+		words = append(words, windowsQuote(a))
+	}
+	return fmt.Sprintf(`Service:     %s
+DisplayName: runpodctl daemon %s
+Command:     %s
+Start:       automatic (delayed), restarted 30s after it fails
+Environment: %s
+Log:         windows event log Application, source %s
+`, u.serviceName(), u.Name, strings.Join(words, " "), strings.Join(env, " "), u.serviceName()), nil
+}
+
+// serviceEnv points the service, which runs as LocalSystem, at the
+// installing user's config and history, and names it so that the process
+// knows to talk to the service manager.
+func (u *Unit) serviceEnv() ([]string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return []string{"USERPROFILE=" + home, serviceVar + "=" + u.serviceName()}, nil
+}
+
+// windowsQuote quotes an argument like syscall.EscapeArg.
+func windowsQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"") {
+		return s
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	slashes := 0
+	for _, r := range s {
+		switch r {
+		case '\\':
+			slashes++
+		case '"':
+			b.WriteString(strings.Repeat(`\`, slashes+1))
+			slashes = 0
+		default:
+			slashes = 0
+		}
+		b.WriteRune(r)
+	}
+	b.WriteString(strings.Repeat(`\`, slashes))
+	b.WriteByte('"')
+	return b.String()
+}
+
+// Install writes the unit file and, with start, enables and starts it.
+// Windows services are registered with the service manager instead.
+func (u *Unit) Install(start bool) (string, error) {
+	if u.Service {
+		return u.serviceName(), u.installService(start)
+	}
+	path, err := u.Path()
+	if err != nil {
+		return "", err
+	}
+	content, err := u.Render()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "darwin" {
+		dir, err := Dir()
+		if err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return "", err
+		}
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", err
+	}
+	if !start {
+		return path, nil
+	}
+	if runtime.GOOS == "darwin" {
+		return path, run("launchctl", "load", "-w", path)
+	}
+	if err := run("systemctl", "--user", "daemon-reload"); err != nil {
+		return path, err
+	}
+	return path, run("systemctl", "--user", "enable", "--now", filepath.Base(path))
+}
+
+// Uninstall stops the unit called name and removes its file, or on windows
+// stops and deletes its service.
+func Uninstall(name string) (string, error) {
+	u := &Unit{Name: name}
+	if runtime.GOOS == "windows" {
+		return u.serviceName(), uninstallService(u.serviceName())
+	}
+	path, err := u.Path()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf(`daemon "%s" is not installed`, name)
+		}
+		return "", err
+	}
+	if runtime.GOOS == "darwin" {
+		run("launchctl", "unload", "-w", path) //nolint
+	} else {
+		run("systemctl", "--user", "disable", "--now", filepath.Base(path)) //nolint
+	}
+	if err := os.Remove(path); err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "linux" {
+		run("systemctl", "--user", "daemon-reload") //nolint
+	}
+	return path, nil
+}
+
+func run(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %v: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// SplitArgs splits a command line on spaces, keeping quoted strings together.
+func SplitArgs(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			cur.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package daemon
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+func interrupt(p *os.Process) error {
+	return p.Signal(os.Interrupt)
+}
+
+// detach starts cmd in its own session so it outlives the terminal.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package daemon
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// interrupt kills the process: windows cannot deliver Ctrl-C to another
+// console's process.
+func interrupt(p *os.Process) error {
+	return p.Kill()
+}
+
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: 0x00000008} // DETACHED_PROCESS
+}
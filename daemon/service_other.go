@@ -0,0 +1,37 @@
+//go:build !windows
+// +build !windows
+
+package daemon
+
+import (
+	"context"
+	"errors"
+)
+
+var errNoServices = errors.New("windows services are only supported on windows")
+
+// IsService reports whether this process runs as a windows service.
+func IsService() bool {
+	return false
+}
+
+// RunService runs the command as a windows service.
+func RunService(run func(ctx context.Context) error) error {
+	return errNoServices
+}
+
+func (u *Unit) installService(start bool) error {
+	return errNoServices
+}
+
+func uninstallService(name string) error {
+	return errNoServices
+}
+
+func stopService(name string) error {
+	return errNoServices
+}
+
+func startService(name string) (int, error) {
+	return 0, errNoServices
+}
@@ -0,0 +1,280 @@
+//go:build windows
+// +build windows
+
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// IsService reports whether this process was started by the service
+// manager as a daemon installed with --windows-service.
+func IsService() bool {
+	if os.Getenv(serviceVar) == "" {
+		return false
+	}
+	ok, err := svc.IsWindowsService()
+	return err == nil && ok
+}
+
+// RunService runs the command as the service, cancelling its context when
+// the service is stopped. Its output goes to the windows event log.
+func RunService(run func(ctx context.Context) error) error {
+	name := os.Getenv(serviceVar)
+	elog, err := eventlog.Open(name)
+	if err != nil {
+		return err
+	}
+	defer elog.Close()
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	os.Stdout, os.Stderr = w, w
+	go forward(r, elog)
+
+	s := &service{run: run}
+	if err := svc.Run(name, s); err != nil {
+		elog.Error(1, fmt.Sprintf("%s: %s", name, err)) //nolint
+		return err
+	}
+	return s.err
+}
+
+// forward writes each line of output to the event log, as an error or
+// warning when it reads like one.
+func forward(r *os.File, elog *eventlog.Log) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		lower := strings.ToLower(line)
+		switch {
+		case strings.HasPrefix(lower, "error"):
+			elog.Error(1, line) //nolint
+		case strings.HasPrefix(lower, "warning"):
+			elog.Warning(1, line) //nolint
+		default:
+			elog.Info(1, line) //nolint
+		}
+	}
+}
+
+type service struct {
+	run func(ctx context.Context) error
+	err error
+}
+
+// Execute runs the command until it returns or the service manager stops
+// it. A command that fails exits with a service error, so the manager
+// restarts it.
+func (s *service) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.run(ctx)
+	}()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case s.err = <-done:
+			if s.err != nil {
+				return true, 1
+			}
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				cancel()
+				select {
+				case s.err = <-done:
+				case <-time.After(stopTimeout):
+				}
+				return false, 0
+			}
+		}
+	}
+}
+
+// installService registers the unit as a service that starts at boot and
+// is restarted when it fails, with an event log source of the same name.
+func (u *Unit) installService(start bool) error {
+	m, err := connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect() //nolint
+	name := u.serviceName()
+	if s, err := m.OpenService(name); err == nil {
+		s.Close()
+		return fmt.Errorf(`service %s already exists; remove it with runpodctl daemons uninstall %s`, name, u.Name)
+	}
+	env, err := u.serviceEnv()
+	if err != nil {
+		return err
+	}
+	s, err := m.CreateService(name, u.Exe, mgr.Config{
+		DisplayName:      "runpodctl daemon " + u.Name,
+		Description:      "runpodctl " + strings.Join(u.Args, " "),
+		StartType:        mgr.StartAutomatic,
+		DelayedAutoStart: true,
+	}, u.Args...)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	err = setup(s, name, env)
+	if err == nil {
+		err = eventlog.InstallAsEventCreate(name, eventlog.Error|eventlog.Warning|eventlog.Info)
+	}
+	if err != nil {
+		s.Delete() //nolint
+		return err
+	}
+	if !start {
+		return nil
+	}
+	return s.Start()
+}
+
+// setup sets the service's environment and has it restarted 30s after it
+// fails, including when it exits with an error rather than crashing.
+func setup(s *mgr.Service, name string, env []string) error {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+name, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	if err := k.SetStringsValue("Environment", env); err != nil {
+		return err
+	}
+	err = s.SetRecoveryActions([]mgr.RecoveryAction{{Type: mgr.ServiceRestart, Delay: 30 * time.Second}}, 24*60*60)
+	if err != nil {
+		return err
+	}
+	flag := struct{ onNonCrashFailures int32 }{1}
+	return windows.ChangeServiceConfig2(s.Handle, windows.SERVICE_CONFIG_FAILURE_ACTIONS_FLAG, (*byte)(unsafe.Pointer(&flag)))
+}
+
+// uninstallService stops and deletes the service and its event log source.
+func uninstallService(name string) error {
+	m, err := connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect() //nolint
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf(`daemon "%s" is not installed`, strings.TrimPrefix(name, "runpodctl-"))
+	}
+	defer s.Close()
+	if err := stop(s); err != nil {
+		return err
+	}
+	if err := s.Delete(); err != nil {
+		return err
+	}
+	eventlog.Remove(name) //nolint
+	return nil
+}
+
+func stopService(name string) error {
+	m, err := connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect() //nolint
+	s, err := m.OpenService(name)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	return stop(s)
+}
+
+// stop asks the service to stop and waits up to stopTimeout for it.
+func stop(s *mgr.Service) error {
+	status, err := s.Query()
+	if err != nil {
+		return err
+	}
+	if status.State == svc.Stopped {
+		return nil
+	}
+	if status.State != svc.StopPending {
+		if _, err := s.Control(svc.Stop); err != nil {
+			return err
+		}
+	}
+	deadline := time.Now().Add(stopTimeout)
+	for {
+		status, err := s.Query()
+		if err != nil {
+			return err
+		}
+		if status.State == svc.Stopped {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("service %s did not stop within %s", s.Name, stopTimeout)
+		}
+		time.Sleep(300 * time.Millisecond)
+	}
+}
+
+// startService starts the service and returns its process id once it runs.
+func startService(name string) (int, error) {
+	m, err := connect()
+	if err != nil {
+		return 0, err
+	}
+	defer m.Disconnect() //nolint
+	s, err := m.OpenService(name)
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+	if err := s.Start(); err != nil {
+		return 0, err
+	}
+	deadline := time.Now().Add(stopTimeout)
+	for {
+		status, err := s.Query()
+		if err != nil {
+			return 0, err
+		}
+		if status.State == svc.Running {
+			return int(status.ProcessId), nil
+		}
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("service %s did not start within %s", name, stopTimeout)
+		}
+		time.Sleep(300 * time.Millisecond)
+	}
+}
+
+// connect opens the service manager, which takes an administrator.
+func connect() (*mgr.Mgr, error) {
+	m, err := mgr.Connect()
+	if errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+		return nil, errors.New("managing windows services needs an administrator prompt")
+	}
+	return m, err
+}
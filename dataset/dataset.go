@@ -0,0 +1,215 @@
+package dataset
+
+import (
+	"bytes"
+	"cli/api"
+	"cli/profile"
+	"cli/remote"
+	"cli/store"
+	"cli/watch"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Dataset is a named dataset from the datasets section of the config. It
+// lives either on a network volume, at Path inside the volume, or at a
+// url that is downloaded to Path inside the pod. Profile is the config
+// profile it was added under; its volume belongs to that account.
+type Dataset struct {
+	Name    string
+	Volume  string
+	Url     string
+	Path    string
+	Profile string
+}
+
+// List returns the registered datasets sorted by name.
+func List() []*Dataset {
+	all := viper.GetStringMap("datasets")
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	datasets := make([]*Dataset, 0, len(names))
+	for _, name := range names {
+		d, _ := Get(name)
+		datasets = append(datasets, d)
+	}
+	return datasets
+}
+
+// Get returns a registered dataset. Names are case-insensitive.
+func Get(name string) (*Dataset, error) {
+	name = strings.ToLower(name)
+	entry, ok := viper.GetStringMap("datasets")[name].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf(`dataset "%s" not found; add it with runpodctl dataset add`, name)
+	}
+	d := &Dataset{Name: name}
+	d.Volume, _ = entry["volume"].(string)
+	d.Url, _ = entry["url"].(string)
+	d.Path, _ = entry["path"].(string)
+	d.Profile, _ = entry["profile"].(string)
+	return d, nil
+}
+
+// Add registers the dataset under the active profile in the config file,
+// replacing one with the same name.
+func Add(d *Dataset) error {
+	if (d.Volume == "") == (d.Url == "") {
+		return errors.New("a dataset needs exactly one of a volume or a url")
+	}
+	if d.Url != "" && d.Path == "" {
+		d.Path = "/workspace/datasets/" + d.Name
+	}
+	return store.UpdateConfig(func() error {
+		if old, err := Get(d.Name); err == nil {
+			if err := old.Check(); err != nil {
+				return err
+			}
+		}
+		d.Profile = profile.Name()
+		all := viper.GetStringMap("datasets")
+		entry := map[string]interface{}{"path": d.Path, "profile": d.Profile}
+		if d.Volume != "" {
+			entry["volume"] = d.Volume
+		} else {
+			entry["url"] = d.Url
+		}
+		all[strings.ToLower(d.Name)] = entry
+		viper.Set("datasets", all)
+		return nil
+	})
+}
+
+// Remove deletes the dataset from the config file.
+func Remove(name string) error {
+	return store.UpdateConfig(func() error {
+		d, err := Get(name)
+		if err != nil {
+			return err
+		}
+		if err := d.Check(); err != nil {
+			return err
+		}
+		all := viper.GetStringMap("datasets")
+		delete(all, d.Name)
+		viper.Set("datasets", all)
+		return nil
+	})
+}
+
+// Apply adds the named datasets to the pod input: volume datasets attach
+// their network volume, and every dataset's location in the pod is exported
+// as RUNPOD_DATASET_<NAME>. It returns the url datasets, which must be
+// downloaded with Fetch once the pod is running.
+func Apply(input *api.CreatePodInput, names []string) (fetch []*Dataset, err error) {
+	for _, name := range names {
+		d, err := Get(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := d.Check(); err != nil {
+			return nil, err
+		}
+		location := d.Path
+		if d.Volume != "" {
+			if input.NetworkVolumeId != "" && input.NetworkVolumeId != d.Volume {
+				return nil, fmt.Errorf(`dataset "%s" is on volume %s, but the pod already mounts volume %s`, d.Name, d.Volume, input.NetworkVolumeId)
+			}
+			input.NetworkVolumeId = d.Volume
+			location = path.Join(input.VolumeMountPath, d.Path)
+		} else {
+			fetch = append(fetch, d)
+		}
+		input.Env = append(input.Env, &api.PodEnv{Key: d.EnvKey(), Value: location})
+	}
+	return fetch, nil
+}
+
+// Check refuses a dataset added under another profile than the active one.
+func (d *Dataset) Check() error {
+	return profile.Check(fmt.Sprintf(`dataset "%s"`, d.Name), d.Profile)
+}
+
+// EnvKey is the env var that holds the dataset's location in the pod.
+func (d *Dataset) EnvKey() string {
+	key := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, d.Name)
+	return "RUNPOD_DATASET_" + key
+}
+
+// FetchCommand is the shell command that downloads a url dataset into its
+// path, skipping the download when the path already has data.
+func (d *Dataset) FetchCommand() (string, error) {
+	dest := remote.Quote(d.Path)
+	src := remote.Quote(d.Url)
+	var download string
+	switch {
+	case strings.HasPrefix(d.Url, "s3://") && strings.HasSuffix(d.Url, "/"):
+		download = fmt.Sprintf("aws s3 sync %s %s", src, dest)
+	case strings.HasPrefix(d.Url, "s3://"):
+		download = fmt.Sprintf("aws s3 cp %s %s/", src, dest)
+	case strings.HasPrefix(d.Url, "gs://"):
+		download = fmt.Sprintf("gsutil -m cp -r %s %s/", src, dest)
+	case strings.HasPrefix(d.Url, "http://"), strings.HasPrefix(d.Url, "https://"):
+		download = fmt.Sprintf("cd %s && curl -fsSLO %s", dest, src)
+	default:
+		return "", fmt.Errorf(`dataset "%s": unsupported url %s; use s3://, gs:// or https://`, d.Name, d.Url)
+	}
+	return fmt.Sprintf(`[ -n "$(ls -A %s 2>/dev/null)" ] || { mkdir -p %s && %s; }`, dest, dest, download), nil
+}
+
+// Fetch waits for the pod to be running and downloads the datasets into it over ssh.
+func Fetch(ctx context.Context, podId string, datasets []*Dataset, out io.Writer) error {
+	pod, err := watch.Running(ctx, podId)
+	if err != nil {
+		return err
+	}
+	target, err := remote.Resolve(pod)
+	if err != nil {
+		return err
+	}
+	for _, d := range datasets {
+		command, err := d.FetchCommand()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "downloading dataset %s to %s on pod %s\n", d.Name, d.Path, podId)
+		for {
+			var stderr bytes.Buffer
+			err = target.Run(ctx, command, nil, out, &stderr)
+			var exitErr *exec.ExitError
+			if err == nil {
+				break
+			}
+			if !errors.As(err, &exitErr) || exitErr.ExitCode() != 255 {
+				return fmt.Errorf("dataset %s: %w: %s", d.Name, err, strings.TrimSpace(stderr.String()))
+			}
+			// ssh exits with 255 while sshd in the pod is not up yet
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("ssh not reachable: %s", strings.TrimSpace(stderr.String()))
+			case <-time.After(watch.PollInterval):
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package doctor
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Script collects diagnostics inside a pod. Each section starts with a
+// "### <name>" line so the output can be split and analyzed locally.
+const Script = `
+echo '### gpu'
+nvidia-smi --query-gpu=index,name,driver_version,memory.used,memory.total,temperature.gpu,utilization.gpu --format=csv,noheader,nounits 2>&1
+echo '### cuda'
+nvidia-smi 2>/dev/null | grep -o 'CUDA Version: [0-9.]*' | head -1
+python3 -c 'import torch; print("torch", torch.__version__, torch.version.cuda, torch.cuda.is_available())' 2>/dev/null
+echo '### disk'
+df -P / /workspace /runpod 2>/dev/null | tail -n +2 | sort -u
+echo '### network'
+for url in https://registry-1.docker.io/v2/ https://ghcr.io/v2/ https://huggingface.co https://pypi.org/simple/; do
+  echo "$url $(curl -s -o /dev/null -m 10 -w '%{http_code} %{time_total}' "$url" 2>/dev/null || echo '000 -')"
+done
+echo '### oom'
+cat /sys/fs/cgroup/memory.events 2>/dev/null | grep oom_kill
+dmesg 2>/dev/null | grep -iE 'out of memory|oom-kill|killed process' | tail -5
+`
+
+const (
+	Ok   = "ok"
+	Warn = "warn"
+	Fail = "fail"
+)
+
+// Check is one line of the diagnosis.
+type Check struct {
+	Name   string
+	Status string
+	Detail string
+}
+
+// Analyze turns the output of Script into checks, flagging likely problems.
+func Analyze(output string) []*Check {
+	sections := split(output)
+	var checks []*Check
+	checks = append(checks, gpuChecks(sections["gpu"])...)
+	checks = append(checks, cudaCheck(sections["cuda"]))
+	checks = append(checks, diskChecks(sections["disk"])...)
+	checks = append(checks, networkChecks(sections["network"])...)
+	checks = append(checks, oomCheck(sections["oom"]))
+	return checks
+}
+
+func split(output string) map[string][]string {
+	sections := make(map[string][]string)
+	current := ""
+	sc := bufio.NewScanner(strings.NewReader(output))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if name := strings.TrimPrefix(line, "### "); name != line {
+			current = name
+			continue
+		}
+		if line != "" && current != "" {
+			sections[current] = append(sections[current], line)
+		}
+	}
+	return sections
+}
+
+func gpuChecks(lines []string) []*Check {
+	if len(lines) == 0 {
+		return []*Check{{"gpu", Fail, "nvidia-smi printed nothing"}}
+	}
+	var checks []*Check
+	for _, line := range lines {
+		f := strings.Split(line, ", ")
+		if len(f) < 7 {
+			return []*Check{{"gpu", Fail, line}}
+		}
+		name := "gpu " + f[0]
+		used, _ := strconv.ParseFloat(f[3], 64)
+		total, _ := strconv.ParseFloat(f[4], 64)
+		temp, _ := strconv.Atoi(f[5])
+		c := &Check{name, Ok, fmt.Sprintf("%s, driver %s, %.0f/%.0f MiB, %d C, %s%% util", f[1], f[2], used, total, temp, f[6])}
+		switch {
+		case temp >= 85:
+			c.Status = Warn
+			c.Detail += "; running hot"
+		case total > 0 && used/total > 0.95:
+			c.Status = Warn
+			c.Detail += "; memory nearly full"
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+func cudaCheck(lines []string) *Check {
+	c := &Check{"cuda", Ok, strings.Join(lines, "; ")}
+	if len(lines) == 0 {
+		c.Status, c.Detail = Warn, "cuda version not found"
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(line, "torch ") && strings.HasSuffix(line, " False") {
+			c.Status = Fail
+			c.Detail += "; torch cannot see the gpu, check that its cuda build matches the driver"
+		}
+	}
+	return c
+}
+
+func diskChecks(lines []string) []*Check {
+	var checks []*Check
+	for _, line := range lines {
+		f := strings.Fields(line)
+		if len(f) < 6 {
+			continue
+		}
+		pct, _ := strconv.Atoi(strings.TrimSuffix(f[4], "%"))
+		avail, _ := strconv.ParseInt(f[3], 10, 64)
+		c := &Check{"disk " + f[5], Ok, fmt.Sprintf("%s used, %.1f GB free", f[4], float64(avail)/1024/1024)}
+		if pct >= 95 {
+			c.Status = Fail
+		} else if pct >= 85 {
+			c.Status = Warn
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+func networkChecks(lines []string) []*Check {
+	var checks []*Check
+	for _, line := range lines {
+		f := strings.Fields(line)
+		if len(f) < 3 {
+			continue
+		}
+		host := strings.Split(strings.TrimPrefix(f[0], "https://"), "/")[0]
+		c := &Check{"network " + host, Ok, fmt.Sprintf("http %s in %ss", f[1], f[2])}
+		seconds, err := strconv.ParseFloat(f[2], 64)
+		switch {
+		case f[1] == "000" || err != nil:
+			c.Status, c.Detail = Fail, "unreachable"
+		case seconds > 2:
+			c.Status = Warn
+			c.Detail += "; slow"
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+func oomCheck(lines []string) *Check {
+	c := &Check{"oom kills", Ok, "none"}
+	var kills []string
+	for _, line := range lines {
+		if f := strings.Fields(line); len(f) == 2 && f[0] == "oom_kill" {
+			if f[1] != "0" {
+				kills = append(kills, f[1]+" oom kills in the container")
+			}
+			continue
+		}
+		kills = append(kills, line)
+	}
+	if len(kills) > 0 {
+		c.Status, c.Detail = Warn, strings.Join(kills, "; ")
+	}
+	return c
+}
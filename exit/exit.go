@@ -0,0 +1,49 @@
+// Package exit ends the process for commands. A command that fails through
+// CheckErr exits right away, skipping deferred calls and cobra's post-run
+// hooks, so what must happen however a command ends is registered with Defer.
+package exit
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+var (
+	mu    sync.Mutex
+	hooks []func()
+)
+
+// Defer registers fn to run when the command ends, whether it succeeded or
+// failed. Like deferred calls, the last registered runs first.
+func Defer(fn func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	hooks = append(hooks, fn)
+}
+
+// RunHooks runs the registered hooks, once.
+func RunHooks() {
+	mu.Lock()
+	fns := hooks
+	hooks = nil
+	mu.Unlock()
+	for i := len(fns) - 1; i >= 0; i-- {
+		fns[i]()
+	}
+}
+
+// With runs the hooks and exits with code.
+func With(code int) {
+	RunHooks()
+	os.Exit(code)
+}
+
+// CheckErr prints msg and exits with status 1 when msg is not nil, as
+// cobra.CheckErr does, after running the hooks.
+func CheckErr(msg interface{}) {
+	if msg != nil {
+		fmt.Fprintln(os.Stderr, "Error:", msg)
+		With(1)
+	}
+}
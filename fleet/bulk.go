@@ -0,0 +1,92 @@
+package fleet
+
+import (
+	"cli/api"
+	"cli/history"
+	"cli/selector"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Filter picks the pods a bulk command acts on when no pod ids are given.
+type Filter struct {
+	All        bool
+	NamePrefix string
+	Selector   string
+}
+
+// Set reports whether any filter was given.
+func (f *Filter) Set() bool {
+	return f.All || f.NamePrefix != "" || f.Selector != ""
+}
+
+// Select returns the pods matching every filter that is set. want narrows
+// the match to the pods the command applies to, e.g. running ones for stop.
+func (f *Filter) Select(pods []*api.Pod, want func(p *api.Pod) bool) ([]*api.Pod, error) {
+	var sel *selector.Selector
+	if f.Selector != "" {
+		var err error
+		if sel, err = selector.Parse(f.Selector); err != nil {
+			return nil, err
+		}
+	}
+	var out []*api.Pod
+	for _, p := range pods {
+		if f.NamePrefix != "" && !strings.HasPrefix(p.Name, f.NamePrefix) {
+			continue
+		}
+		if sel != nil && !sel.Matches(p) {
+			continue
+		}
+		if want != nil && !want(p) {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// Bulk runs fn on every pod with at most parallel concurrent workers and
+// prints the message fn returns for each pod, or why it failed. It returns
+// an error when any pod failed, and acts on none when one of them was created
+// under another profile. When the api's retry budget runs out, the pods not
+// started yet are left alone and the error says how far it got.
+func Bulk(pods []*api.Pod, parallel int, action string, fn func(p *api.Pod) (string, error)) error {
+	ids := make([]string, len(pods))
+	for i, p := range pods {
+		ids[i] = p.Id
+	}
+	if err := history.CheckPods(ids); err != nil {
+		return err
+	}
+	var mu sync.Mutex
+	skipped := 0
+	messages := make(map[*api.Pod]string, len(pods))
+	failed := Run(pods, parallel, func(p *api.Pod) error {
+		msg, err := fn(p)
+		mu.Lock()
+		messages[p] = msg
+		mu.Unlock()
+		return err
+	}, func(r Result) {
+		switch {
+		case r.Err == ErrNotAttempted:
+			skipped++
+		case r.Err != nil:
+			fmt.Printf(`pod "%s" %s failed: %s`+"\n", r.Pod.Id, action, r.Err)
+		default:
+			mu.Lock()
+			fmt.Println(messages[r.Pod])
+			mu.Unlock()
+		}
+	})
+	if skipped > 0 {
+		return fmt.Errorf("%s gave up, %s: %d of %d pods done, %d failed, %d not attempted",
+			action, api.ErrRetryBudget, len(pods)-failed, len(pods), failed-skipped, skipped)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%s failed on %d of %d pods", action, failed, len(pods))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package fleet
+
+import (
+	"cli/api"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrNotAttempted is the result of the pods Run skipped because the api's
+// retry budget ran out.
+var ErrNotAttempted = errors.New("not attempted")
+
+// Result is the outcome of running an operation on one pod.
+type Result struct {
+	Pod *api.Pod
+	Err error
+}
+
+// Run calls fn for every pod using at most parallel concurrent workers and
+// hands each result to done, in completion order, from a single goroutine.
+// Once fn fails with api.ErrRetryBudget, the pods not started yet fail with
+// ErrNotAttempted instead.
+func Run(pods []*api.Pod, parallel int, fn func(p *api.Pod) error, done func(r Result)) (failed int) {
+	if parallel < 1 {
+		parallel = 1
+	}
+	jobs := make(chan *api.Pod)
+	results := make(chan Result)
+	var exhausted int32
+	var wg sync.WaitGroup
+	for i := 0; i < parallel && i < len(pods); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				if atomic.LoadInt32(&exhausted) == 1 {
+					results <- Result{Pod: p, Err: ErrNotAttempted}
+					continue
+				}
+				err := fn(p)
+				if errors.Is(err, api.ErrRetryBudget) {
+					atomic.StoreInt32(&exhausted, 1)
+				}
+				results <- Result{Pod: p, Err: err}
+			}
+		}()
+	}
+	go func() {
+		for _, p := range pods {
+			jobs <- p
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+	for r := range results {
+		if r.Err != nil {
+			failed++
+		}
+		if done != nil {
+			done(r)
+		}
+	}
+	return
+}
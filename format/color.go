@@ -0,0 +1,66 @@
+package format
+
+import (
+	"cli/profile"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+const (
+	red    = "\033[31m"
+	green  = "\033[32m"
+	yellow = "\033[33m"
+	reset  = "\033[0m"
+)
+
+// Color is true when stdout is a terminal and NO_COLOR is not set.
+var Color = term.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("NO_COLOR") == ""
+
+var statusColors = map[string]string{
+	"RUNNING": green,
+	"ok":      green,
+	"EXITED":  yellow,
+	"IDLE":    yellow,
+	"warn":    yellow,
+	"ERROR":   red,
+	"FAILED":  red,
+	"DEAD":    red,
+	"BREACH":  red,
+	"fail":    red,
+}
+
+// Highlight colors table cells in place before they are appended: status
+// columns by state, and $/hr columns in red when above colors.costThreshold
+// from the config. It does nothing when Color is off.
+func Highlight(header []string, data [][]string) {
+	if !Color {
+		return
+	}
+	threshold := viper.GetFloat64(profile.Key("colors.costThreshold"))
+	for j, h := range header {
+		h = strings.ToLower(h)
+		isStatus := h == "status" || h == "desiredstatus"
+		isCost := strings.HasSuffix(h, "$/hr") || h == "costperhr"
+		if !isStatus && !(isCost && threshold > 0) {
+			continue
+		}
+		for _, row := range data {
+			if j >= len(row) {
+				continue
+			}
+			color := ""
+			if isStatus {
+				color = statusColors[row[j]]
+			} else if cost, err := strconv.ParseFloat(strings.TrimSpace(row[j]), 64); err == nil && cost > threshold {
+				color = red
+			}
+			if color != "" {
+				row[j] = color + row[j] + reset
+			}
+		}
+	}
+}
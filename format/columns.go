@@ -0,0 +1,98 @@
+package format
+
+import (
+	"cli/profile"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// Column computes the cell of a named column for one row.
+type Column func(row interface{}) string
+
+// ColumnSet returns the columns selected by -o columns=<name>. The name is
+// looked up under columns.<name> in the config, as a list or a comma
+// separated string; a name that is not configured is read as the column list
+// itself, e.g. -o columns=id,name. It returns nil for any other output.
+func ColumnSet(output string) ([]string, error) {
+	if !strings.HasPrefix(output, "columns=") {
+		return nil, nil
+	}
+	name := strings.TrimPrefix(output, "columns=")
+	set := name
+	var columns []string
+	if v := viper.Get(profile.Key("columns." + name)); v != nil {
+		if s, ok := v.(string); ok {
+			set = s
+		} else {
+			columns = cast.ToStringSlice(v)
+		}
+	}
+	if columns == nil {
+		for _, c := range strings.Split(set, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				columns = append(columns, c)
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns in -o %s", output)
+	}
+	return columns, nil
+}
+
+// PrintColumns writes rows, a slice, as a table of the given columns. A
+// column found in known is computed by it; any other column is a field path
+// into the row as it encodes to json, e.g. machine.gpuTypeId.
+func PrintColumns(w io.Writer, columns []string, rows interface{}, known map[string]Column) error {
+	list := reflect.ValueOf(rows)
+	if list.Kind() != reflect.Slice {
+		return fmt.Errorf("columns need a list, got %T", rows)
+	}
+	data := make([][]string, list.Len())
+	found := make([]bool, len(columns))
+	for i := range data {
+		row := list.Index(i).Interface()
+		var generic interface{}
+		data[i] = make([]string, len(columns))
+		for j, c := range columns {
+			if col, ok := known[c]; ok {
+				data[i][j] = col(row)
+				found[j] = true
+				continue
+			}
+			if generic == nil {
+				var err error
+				if generic, err = toGeneric(row); err != nil {
+					return err
+				}
+			}
+			values, err := JsonPath(generic, "."+c)
+			if err != nil {
+				return err
+			}
+			if len(values) > 0 {
+				data[i][j] = scalar(values[0])
+				found[j] = true
+			}
+		}
+	}
+	for j, c := range columns {
+		if !found[j] && len(data) > 0 {
+			return fmt.Errorf("unknown column %q", c)
+		}
+	}
+
+	tb := tablewriter.NewWriter(w)
+	Highlight(columns, data)
+	tb.SetHeader(columns)
+	TableDefaults(tb)
+	tb.AppendBulk(data)
+	tb.Render()
+	return nil
+}
@@ -0,0 +1,37 @@
+package format
+
+import "time"
+
+// SchemaVersion is the version of the -o json envelope and of the objects in
+// it. Within a version, changes are additive only: fields are added but never
+// renamed, retyped or removed, so a parser written against a version keeps
+// working on later releases. Anything else bumps the version.
+const SchemaVersion = 1
+
+// Bare prints -o json as the plain object, without the envelope.
+var Bare bool
+
+// Command names the command in the envelope, e.g. "get pod".
+var Command string
+
+// Envelope wraps -o json output so parsers can check what they read and
+// where it came from.
+type Envelope struct {
+	SchemaVersion int         `json:"schemaVersion"`
+	Command       string      `json:"command"`
+	Timestamp     string      `json:"timestamp"`
+	Data          interface{} `json:"data"`
+}
+
+// envelope wraps v unless Bare is set.
+func envelope(v interface{}) interface{} {
+	if Bare {
+		return v
+	}
+	return &Envelope{
+		SchemaVersion: SchemaVersion,
+		Command:       Command,
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		Data:          v,
+	}
+}
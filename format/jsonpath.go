@@ -0,0 +1,72 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JsonPath evaluates a kubectl-style jsonpath expression such as
+// {.machine.gpuTypeId} or {[*].id} against a generic json value. It supports
+// field names, array indexes and the [*] wildcard; a wildcard yields one
+// result per element.
+func JsonPath(v interface{}, expr string) ([]interface{}, error) {
+	expr = strings.TrimSpace(expr)
+	expr = strings.TrimSuffix(strings.TrimPrefix(expr, "{"), "}")
+	expr = strings.TrimPrefix(expr, "$")
+	results := []interface{}{v}
+	for expr != "" {
+		var next []interface{}
+		switch expr[0] {
+		case '.':
+			expr = expr[1:]
+			end := strings.IndexAny(expr, ".[")
+			if end < 0 {
+				end = len(expr)
+			}
+			field := expr[:end]
+			expr = expr[end:]
+			if field == "" {
+				continue
+			}
+			for _, r := range results {
+				if m, ok := r.(map[string]interface{}); ok {
+					if value, ok := m[field]; ok {
+						next = append(next, value)
+					}
+				}
+			}
+		case '[':
+			end := strings.Index(expr, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath %q: unclosed [", expr)
+			}
+			index := expr[1:end]
+			expr = expr[end+1:]
+			for _, r := range results {
+				list, ok := r.([]interface{})
+				if !ok {
+					continue
+				}
+				if index == "*" {
+					next = append(next, list...)
+					continue
+				}
+				i, err := strconv.Atoi(index)
+				if err != nil {
+					return nil, fmt.Errorf("jsonpath: bad index %q", index)
+				}
+				if i < 0 {
+					i += len(list)
+				}
+				if i >= 0 && i < len(list) {
+					next = append(next, list[i])
+				}
+			}
+		default:
+			return nil, fmt.Errorf("jsonpath: unexpected %q; use .field, [n] or [*]", expr)
+		}
+		results = next
+	}
+	return results, nil
+}
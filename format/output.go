@@ -0,0 +1,149 @@
+package format
+
+import (
+	"bytes"
+	"cli/api"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Print writes v in the structured format named by output: json, yaml,
+// jsonpath=<expression> or template=<go template>. A non-empty tmpl is
+// shorthand for template=<tmpl>. It returns false without writing anything
+// when output is empty, wide or columns=<set>, leaving the table to the caller.
+//
+// json is wrapped in an Envelope unless Bare is set. yaml, jsonpath and
+// templates all see v as it encodes to json, without the envelope, so field
+// names are the same in every format.
+func Print(w io.Writer, output string, tmpl string, v interface{}) (printed bool, err error) {
+	if tmpl != "" {
+		if output != "" && output != "template" {
+			return false, fmt.Errorf("--template cannot be used with -o %s", output)
+		}
+		output = "template=" + tmpl
+	}
+	name, arg := output, ""
+	if i := strings.Index(output, "="); i >= 0 {
+		name, arg = output[:i], output[i+1:]
+	}
+	switch name {
+	case "", "wide", "columns":
+		return false, nil
+	case "json":
+		out, err := json.MarshalIndent(envelope(v), "", "  ")
+		if err != nil {
+			return false, err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return true, err
+	case "yaml":
+		return true, printYaml(w, v)
+	case "jsonpath":
+		generic, err := toGeneric(v)
+		if err != nil {
+			return false, err
+		}
+		results, err := JsonPath(generic, arg)
+		if err != nil {
+			return false, err
+		}
+		values := make([]string, len(results))
+		for i, r := range results {
+			values[i] = scalar(r)
+		}
+		_, err = fmt.Fprintln(w, strings.Join(values, " "))
+		return true, err
+	case "template", "go-template":
+		t, err := template.New("output").Parse(arg)
+		if err != nil {
+			return false, err
+		}
+		generic, err := toGeneric(v)
+		if err != nil {
+			return false, err
+		}
+		return true, t.Execute(w, generic)
+	}
+	return false, fmt.Errorf("unknown output format: %s", output)
+}
+
+// toGeneric round-trips v through json into maps, slices and scalars.
+func toGeneric(v interface{}) (interface{}, error) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(out))
+	dec.UseNumber()
+	err = dec.Decode(&generic)
+	return generic, err
+}
+
+// printYaml converts the json encoding of v to yaml, keeping the field order.
+func printYaml(w io.Writer, v interface{}) error {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(out, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func blockStyle(n *yaml.Node) {
+	if n.Style == yaml.FlowStyle || n.Style == yaml.DoubleQuotedStyle && !needsQuotes(n.Value) {
+		n.Style = 0
+	}
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+// Partial lets list commands render partial api data: it prints a warning
+// naming the fields that failed and returns nil for an api.PartialError, and
+// returns any other error unchanged.
+func Partial(err error) error {
+	if api.IsPartial(err) {
+		fmt.Fprintf(os.Stderr, "warning: showing what came back; %s\n", err)
+		return nil
+	}
+	return err
+}
+
+// needsQuotes reports whether a json string would read as another type in plain yaml.
+func needsQuotes(s string) bool {
+	var v interface{}
+	if s == "" || yaml.Unmarshal([]byte(s), &v) != nil {
+		return true
+	}
+	_, isString := v.(string)
+	return !isString || strings.ContainsAny(s, "\n:#")
+}
+
+func scalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		out, _ := json.Marshal(v)
+		return string(out)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,49 @@
+package format
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timestamps switches listings from relative durations to absolute RFC3339 times.
+var Timestamps bool
+
+// Ago formats a past time as "3h4m ago", or as RFC3339 with Timestamps. The
+// zero time is empty.
+func Ago(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	if Timestamps {
+		return t.Local().Format(time.RFC3339)
+	}
+	return Duration(time.Since(t)) + " ago"
+}
+
+// Uptime formats the time since start as "3h4m", or start as RFC3339 with
+// Timestamps. The zero time is empty.
+func Uptime(start time.Time) string {
+	if start.IsZero() {
+		return ""
+	}
+	if Timestamps {
+		return start.Local().Format(time.RFC3339)
+	}
+	return Duration(time.Since(start))
+}
+
+// Duration formats d with its two most significant units, e.g. 2d5h or 12m.
+func Duration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+}
@@ -0,0 +1,28 @@
+package hfcache
+
+import (
+	"cli/api"
+	"fmt"
+	"path"
+)
+
+// Dir is the Hugging Face cache directory inside the mounted volume.
+const Dir = "huggingface"
+
+// Apply mounts the network volume on the pod and points HF_HOME at the
+// cache directory inside it, so models downloaded once are reused by every
+// pod that mounts the same volume.
+func Apply(input *api.CreatePodInput, volumeId string) error {
+	if volumeId == "" {
+		return nil
+	}
+	if input.NetworkVolumeId != "" && input.NetworkVolumeId != volumeId {
+		return fmt.Errorf("--hf-cache volume %s conflicts with volume %s already mounted on the pod", volumeId, input.NetworkVolumeId)
+	}
+	if input.VolumeMountPath == "" {
+		return fmt.Errorf("--hf-cache needs a volume mount path")
+	}
+	input.NetworkVolumeId = volumeId
+	input.Env = append(input.Env, &api.PodEnv{Key: "HF_HOME", Value: path.Join(input.VolumeMountPath, Dir)})
+	return nil
+}
@@ -0,0 +1,48 @@
+package history
+
+import (
+	"cli/api"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// GitInfo describes the git checkout a resource was created from.
+type GitInfo struct {
+	Commit string `json:"commit"`
+	Branch string `json:"branch"`
+	Dirty  bool   `json:"dirty"`
+	Remote string `json:"remote,omitempty"`
+}
+
+// Git inspects the git repository of the working directory.
+func Git() (*GitInfo, error) {
+	commit, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("not in a git repository: %w", err)
+	}
+	info := &GitInfo{Commit: commit}
+	info.Branch, _ = git("rev-parse", "--abbrev-ref", "HEAD")
+	info.Remote, _ = git("config", "--get", "remote.origin.url")
+	status, err := git("status", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	info.Dirty = status != ""
+	return info, nil
+}
+
+// Env returns the RUNPOD_GIT_* env vars injected into pods.
+func (g *GitInfo) Env() []*api.PodEnv {
+	return []*api.PodEnv{
+		{Key: "RUNPOD_GIT_COMMIT", Value: g.Commit},
+		{Key: "RUNPOD_GIT_BRANCH", Value: g.Branch},
+		{Key: "RUNPOD_GIT_DIRTY", Value: strconv.FormatBool(g.Dirty)},
+	}
+}
+
+func git(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).Output()
+	return strings.TrimSpace(string(out)), err
+}
@@ -0,0 +1,210 @@
+package history
+
+import (
+	"bufio"
+	"cli/profile"
+	"cli/store"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry records a resource created from this machine.
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	PodId  string    `json:"podId,omitempty"`
+	// EndpointId is set instead of PodId for serverless endpoints.
+	EndpointId string   `json:"endpointId,omitempty"`
+	Name       string   `json:"name,omitempty"`
+	ImageName  string   `json:"imageName,omitempty"`
+	GpuType    string   `json:"gpuType,omitempty"`
+	Status     string   `json:"status,omitempty"`
+	Git        *GitInfo `json:"git,omitempty"`
+	Startup    *Startup `json:"startup,omitempty"`
+	// Profile is the config profile that was active.
+	Profile string `json:"profile,omitempty"`
+}
+
+// Startup is how long a pod took from the create request until it was
+// running, split into phases.
+type Startup struct {
+	DataCenterId string  `json:"dataCenterId,omitempty"`
+	QueueSeconds float64 `json:"queueSeconds"`
+	PullSeconds  float64 `json:"pullSeconds"`
+	StartSeconds float64 `json:"startSeconds"`
+	TotalSeconds float64 `json:"totalSeconds"`
+}
+
+// Dir is the directory holding local runpodctl state.
+func Dir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".runpod"), nil
+}
+
+func path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// Append adds an entry to the local history.
+func Append(e *Entry) error {
+	p, err := path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if e.Profile == "" {
+		e.Profile = profile.Name()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	unlock, err := store.Lock(p + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := os.OpenFile(p, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// a write cut short by a crash leaves a line without its newline; end
+	// it so the new entry is not glued to it
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			line = append([]byte{'\n'}, line...)
+		}
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Read returns all history entries, oldest first.
+func Read() (entries []*Entry, err error) {
+	p, err := path()
+	if err != nil {
+		return
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	corrupt := 0
+	for sc.Scan() {
+		e := &Entry{}
+		if json.Unmarshal(sc.Bytes(), e) == nil {
+			entries = append(entries, e)
+		} else if len(sc.Bytes()) > 0 {
+			corrupt++
+		}
+	}
+	if corrupt > 0 {
+		fmt.Fprintf(os.Stderr, "warning: skipped %d corrupt lines of %s\n", corrupt, p)
+	}
+	return entries, sc.Err()
+}
+
+// Mine returns the entries recorded under the active profile and the ones
+// from before profiles were recorded; with --cross-profile, all of them.
+func Mine(entries []*Entry) []*Entry {
+	var out []*Entry
+	for _, e := range entries {
+		if profile.Check("", e.Profile) == nil {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// ApplyAction is the action of the entries apply records for the pods it
+// creates. Those are the pods a later apply matches by name and may prune.
+const ApplyAction = "apply pod"
+
+// Applied returns the ids of the pods apply created under the active profile.
+func Applied() (map[string]bool, error) {
+	entries, err := Read()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool)
+	for _, e := range Mine(entries) {
+		if e.Action == ApplyAction && e.PodId != "" {
+			ids[e.PodId] = true
+		}
+	}
+	return ids, nil
+}
+
+// StopAction and StartAction are the actions of the entries recorded when
+// pods are stopped and started with runpodctl.
+const (
+	StopAction  = "stop pod"
+	StartAction = "start pod"
+)
+
+// IsStop reports whether action stopped a pod on purpose: stop pod, or a
+// schedule or the watchdog stopping it.
+func IsStop(action string) bool {
+	return action == StopAction || action == "schedule stop" || action == "watchdog stop"
+}
+
+// StoppedOnPurpose returns the ids of the pods whose last start or stop in
+// entries is a stop, so a pod that is not running because of it is not taken
+// for a preempted one.
+func StoppedOnPurpose(entries []*Entry) map[string]bool {
+	stopped := make(map[string]bool)
+	for _, e := range entries {
+		switch {
+		case IsStop(e.Action):
+			stopped[e.PodId] = true
+		case e.Action == StartAction || e.Action == "schedule start":
+			delete(stopped, e.PodId)
+		}
+	}
+	return stopped
+}
+
+// CheckPods refuses pods whose first history entry, normally their creation,
+// was recorded under another profile than the active one.
+func CheckPods(ids []string) error {
+	entries, err := Read()
+	if err != nil {
+		return err
+	}
+	owners := make(map[string]string)
+	for _, e := range entries {
+		if _, ok := owners[e.PodId]; e.PodId != "" && !ok {
+			owners[e.PodId] = e.Profile
+		}
+	}
+	for _, id := range ids {
+		if err := profile.Check(fmt.Sprintf(`pod "%s"`, id), owners[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
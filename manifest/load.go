@@ -0,0 +1,255 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a single manifest with its extends chain already resolved.
+type Document struct {
+	Kind   string
+	Name   string
+	Source string
+	Fields map[string]interface{}
+}
+
+// Load reads every manifest document in path ("-" for stdin), substitutes
+// the values of its params and resolves extends. Params missing from values
+// and without a default are asked for on a terminal, or are an error.
+func Load(path string, values map[string]string) (docs []*Document, err error) {
+	ps := newParams(path, values)
+	raw, err := readDocuments(path, ps)
+	if err != nil {
+		return
+	}
+	for _, fields := range raw {
+		name, _ := fields["name"].(string)
+		fields, err = resolveExtends(fields, baseDir(path), []string{filepath.Clean(path) + "#" + name}, ps)
+		if err != nil {
+			return nil, err
+		}
+		doc := &Document{Source: path, Fields: fields}
+		doc.Kind, _ = fields["kind"].(string)
+		doc.Name, _ = fields["name"].(string)
+		if doc.Kind == "" {
+			return nil, fmt.Errorf("%s: manifest %q has no kind", path, doc.Name)
+		}
+		if schema, err := SchemaFor(doc.Kind); err == nil {
+			if errs := schema.checkRequired(fields, ""); len(errs) > 0 {
+				return nil, joinErrors(fmt.Sprintf("%s: %s %q: ", path, doc.Kind, doc.Name), errs)
+			}
+		}
+		docs = append(docs, doc)
+	}
+	return docs, ps.unused(path)
+}
+
+// Decode converts the document into a typed manifest, rejecting unknown fields.
+func (d *Document) Decode(v interface{}) error {
+	out, err := yaml.Marshal(d.Fields)
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(out))
+	dec.KnownFields(true)
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%s: %s %q: %w", d.Source, d.Kind, d.Name, err)
+	}
+	return nil
+}
+
+// Pod decodes a document of kind pod.
+func (d *Document) Pod() (*Pod, error) {
+	if d.Kind != KindPod {
+		return nil, fmt.Errorf("%s: %q is a %s, not a pod", d.Source, d.Name, d.Kind)
+	}
+	p := &Pod{}
+	if err := d.Decode(p); err != nil {
+		return nil, err
+	}
+	if p.Spec == nil {
+		return nil, fmt.Errorf("%s: pod %q has no spec", d.Source, p.Name)
+	}
+	return p, nil
+}
+
+// Pods decodes the pods in docs, with the pods that services in pod mode
+// run as. Other kinds are skipped.
+func Pods(docs []*Document) ([]*Pod, error) {
+	var pods []*Pod
+	for _, doc := range docs {
+		switch doc.Kind {
+		case KindPod:
+			p, err := doc.Pod()
+			if err != nil {
+				return nil, err
+			}
+			pods = append(pods, p)
+		case KindService:
+			s, err := doc.Service()
+			if err != nil {
+				return nil, err
+			}
+			if s.Spec.Mode == ModePod {
+				pods = append(pods, s.Pod())
+			}
+		}
+	}
+	return pods, nil
+}
+
+func readDocuments(path string, ps *params) (docs []map[string]interface{}, err error) {
+	var r io.Reader
+	if path == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	dec := yaml.NewDecoder(r)
+	for {
+		var node yaml.Node
+		err = dec.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if len(node.Content) > 0 {
+			if err := ps.apply(path, node.Content[0]); err != nil {
+				return nil, err
+			}
+		}
+		if errs := validate(&node); len(errs) > 0 {
+			return nil, joinErrors(path+":", errs)
+		}
+		fields := make(map[string]interface{})
+		if err = node.Decode(&fields); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if len(fields) > 0 {
+			docs = append(docs, fields)
+		}
+	}
+}
+
+func baseDir(path string) string {
+	if path == "-" {
+		return "."
+	}
+	return filepath.Dir(path)
+}
+
+// resolveExtends merges the document over its base manifests, following
+// `extends: base.yaml` or `extends: base.yaml#name` references recursively.
+func resolveExtends(fields map[string]interface{}, dir string, chain []string, ps *params) (map[string]interface{}, error) {
+	ext, ok := fields["extends"]
+	if !ok {
+		return fields, nil
+	}
+	delete(fields, "extends")
+
+	var refs []string
+	switch v := ext.(type) {
+	case string:
+		refs = []string{v}
+	case []interface{}:
+		for _, r := range v {
+			s, ok := r.(string)
+			if !ok {
+				return nil, fmt.Errorf("extends must be a file name or a list of file names")
+			}
+			refs = append(refs, s)
+		}
+	default:
+		return nil, fmt.Errorf("extends must be a file name or a list of file names")
+	}
+
+	base := make(map[string]interface{})
+	for _, ref := range refs {
+		file, name := ref, ""
+		if i := strings.LastIndex(ref, "#"); i >= 0 {
+			file, name = ref[:i], ref[i+1:]
+		}
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		key := filepath.Clean(file) + "#" + name
+		for _, seen := range chain {
+			if seen == key {
+				return nil, fmt.Errorf("extends cycle: %s -> %s", strings.Join(chain, " -> "), key)
+			}
+		}
+		parent, err := selectDocument(file, name, ps)
+		if err != nil {
+			return nil, err
+		}
+		parent, err = resolveExtends(parent, filepath.Dir(file), append(chain, key), ps)
+		if err != nil {
+			return nil, err
+		}
+		base = merge(base, parent)
+	}
+	return merge(base, fields), nil
+}
+
+func selectDocument(file string, name string, ps *params) (map[string]interface{}, error) {
+	docs, err := readDocuments(file, ps)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		if len(docs) != 1 {
+			return nil, fmt.Errorf("%s has %d documents; use extends: %s#<name>", file, len(docs), file)
+		}
+		return docs[0], nil
+	}
+	for _, d := range docs {
+		if d["name"] == name {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: no manifest named %q", file, name)
+}
+
+// validate checks a document against the schema of its kind so mistakes are
+// reported with their line and column. Documents of other kinds are left to
+// apply, which rejects them.
+func validate(doc *yaml.Node) []error {
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	root := doc.Content[0]
+	kind := ""
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "kind" {
+			kind = root.Content[i+1].Value
+		}
+	}
+	schema, err := SchemaFor(kind)
+	if err != nil || kind == KindAll {
+		return nil
+	}
+	return schema.validate(root, "")
+}
+
+// joinErrors reports every error on its own line, each starting with prefix.
+func joinErrors(prefix string, errs []error) error {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = prefix + err.Error()
+	}
+	return errors.New(strings.Join(msgs, "\n"))
+}
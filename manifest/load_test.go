@@ -0,0 +1,72 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeManifest(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadMergeKeys(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "base.yaml", `
+kind: pod
+name: base
+spec:
+  imageName: runpod/pytorch:2.0
+  gpuType: NVIDIA GeForce RTX 3090
+  gpuCount: 1
+  containerDiskInGb: 20
+  volumeInGb: 50
+  ports: [8888/http, 22/tcp]
+`)
+	path := writeManifest(t, dir, "train.yaml", `
+kind: pod
+name: train
+extends: base.yaml
+spec:
+  ports+: [6006/http]
+  ports-: 22/tcp
+`)
+	docs, err := Load(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod, err := docs[0].Pod()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"8888/http", "6006/http"}; !reflect.DeepEqual(pod.Spec.Ports, want) {
+		t.Errorf("ports = %v, want %v", pod.Spec.Ports, want)
+	}
+}
+
+func TestLoadMergeKeyOfUnknownField(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "base.yaml", `
+kind: pod
+name: base
+spec: {imageName: x, gpuType: y, gpuCount: 1, containerDiskInGb: 10, volumeInGb: 0}
+`)
+	path := writeManifest(t, dir, "train.yaml", `
+kind: pod
+name: train
+extends: base.yaml
+spec:
+  portz+: [6006/http]
+`)
+	_, err := Load(path, nil)
+	if err == nil || !strings.Contains(err.Error(), "spec.portz+: unknown field") {
+		t.Fatalf("err = %v, want an unknown field error", err)
+	}
+}
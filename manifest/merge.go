@@ -0,0 +1,66 @@
+package manifest
+
+import (
+	"reflect"
+	"strings"
+)
+
+// merge deep merges override into base and returns the result.
+//
+// Maps are merged key by key and a null value removes the key. Lists are
+// replaced by default; a key suffixed with "+" appends to the base list
+// (e.g. `ports+: [6006/http]`) and a key suffixed with "-" removes the
+// listed items from it.
+func merge(base map[string]interface{}, override map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range override {
+		switch {
+		case strings.HasSuffix(k, "+"):
+			k = strings.TrimSuffix(k, "+")
+			list, _ := out[k].([]interface{})
+			out[k] = append(append([]interface{}{}, list...), toList(v)...)
+		case strings.HasSuffix(k, "-"):
+			k = strings.TrimSuffix(k, "-")
+			list, _ := out[k].([]interface{})
+			out[k] = without(list, toList(v))
+		case v == nil:
+			delete(out, k)
+		default:
+			baseMap, ok1 := out[k].(map[string]interface{})
+			overrideMap, ok2 := v.(map[string]interface{})
+			if ok1 && ok2 {
+				out[k] = merge(baseMap, overrideMap)
+			} else {
+				out[k] = v
+			}
+		}
+	}
+	return out
+}
+
+func toList(v interface{}) []interface{} {
+	if list, ok := v.([]interface{}); ok {
+		return list
+	}
+	return []interface{}{v}
+}
+
+func without(list []interface{}, remove []interface{}) []interface{} {
+	out := []interface{}{}
+	for _, item := range list {
+		keep := true
+		for _, r := range remove {
+			if reflect.DeepEqual(item, r) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package manifest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Levels groups pods by their dependsOn: every pod comes in a later level
+// than the pods it depends on, and pods in one level do not depend on each
+// other. Pods keep their manifest order within a level.
+func Levels(pods []*Pod) ([][]*Pod, error) {
+	level, err := levels(pods)
+	if err != nil {
+		return nil, err
+	}
+	var out [][]*Pod
+	for _, p := range pods {
+		l := level[p.Name]
+		for len(out) <= l {
+			out = append(out, nil)
+		}
+		out[l] = append(out[l], p)
+	}
+	return out, nil
+}
+
+// OrderPods moves the pod actions that create, update or replace pods into
+// dependency order, leaving every other action where it is.
+func OrderPods(actions []*Action) error {
+	var slots []int
+	var pods []*Pod
+	for i, a := range actions {
+		if a.Kind == KindPod && a.Pod != nil {
+			slots = append(slots, i)
+			pods = append(pods, a.Pod)
+		}
+	}
+	level, err := levels(pods)
+	if err != nil {
+		return err
+	}
+	ordered := make([]*Action, len(slots))
+	for i, slot := range slots {
+		ordered[i] = actions[slot]
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return level[ordered[i].Name] < level[ordered[j].Name]
+	})
+	for i, slot := range slots {
+		actions[slot] = ordered[i]
+	}
+	return nil
+}
+
+// levels returns the level of every pod by name: 0 for pods without
+// dependencies, else one more than the deepest pod they depend on.
+func levels(pods []*Pod) (map[string]int, error) {
+	byName := make(map[string]*Pod, len(pods))
+	for _, p := range pods {
+		byName[p.Name] = p
+	}
+	level := make(map[string]int, len(pods))
+	visiting := make(map[string]bool)
+	var visit func(p *Pod, path []string) (int, error)
+	visit = func(p *Pod, path []string) (int, error) {
+		if l, ok := level[p.Name]; ok {
+			return l, nil
+		}
+		if visiting[p.Name] {
+			for i, name := range path {
+				if name == p.Name {
+					path = path[i:]
+					break
+				}
+			}
+			return 0, fmt.Errorf("pods depend on each other in a cycle: %s", strings.Join(append(path, p.Name), " -> "))
+		}
+		path = append(path, p.Name)
+		visiting[p.Name] = true
+		l := 0
+		for _, name := range p.Spec.DependsOn {
+			dep, ok := byName[name]
+			if !ok {
+				return 0, fmt.Errorf(`pod "%s" depends on "%s", which is not a pod in the manifest`, p.Name, name)
+			}
+			depLevel, err := visit(dep, path)
+			if err != nil {
+				return 0, err
+			}
+			if depLevel+1 > l {
+				l = depLevel + 1
+			}
+		}
+		visiting[p.Name] = false
+		level[p.Name] = l
+		return l, nil
+	}
+	for _, p := range pods {
+		if _, err := visit(p, nil); err != nil {
+			return nil, err
+		}
+	}
+	return level, nil
+}
@@ -0,0 +1,222 @@
+package manifest
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+// Param declares an input of a manifest, given with --param NAME=value and
+// referenced as ${NAME} in the manifest's values, e.g.
+//
+//	params:
+//	  MODEL_NAME: {description: hugging face model to serve}
+//	  GPU_COUNT: {type: integer, default: 1}
+//	spec:
+//	  gpuCount: ${GPU_COUNT}
+//	  env: {MODEL: "${MODEL_NAME}"}
+//
+// A param without a default is required. A value that is only a reference
+// to a typed param takes its type, so gpuCount above is an integer.
+type Param struct {
+	Type        string  `yaml:"type,omitempty" schema:"enum=string|integer|number|boolean"`
+	Default     *string `yaml:"default,omitempty"`
+	Description string  `yaml:"description,omitempty"`
+}
+
+var paramRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ParseParams parses NAME=value flags.
+func ParseParams(flags []string) (map[string]string, error) {
+	values := make(map[string]string, len(flags))
+	for _, f := range flags {
+		i := strings.Index(f, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid --param %q: use NAME=value", f)
+		}
+		values[f[:i]] = f[i+1:]
+	}
+	return values, nil
+}
+
+// params resolves the params of the documents of a Load. Values missing
+// from given are asked for on a terminal, once per name.
+type params struct {
+	given       map[string]string
+	asked       map[string]string
+	used        map[string]bool
+	interactive bool
+}
+
+func newParams(path string, given map[string]string) *params {
+	return &params{
+		given:       given,
+		asked:       make(map[string]string),
+		used:        make(map[string]bool),
+		interactive: path != "-" && term.IsTerminal(int(os.Stdin.Fd())),
+	}
+}
+
+// unused reports the given params no document declared.
+func (ps *params) unused(path string) error {
+	var names []string
+	for name := range ps.given {
+		if !ps.used[name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	return fmt.Errorf("%s declares no param %s", path, strings.Join(names, ", "))
+}
+
+// apply removes the params a document declares and substitutes their
+// values, so the document is validated as if they had been written in.
+func (ps *params) apply(path string, root *yaml.Node) error {
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+	var decl *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "params" {
+			decl = root.Content[i+1]
+			root.Content = append(root.Content[:i], root.Content[i+2:]...)
+			break
+		}
+	}
+	if decl == nil {
+		return nil
+	}
+	declared := make(map[string]*Param)
+	if err := decl.Decode(&declared); err != nil {
+		return fmt.Errorf("%s:%d: params: %w", path, decl.Line, err)
+	}
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make(map[string]string, len(declared))
+	var errs, missing []string
+	for _, name := range names {
+		p := declared[name]
+		if p == nil {
+			p = &Param{}
+			declared[name] = p
+		}
+		ps.used[name] = true
+		value, ok := ps.given[name]
+		if !ok {
+			value, ok = ps.asked[name]
+		}
+		if !ok && p.Default != nil {
+			value, ok = *p.Default, true
+		}
+		if !ok && ps.interactive {
+			value, ok = ps.ask(name, p), true
+		}
+		if !ok {
+			missing = append(missing, name+describe(p))
+			continue
+		}
+		value, err := p.check(value)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("param %s: %s", name, err))
+		}
+		values[name] = value
+	}
+	if len(missing) > 0 {
+		errs = append(errs, "missing required params; give them with --param NAME=value: "+strings.Join(missing, ", "))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s: %s", path, strings.Join(errs, "; "))
+	}
+	substitute(root, declared, values)
+	return nil
+}
+
+func (ps *params) ask(name string, p *Param) string {
+	fmt.Fprintf(os.Stderr, "%s%s: ", name, describe(p))
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	ps.asked[name] = answer
+	return answer
+}
+
+func describe(p *Param) string {
+	if p.Description == "" {
+		return ""
+	}
+	return " (" + p.Description + ")"
+}
+
+// check validates a value against the param's type and returns it as yaml
+// writes that type.
+func (p *Param) check(value string) (string, error) {
+	var out string
+	var err error
+	switch p.Type {
+	case "integer":
+		var i int
+		i, err = strconv.Atoi(value)
+		out = strconv.Itoa(i)
+	case "number":
+		var f float64
+		f, err = strconv.ParseFloat(value, 64)
+		out = strconv.FormatFloat(f, 'g', -1, 64)
+	case "boolean":
+		var b bool
+		b, err = strconv.ParseBool(value)
+		out = strconv.FormatBool(b)
+	case "", "string":
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown type %q: use string, integer, number or boolean", p.Type)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%q is not a valid %s", value, p.Type)
+	}
+	return out, nil
+}
+
+// substitute replaces the references to declared params in the string
+// values under n. Other ${...} text, e.g. shell variables in dockerArgs, is
+// left alone.
+func substitute(n *yaml.Node, declared map[string]*Param, values map[string]string) {
+	if n.Kind == yaml.ScalarNode {
+		if n.Tag != "!!str" || !strings.Contains(n.Value, "${") {
+			return
+		}
+		if m := paramRef.FindStringSubmatch(n.Value); m != nil && m[0] == n.Value && declared[m[1]] != nil {
+			if tag, ok := map[string]string{"integer": "!!int", "number": "!!float", "boolean": "!!bool"}[declared[m[1]].Type]; ok {
+				n.Value, n.Tag, n.Style = values[m[1]], tag, 0
+				return
+			}
+		}
+		n.Value = paramRef.ReplaceAllStringFunc(n.Value, func(ref string) string {
+			name := paramRef.FindStringSubmatch(ref)[1]
+			if declared[name] == nil {
+				return ref
+			}
+			return values[name]
+		})
+		return
+	}
+	for i, c := range n.Content {
+		// mapping keys are names, not values
+		if n.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		substitute(c, declared, values)
+	}
+}
@@ -0,0 +1,284 @@
+package manifest
+
+import (
+	"cli/api"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Op string
+
+const (
+	OpCreate    Op = "create"
+	OpUpdate    Op = "update"
+	OpReplace   Op = "replace"
+	OpDelete    Op = "delete"
+	OpUnchanged Op = "unchanged"
+)
+
+// Change is a single field that differs between the live and the desired spec.
+type Change struct {
+	Field string
+	From  string
+	To    string
+}
+
+// Action is one step of an apply plan. Resources are matched by name.
+type Action struct {
+	Op       Op
+	Kind     string
+	Name     string
+	Id       string
+	Changes  []Change
+	Pod      *Pod
+	Template *Template
+	Endpoint *Endpoint
+}
+
+func (a *Action) String() string {
+	var sign, verb string
+	switch a.Op {
+	case OpCreate:
+		sign, verb = "+", "will be created"
+	case OpUpdate:
+		sign, verb = "~", "will be updated"
+	case OpReplace:
+		sign, verb = "+/-", "will be created again, then the old pod terminated"
+	case OpDelete:
+		sign, verb = "-", "will be terminated"
+		if a.Kind == KindTemplate {
+			verb = "will be deleted"
+		}
+	default:
+		sign, verb = "=", "is unchanged"
+	}
+	s := fmt.Sprintf("%s %s %q %s", sign, a.Kind, a.Name, verb)
+	for _, c := range a.Changes {
+		s += fmt.Sprintf("\n    %s: %s -> %s", c.Field, c.From, c.To)
+	}
+	return s
+}
+
+// PlanPods diffs the desired pods against the live ones. managed holds the
+// ids of the pods apply created: when several live pods share a manifest
+// pod's name, the managed one is matched, and only managed pods that are not
+// in the manifest are deleted, and only when prune is set.
+func PlanPods(desired []*Pod, live []*api.Pod, managed map[string]bool, prune bool) ([]*Action, error) {
+	byName := make(map[string][]*api.Pod, len(live))
+	for _, p := range live {
+		byName[p.Name] = append(byName[p.Name], p)
+	}
+
+	var actions []*Action
+	seen := make(map[string]bool, len(desired))
+	for _, d := range desired {
+		if d.Name == "" {
+			return nil, fmt.Errorf("every pod in the manifest needs a name")
+		}
+		if seen[d.Name] {
+			return nil, fmt.Errorf(`pod "%s" is declared more than once`, d.Name)
+		}
+		seen[d.Name] = true
+		a := &Action{Kind: KindPod, Name: d.Name, Pod: d, Op: OpCreate}
+		p, err := matchPod(d.Name, byName[d.Name], managed)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			a.Id = p.Id
+			a.Changes = diffPod(FromPod(p).Spec, d.Spec)
+			a.Op = OpUnchanged
+			if len(a.Changes) > 0 {
+				a.Op = OpReplace
+				if editable(a.Changes) {
+					a.Op = OpUpdate
+				}
+			}
+		}
+		actions = append(actions, a)
+	}
+	if prune {
+		for _, p := range live {
+			if managed[p.Id] && !seen[p.Name] {
+				actions = append(actions, &Action{Op: OpDelete, Kind: KindPod, Name: p.Name, Id: p.Id})
+			}
+		}
+	}
+	return actions, nil
+}
+
+// matchPod picks the live pod a manifest pod named name stands for among the
+// pods of that name, preferring the one apply created.
+func matchPod(name string, pods []*api.Pod, managed map[string]bool) (*api.Pod, error) {
+	switch len(pods) {
+	case 0:
+		return nil, nil
+	case 1:
+		return pods[0], nil
+	}
+	var mine []*api.Pod
+	for _, p := range pods {
+		if managed[p.Id] {
+			mine = append(mine, p)
+		}
+	}
+	if len(mine) == 1 {
+		return mine[0], nil
+	}
+	return nil, fmt.Errorf(`more than one pod is named "%s"; apply matches pods by name, so rename or remove the others`, name)
+}
+
+// PlanTemplates diffs the desired templates against the live ones.
+func PlanTemplates(desired []*Template, live []*api.Template) ([]*Action, error) {
+	byName := make(map[string]*api.Template, len(live))
+	for _, t := range live {
+		byName[t.Name] = t
+	}
+	var actions []*Action
+	seen := make(map[string]bool, len(desired))
+	for _, d := range desired {
+		if seen[d.Name] {
+			return nil, fmt.Errorf(`template "%s" is declared more than once`, d.Name)
+		}
+		seen[d.Name] = true
+		a := &Action{Kind: KindTemplate, Name: d.Name, Template: d, Op: OpCreate}
+		if t, ok := byName[d.Name]; ok {
+			a.Id = t.Id
+			a.Changes = diffTemplate(FromTemplate(t).Spec, d.Spec)
+			a.Op = OpUnchanged
+			if len(a.Changes) > 0 {
+				a.Op = OpUpdate
+			}
+		}
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
+
+// editable reports whether every change can be made in place with
+// api.UpdatePod. Volumes can only grow in place.
+func editable(changes []Change) bool {
+	for _, c := range changes {
+		switch {
+		case c.Field == "imageName", c.Field == "containerDiskInGb", c.Field == "volumeMountPath",
+			c.Field == "dockerArgs", c.Field == "ports", strings.HasPrefix(c.Field, "env."):
+		case c.Field == "volumeInGb":
+			from, _ := strconv.Atoi(c.From)
+			to, _ := strconv.Atoi(c.To)
+			if to < from {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// diffPod compares the fields a manifest sets. Fields left empty in the
+// manifest keep whatever the pod has, and minimum memory and vcpu only
+// differ when the pod has less than the manifest asks for.
+func diffPod(live *PodSpec, want *PodSpec) []Change {
+	var changes []Change
+	str := func(field string, from string, to string) {
+		if to != "" && from != to {
+			changes = append(changes, Change{field, from, to})
+		}
+	}
+	num := func(field string, from int, to int) {
+		if from != to {
+			changes = append(changes, Change{field, fmt.Sprint(from), fmt.Sprint(to)})
+		}
+	}
+	min := func(field string, from int, to int) {
+		if from < to {
+			changes = append(changes, Change{field, fmt.Sprint(from), fmt.Sprint(to)})
+		}
+	}
+	str("imageName", live.ImageName, want.ImageName)
+	str("gpuType", live.GpuType, want.GpuType)
+	num("gpuCount", live.GpuCount, want.GpuCount)
+	cloudType := want.CloudType
+	if cloudType == "" {
+		cloudType = "COMMUNITY"
+	}
+	str("cloudType", live.CloudType, cloudType)
+	num("containerDiskInGb", live.ContainerDiskInGb, want.ContainerDiskInGb)
+	num("volumeInGb", live.VolumeInGb, want.VolumeInGb)
+	str("volumeMountPath", live.VolumeMountPath, want.VolumeMountPath)
+	min("minMemoryInGb", live.MinMemoryInGb, want.MinMemoryInGb)
+	min("minVcpuCount", live.MinVcpuCount, want.MinVcpuCount)
+	if live.DockerArgs != want.DockerArgs {
+		changes = append(changes, Change{"dockerArgs", quoteOrNone(live.DockerArgs), quoteOrNone(want.DockerArgs)})
+	}
+	str("templateId", live.TemplateId, want.TemplateId)
+	str("networkVolumeId", live.NetworkVolumeId, want.NetworkVolumeId)
+	changes = append(changes, diffLists("ports", live.Ports, want.Ports)...)
+	changes = append(changes, diffEnv(live.Env, want.Env)...)
+	return changes
+}
+
+func diffTemplate(live *TemplateSpec, want *TemplateSpec) []Change {
+	var changes []Change
+	str := func(field string, from string, to string) {
+		if from != to {
+			changes = append(changes, Change{field, quoteOrNone(from), quoteOrNone(to)})
+		}
+	}
+	num := func(field string, from int, to int) {
+		if from != to {
+			changes = append(changes, Change{field, fmt.Sprint(from), fmt.Sprint(to)})
+		}
+	}
+	str("imageName", live.ImageName, want.ImageName)
+	num("containerDiskInGb", live.ContainerDiskInGb, want.ContainerDiskInGb)
+	num("volumeInGb", live.VolumeInGb, want.VolumeInGb)
+	str("volumeMountPath", live.VolumeMountPath, want.VolumeMountPath)
+	str("dockerArgs", live.DockerArgs, want.DockerArgs)
+	str("readme", live.Readme, want.Readme)
+	if live.Serverless != want.Serverless {
+		changes = append(changes, Change{"serverless", fmt.Sprint(live.Serverless), fmt.Sprint(want.Serverless)})
+	}
+	changes = append(changes, diffLists("ports", live.Ports, want.Ports)...)
+	changes = append(changes, diffEnv(live.Env, want.Env)...)
+	return changes
+}
+
+// diffLists compares two lists ignoring order.
+func diffLists(field string, from []string, to []string) []Change {
+	a := append([]string(nil), from...)
+	b := append([]string(nil), to...)
+	sort.Strings(a)
+	sort.Strings(b)
+	if strings.Join(a, ",") == strings.Join(b, ",") {
+		return nil
+	}
+	return []Change{{field, "[" + strings.Join(from, ",") + "]", "[" + strings.Join(to, ",") + "]"}}
+}
+
+// diffEnv reports changed keys only; values may be secrets and are not shown.
+func diffEnv(from map[string]string, to map[string]string) []Change {
+	var changes []Change
+	for _, k := range sortedKeys(to) {
+		if v, ok := from[k]; !ok {
+			changes = append(changes, Change{"env." + k, "(none)", "(set)"})
+		} else if v != to[k] {
+			changes = append(changes, Change{"env." + k, "(set)", "(changed)"})
+		}
+	}
+	for _, k := range sortedKeys(from) {
+		if _, ok := to[k]; !ok {
+			changes = append(changes, Change{"env." + k, "(set)", "(none)"})
+		}
+	}
+	return changes
+}
+
+func quoteOrNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return fmt.Sprintf("%q", s)
+}
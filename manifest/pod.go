@@ -0,0 +1,224 @@
+package manifest
+
+import (
+	"cli/api"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const KindPod = "pod"
+
+type Pod struct {
+	Kind string   `yaml:"kind" schema:"required"`
+	Name string   `yaml:"name" schema:"required"`
+	Spec *PodSpec `yaml:"spec" schema:"required"`
+}
+
+type PodSpec struct {
+	ImageName         string            `yaml:"imageName"`
+	GpuType           string            `yaml:"gpuType" schema:"required"`
+	GpuCount          int               `yaml:"gpuCount"`
+	CloudType         string            `yaml:"cloudType,omitempty" schema:"enum=SECURE|COMMUNITY"`
+	ContainerDiskInGb int               `yaml:"containerDiskInGb"`
+	VolumeInGb        int               `yaml:"volumeInGb"`
+	VolumeMountPath   string            `yaml:"volumeMountPath,omitempty"`
+	MinMemoryInGb     int               `yaml:"minMemoryInGb,omitempty"`
+	MinVcpuCount      int               `yaml:"minVcpuCount,omitempty"`
+	DockerArgs        string            `yaml:"dockerArgs,omitempty"`
+	Ports             []string          `yaml:"ports,omitempty"`
+	Env               map[string]string `yaml:"env,omitempty"`
+	TemplateId        string            `yaml:"templateId,omitempty"`
+	NetworkVolumeId   string            `yaml:"networkVolumeId,omitempty"`
+	// BidPerGpu makes the pod a spot pod bidding that much per gpu per hour.
+	BidPerGpu      float32 `yaml:"bidPerGpu,omitempty"`
+	RegistryAuthId string  `yaml:"registryAuthId,omitempty"`
+	// DependsOn names the pods of the manifest that must be running before
+	// this one is started, and that are stopped after it.
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+}
+
+// FromPod builds the manifest that reproduces an existing pod.
+func FromPod(p *api.Pod) *Pod {
+	spec := &PodSpec{
+		ImageName:         p.ImageName,
+		GpuCount:          p.GpuCount,
+		ContainerDiskInGb: p.ContainerDiskInGb,
+		VolumeInGb:        p.VolumeInGb,
+		VolumeMountPath:   p.VolumeMountPath,
+		MinMemoryInGb:     p.MemoryInGb,
+		MinVcpuCount:      p.VcpuCount,
+		DockerArgs:        p.DockerArgs,
+		TemplateId:        p.TemplateId,
+		NetworkVolumeId:   p.NetworkVolumeId,
+		BidPerGpu:         p.BidPerGpu(),
+		RegistryAuthId:    p.ContainerRegistryAuthId,
+		CloudType:         "COMMUNITY",
+	}
+	if p.Machine != nil {
+		spec.GpuType = p.Machine.GpuTypeId
+		if p.Machine.SecureCloud {
+			spec.CloudType = "SECURE"
+		}
+	}
+	if p.Ports != "" {
+		spec.Ports = strings.Split(p.Ports, ",")
+	}
+	if len(p.Env) > 0 {
+		spec.Env = make(map[string]string, len(p.Env))
+		for _, v := range p.Env {
+			kv := strings.SplitN(v, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			spec.Env[kv[0]] = kv[1]
+		}
+	}
+	return &Pod{Kind: KindPod, Name: p.Name, Spec: spec}
+}
+
+// CreatePodInput converts the manifest into the input of api.CreatePod.
+func (p *Pod) CreatePodInput() *api.CreatePodInput {
+	s := p.Spec
+	input := &api.CreatePodInput{
+		BidPerGpu:               s.BidPerGpu,
+		CloudType:               s.CloudType,
+		ContainerDiskInGb:       s.ContainerDiskInGb,
+		ContainerRegistryAuthId: s.RegistryAuthId,
+		DockerArgs:              s.DockerArgs,
+		GpuCount:                s.GpuCount,
+		GpuTypeId:               s.GpuType,
+		ImageName:               s.ImageName,
+		MinMemoryInGb:           s.MinMemoryInGb,
+		MinVcpuCount:            s.MinVcpuCount,
+		Name:                    p.Name,
+		NetworkVolumeId:         s.NetworkVolumeId,
+		Ports:                   strings.Join(s.Ports, ","),
+		TemplateId:              s.TemplateId,
+		VolumeInGb:              s.VolumeInGb,
+		VolumeMountPath:         s.VolumeMountPath,
+	}
+	if input.CloudType == "" {
+		input.CloudType = "COMMUNITY"
+	}
+	input.Env = make([]*api.PodEnv, 0, len(s.Env))
+	for _, k := range sortedKeys(s.Env) {
+		input.Env = append(input.Env, &api.PodEnv{Key: k, Value: s.Env[k]})
+	}
+	return input
+}
+
+// UpdatePodInput converts the manifest into the input of api.UpdatePod for
+// the live pod. Strings left empty in the manifest keep the pod's value.
+func (p *Pod) UpdatePodInput(live *api.Pod) *api.UpdatePodInput {
+	s := p.Spec
+	input := &api.UpdatePodInput{
+		PodId:             live.Id,
+		ContainerDiskInGb: s.ContainerDiskInGb,
+		DockerArgs:        s.DockerArgs,
+		ImageName:         s.ImageName,
+		Ports:             strings.Join(s.Ports, ","),
+		VolumeInGb:        s.VolumeInGb,
+		VolumeMountPath:   s.VolumeMountPath,
+	}
+	if input.ImageName == "" {
+		input.ImageName = live.ImageName
+	}
+	if input.VolumeMountPath == "" {
+		input.VolumeMountPath = live.VolumeMountPath
+	}
+	input.Env = make([]*api.PodEnv, 0, len(s.Env))
+	for _, k := range sortedKeys(s.Env) {
+		input.Env = append(input.Env, &api.PodEnv{Key: k, Value: s.Env[k]})
+	}
+	return input
+}
+
+// CreateCommand renders the `runpodctl create pod` invocation that reproduces the manifest.
+func (p *Pod) CreateCommand() string {
+	s := p.Spec
+	args := []string{"runpodctl", "create", "pod"}
+	flag := func(name string, value string) {
+		args = append(args, "--"+name, shellQuote(value))
+	}
+	if p.Name != "" {
+		flag("name", p.Name)
+	}
+	flag("imageName", s.ImageName)
+	flag("gpuType", s.GpuType)
+	flag("gpuCount", fmt.Sprint(s.GpuCount))
+	if s.CloudType == "SECURE" {
+		args = append(args, "--secureCloud")
+	} else {
+		args = append(args, "--communityCloud")
+	}
+	flag("containerDiskSize", fmt.Sprint(s.ContainerDiskInGb))
+	flag("volumeSize", fmt.Sprint(s.VolumeInGb))
+	if s.VolumeMountPath != "" {
+		flag("volumePath", s.VolumeMountPath)
+	}
+	if s.MinMemoryInGb > 0 {
+		flag("mem", fmt.Sprint(s.MinMemoryInGb))
+	}
+	if s.MinVcpuCount > 0 {
+		flag("vcpu", fmt.Sprint(s.MinVcpuCount))
+	}
+	if s.DockerArgs != "" {
+		flag("args", s.DockerArgs)
+	}
+	for _, port := range s.Ports {
+		flag("ports", port)
+	}
+	for _, k := range sortedKeys(s.Env) {
+		flag("env", k+"="+s.Env[k])
+	}
+	if s.TemplateId != "" {
+		flag("templateId", s.TemplateId)
+	}
+	if s.NetworkVolumeId != "" {
+		flag("network-volume-id", s.NetworkVolumeId)
+	}
+	if s.BidPerGpu > 0 {
+		flag("bid", fmt.Sprint(s.BidPerGpu))
+	}
+	if s.RegistryAuthId != "" {
+		flag("registry-auth-id", s.RegistryAuthId)
+	}
+	return strings.Join(args, " ")
+}
+
+// Marshal encodes manifests as a multi-document YAML stream.
+func Marshal(docs ...interface{}) ([]byte, error) {
+	var sb strings.Builder
+	enc := yaml.NewEncoder(&sb)
+	enc.SetIndent(2)
+	for _, d := range docs {
+		if err := enc.Encode(d); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(sb.String()), nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=,@%+", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,321 @@
+package manifest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is the subset of JSON Schema (draft-07) the manifest schemas use.
+// The schemas are generated from the manifest types, so they are built into
+// the binary and never drift from what apply decodes.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Const                string             `json:"const,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	If                   *Schema            `json:"if,omitempty"`
+	Then                 *Schema            `json:"then,omitempty"`
+}
+
+const draft07 = "http://json-schema.org/draft-07/schema#"
+
+// KindAll names the schema that accepts a document of any kind.
+const KindAll = "manifest"
+
+var kindTypes = map[string]reflect.Type{
+	KindPod:      reflect.TypeOf(Pod{}),
+	KindService:  reflect.TypeOf(Service{}),
+	KindTemplate: reflect.TypeOf(Template{}),
+}
+
+// Kinds lists the manifest kinds that have a schema.
+func Kinds() []string {
+	var kinds []string
+	for k := range kindTypes {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// SchemaFor returns the schema of a manifest kind, or of any manifest for
+// KindAll.
+func SchemaFor(kind string) (*Schema, error) {
+	if kind == KindAll {
+		s := &Schema{Schema: draft07, Title: "runpodctl manifest", Type: "object"}
+		for _, k := range Kinds() {
+			s.AllOf = append(s.AllOf, &Schema{
+				If:   &Schema{Properties: map[string]*Schema{"kind": {Const: k}}, Required: []string{"kind"}},
+				Then: kindSchema(k),
+			})
+		}
+		s.Properties = map[string]*Schema{"kind": {Type: "string", Enum: Kinds()}}
+		s.Required = []string{"kind"}
+		return s, nil
+	}
+	if _, ok := kindTypes[kind]; !ok {
+		return nil, fmt.Errorf("unknown kind %q: use one of %s or %s", kind, strings.Join(Kinds(), ", "), KindAll)
+	}
+	s := kindSchema(kind)
+	s.Schema = draft07
+	s.Title = "runpodctl " + kind + " manifest"
+	return s, nil
+}
+
+func kindSchema(kind string) *Schema {
+	s := typeSchema(kindTypes[kind])
+	s.Properties["kind"].Const = kind
+	s.Properties["extends"] = &Schema{AnyOf: []*Schema{
+		{Type: "string"},
+		{Type: "array", Items: &Schema{Type: "string"}},
+	}}
+	param := typeSchema(reflect.TypeOf(Param{}))
+	param.Properties["default"] = &Schema{AnyOf: []*Schema{{Type: "string"}, {Type: "number"}, {Type: "boolean"}}}
+	s.Properties["params"] = &Schema{Type: "object", AdditionalProperties: param}
+	return s
+}
+
+// typeSchema describes a manifest type by its yaml tags. A `schema` tag marks
+// fields as required and lists allowed values, e.g. `schema:"required,enum=a|b"`.
+func typeSchema(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice:
+		return &Schema{Type: "array", Items: typeSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: typeSchema(t.Elem())}
+	}
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fs := typeSchema(f.Type)
+		for _, opt := range strings.Split(f.Tag.Get("schema"), ",") {
+			switch {
+			case opt == "required":
+				s.Required = append(s.Required, name)
+			case strings.HasPrefix(opt, "enum="):
+				fs.Enum = strings.Split(strings.TrimPrefix(opt, "enum="), "|")
+			}
+		}
+		s.Properties[name] = fs
+	}
+	return s
+}
+
+// validate checks a yaml node against the schema and returns an error per
+// problem, each with the line and column it was found at. Required fields are
+// left to checkRequired because documents that extend others may omit them.
+func (s *Schema) validate(n *yaml.Node, path string) []error {
+	for n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!null" {
+		return nil
+	}
+	if len(s.AnyOf) > 0 {
+		var errs []error
+		for _, alt := range s.AnyOf {
+			if errs = alt.validate(n, path); len(errs) == 0 {
+				return nil
+			}
+		}
+		return errs
+	}
+	if got := nodeType(n); !typeMatches(s.Type, got, n) {
+		return []error{nodeError(n, path, "expected %s, got %s", s.Type, got)}
+	}
+	if s.Const != "" && n.Value != s.Const {
+		return []error{nodeError(n, path, "must be %q", s.Const)}
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, v := range s.Enum {
+			found = found || n.Value == v
+		}
+		if !found {
+			return []error{nodeError(n, path, "%q is not one of %s", n.Value, strings.Join(s.Enum, ", "))}
+		}
+	}
+
+	var errs []error
+	switch s.Type {
+	case "array":
+		for i, item := range n.Content {
+			errs = append(errs, s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "object":
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			child := joinPath(path, key.Value)
+			if prop, ok := s.Properties[key.Value]; ok {
+				errs = append(errs, prop.validate(value, child)...)
+			} else if prop, ok := s.mergeKey(key.Value); ok {
+				// ports+: 6006/http appends a single item
+				if value.Kind != yaml.SequenceNode && prop.Items != nil {
+					prop = prop.Items
+				}
+				errs = append(errs, prop.validate(value, child)...)
+			} else if extra, ok := s.AdditionalProperties.(*Schema); ok {
+				errs = append(errs, extra.validate(value, child)...)
+			} else if s.AdditionalProperties == false {
+				errs = append(errs, nodeError(key, child, "unknown field%s", suggest(key.Value, s.Properties)))
+			}
+		}
+	}
+	return errs
+}
+
+// mergeKey returns the list property a `ports+` or `ports-` key of an
+// extending manifest adds to or removes from.
+func (s *Schema) mergeKey(key string) (*Schema, bool) {
+	name := strings.TrimRight(key, "+-")
+	if len(name) != len(key)-1 {
+		return nil, false
+	}
+	prop, ok := s.Properties[name]
+	if !ok || prop.Type != "array" {
+		return nil, false
+	}
+	return prop, true
+}
+
+// checkRequired reports the required fields missing from a decoded document.
+func (s *Schema) checkRequired(v interface{}, path string) []error {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	var errs []error
+	for _, name := range s.Required {
+		if m[name] == nil {
+			errs = append(errs, fmt.Errorf("%s is required", joinPath(path, name)))
+		}
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if prop, ok := s.Properties[k]; ok {
+			errs = append(errs, prop.checkRequired(m[k], joinPath(path, k))...)
+		}
+	}
+	return errs
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func nodeType(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch n.Tag {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	}
+	return "string"
+}
+
+// typeMatches follows what decoding accepts: any scalar fills a string field,
+// so `env: {DEBUG: 1}` stays valid, and an integer is a valid number.
+func typeMatches(want, got string, n *yaml.Node) bool {
+	switch want {
+	case "", got:
+		return true
+	case "string":
+		return n.Kind == yaml.ScalarNode
+	case "number":
+		return got == "integer"
+	}
+	return false
+}
+
+func nodeError(n *yaml.Node, path string, format string, args ...interface{}) error {
+	if path == "" {
+		path = "document"
+	}
+	return fmt.Errorf("%d:%d: %s: %s", n.Line, n.Column, path, fmt.Sprintf(format, args...))
+}
+
+// suggest names the known field closest to an unknown one, to catch typos
+// such as gpuTyp or imagename.
+func suggest(name string, known map[string]*Schema) string {
+	best, bestDist := "", 3
+	for k := range known {
+		if d := distance(strings.ToLower(name), strings.ToLower(k)); d < bestDist || d == bestDist && k < best {
+			best, bestDist = k, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf("; did you mean %s?", best)
+}
+
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func min(v ...int) int {
+	m := v[0]
+	for _, x := range v[1:] {
+		if x < m {
+			m = x
+		}
+	}
+	return m
+}
@@ -0,0 +1,240 @@
+package manifest
+
+import (
+	"cli/api"
+	"fmt"
+	"strings"
+)
+
+const (
+	KindService  = "service"
+	KindEndpoint = "endpoint"
+)
+
+const (
+	ModePod        = "pod"
+	ModeServerless = "serverless"
+)
+
+// Service is a workload that runs either as a pod or as a serverless
+// endpoint from the same spec; changing mode switches between the two.
+type Service struct {
+	Kind string       `yaml:"kind" schema:"required"`
+	Name string       `yaml:"name" schema:"required"`
+	Spec *ServiceSpec `yaml:"spec" schema:"required"`
+}
+
+type ServiceSpec struct {
+	Mode              string            `yaml:"mode" schema:"required,enum=pod|serverless"`
+	ImageName         string            `yaml:"imageName" schema:"required"`
+	GpuType           string            `yaml:"gpuType,omitempty"`
+	GpuCount          int               `yaml:"gpuCount,omitempty"`
+	GpuIds            string            `yaml:"gpuIds,omitempty"`
+	CloudType         string            `yaml:"cloudType,omitempty" schema:"enum=SECURE|COMMUNITY"`
+	ContainerDiskInGb int               `yaml:"containerDiskInGb"`
+	VolumeInGb        int               `yaml:"volumeInGb,omitempty"`
+	VolumeMountPath   string            `yaml:"volumeMountPath,omitempty"`
+	NetworkVolumeId   string            `yaml:"networkVolumeId,omitempty"`
+	DockerArgs        string            `yaml:"dockerArgs,omitempty"`
+	Ports             []string          `yaml:"ports,omitempty"`
+	Env               map[string]string `yaml:"env,omitempty"`
+	Scaling           *Scaling          `yaml:"scaling,omitempty"`
+	// DependsOn is the same as for pods; only services in pod mode use it.
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+}
+
+// Scaling bounds the workers of a serverless service.
+type Scaling struct {
+	Min int `yaml:"min"`
+	Max int `yaml:"max"`
+}
+
+// Endpoint is the serverless endpoint a service runs as. Its template is
+// referenced by name because it may not exist until apply creates it.
+type Endpoint struct {
+	Name            string
+	TemplateName    string
+	GpuIds          string
+	NetworkVolumeId string
+	WorkersMin      int
+	WorkersMax      int
+}
+
+// Service decodes a document of kind service.
+func (d *Document) Service() (*Service, error) {
+	if d.Kind != KindService {
+		return nil, fmt.Errorf("%s: %q is a %s, not a service", d.Source, d.Name, d.Kind)
+	}
+	s := &Service{}
+	if err := d.Decode(s); err != nil {
+		return nil, err
+	}
+	if s.Spec == nil {
+		return nil, fmt.Errorf("%s: service %q has no spec", d.Source, s.Name)
+	}
+	if s.Name == "" || s.Spec.ImageName == "" {
+		return nil, fmt.Errorf("%s: service needs a name and spec.imageName", d.Source)
+	}
+	switch s.Spec.Mode {
+	case ModePod:
+		if s.Spec.GpuType == "" {
+			return nil, fmt.Errorf("%s: service %q in pod mode needs spec.gpuType", d.Source, s.Name)
+		}
+	case ModeServerless:
+		if s.Spec.GpuIds == "" {
+			return nil, fmt.Errorf("%s: service %q in serverless mode needs spec.gpuIds, e.g. AMPERE_24", d.Source, s.Name)
+		}
+	default:
+		return nil, fmt.Errorf("%s: service %q has mode %q; use pod or serverless", d.Source, s.Name, s.Spec.Mode)
+	}
+	return s, nil
+}
+
+// TemplateName is the serverless template the service's endpoint runs.
+func (s *Service) TemplateName() string {
+	return s.Name + "-service"
+}
+
+// Pod is the pod a service in pod mode runs as.
+func (s *Service) Pod() *Pod {
+	sp := s.Spec
+	gpuCount := sp.GpuCount
+	if gpuCount == 0 {
+		gpuCount = 1
+	}
+	return &Pod{Kind: KindPod, Name: s.Name, Spec: &PodSpec{
+		ImageName:         sp.ImageName,
+		GpuType:           sp.GpuType,
+		GpuCount:          gpuCount,
+		CloudType:         sp.CloudType,
+		ContainerDiskInGb: sp.ContainerDiskInGb,
+		VolumeInGb:        sp.VolumeInGb,
+		VolumeMountPath:   sp.VolumeMountPath,
+		DockerArgs:        sp.DockerArgs,
+		Ports:             sp.Ports,
+		Env:               sp.Env,
+		DependsOn:         sp.DependsOn,
+	}}
+}
+
+// Template is the serverless template of a service in serverless mode.
+func (s *Service) Template() *Template {
+	sp := s.Spec
+	return &Template{Kind: KindTemplate, Name: s.TemplateName(), Spec: &TemplateSpec{
+		ImageName:         sp.ImageName,
+		ContainerDiskInGb: sp.ContainerDiskInGb,
+		VolumeInGb:        sp.VolumeInGb,
+		VolumeMountPath:   sp.VolumeMountPath,
+		DockerArgs:        sp.DockerArgs,
+		Ports:             sp.Ports,
+		Env:               sp.Env,
+		Serverless:        true,
+	}}
+}
+
+// Endpoint is the endpoint of a service in serverless mode. Without scaling
+// it scales from zero to one worker.
+func (s *Service) Endpoint() *Endpoint {
+	e := &Endpoint{
+		Name:            s.Name,
+		TemplateName:    s.TemplateName(),
+		GpuIds:          s.Spec.GpuIds,
+		NetworkVolumeId: s.Spec.NetworkVolumeId,
+		WorkersMax:      1,
+	}
+	if s.Spec.Scaling != nil {
+		e.WorkersMin = s.Spec.Scaling.Min
+		e.WorkersMax = s.Spec.Scaling.Max
+	}
+	return e
+}
+
+// SaveEndpointInput converts the endpoint into the input of api.SaveEndpoint.
+func (e *Endpoint) SaveEndpointInput(templateId string) *api.SaveEndpointInput {
+	return &api.SaveEndpointInput{
+		Name:            e.Name,
+		TemplateId:      templateId,
+		GpuIds:          e.GpuIds,
+		NetworkVolumeId: e.NetworkVolumeId,
+		WorkersMin:      e.WorkersMin,
+		WorkersMax:      e.WorkersMax,
+	}
+}
+
+// PlanEndpoints diffs the desired endpoints against the live ones.
+func PlanEndpoints(desired []*Endpoint, live []*api.Endpoint) ([]*Action, error) {
+	byName := make(map[string]*api.Endpoint, len(live))
+	for _, e := range live {
+		byName[e.Name] = e
+	}
+	var actions []*Action
+	for _, d := range desired {
+		a := &Action{Kind: KindEndpoint, Name: d.Name, Endpoint: d, Op: OpCreate}
+		if e, ok := byName[d.Name]; ok {
+			a.Id = e.Id
+			a.Changes = diffEndpoint(e, d)
+			a.Op = OpUnchanged
+			if len(a.Changes) > 0 {
+				a.Op = OpUpdate
+			}
+		}
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
+
+// PlanModeSwitch terminates the pod or endpoint a service ran as before its
+// mode changed, and the template of a serverless service that now runs as a
+// pod. Its actions belong after the ones that start the service in its new
+// mode, so the service keeps running until its replacement exists. Only
+// pods apply created, the ids in managed, are terminated; a pod that merely
+// shares the service's name is left alone.
+func PlanModeSwitch(services []*Service, livePods []*api.Pod, managed map[string]bool, liveEndpoints []*api.Endpoint, liveTemplates []*api.Template) []*Action {
+	var actions []*Action
+	for _, s := range services {
+		if s.Spec.Mode == ModeServerless {
+			for _, p := range livePods {
+				if p.Name == s.Name && managed[p.Id] {
+					actions = append(actions, &Action{Op: OpDelete, Kind: KindPod, Name: p.Name, Id: p.Id})
+				}
+			}
+			continue
+		}
+		for _, e := range liveEndpoints {
+			if e.Name == s.Name {
+				actions = append(actions, &Action{Op: OpDelete, Kind: KindEndpoint, Name: e.Name, Id: e.Id})
+			}
+		}
+		// the endpoint goes first, as it runs the template
+		for _, t := range liveTemplates {
+			if t.Name == s.TemplateName() {
+				actions = append(actions, &Action{Op: OpDelete, Kind: KindTemplate, Name: t.Name, Id: t.Id})
+			}
+		}
+	}
+	return actions
+}
+
+func diffEndpoint(live *api.Endpoint, want *Endpoint) []Change {
+	var changes []Change
+	str := func(field string, from string, to string) {
+		if from != to {
+			changes = append(changes, Change{field, quoteOrNone(from), quoteOrNone(to)})
+		}
+	}
+	num := func(field string, from int, to int) {
+		if from != to {
+			changes = append(changes, Change{field, fmt.Sprint(from), fmt.Sprint(to)})
+		}
+	}
+	template := ""
+	if live.Template != nil {
+		template = live.Template.Name
+	}
+	str("template", template, want.TemplateName)
+	str("gpuIds", strings.TrimSpace(live.GpuIds), want.GpuIds)
+	str("networkVolumeId", live.NetworkVolumeId, want.NetworkVolumeId)
+	num("workersMin", live.WorkersMin, want.WorkersMin)
+	num("workersMax", live.WorkersMax, want.WorkersMax)
+	return changes
+}
@@ -0,0 +1,89 @@
+package manifest
+
+import (
+	"cli/api"
+	"fmt"
+	"strings"
+)
+
+const KindTemplate = "template"
+
+type Template struct {
+	Kind string        `yaml:"kind" schema:"required"`
+	Name string        `yaml:"name" schema:"required"`
+	Spec *TemplateSpec `yaml:"spec" schema:"required"`
+}
+
+type TemplateSpec struct {
+	ImageName         string            `yaml:"imageName" schema:"required"`
+	ContainerDiskInGb int               `yaml:"containerDiskInGb"`
+	VolumeInGb        int               `yaml:"volumeInGb"`
+	VolumeMountPath   string            `yaml:"volumeMountPath,omitempty"`
+	DockerArgs        string            `yaml:"dockerArgs,omitempty"`
+	Ports             []string          `yaml:"ports,omitempty"`
+	Env               map[string]string `yaml:"env,omitempty"`
+	Readme            string            `yaml:"readme,omitempty"`
+	Serverless        bool              `yaml:"serverless,omitempty"`
+}
+
+// FromTemplate builds the manifest that reproduces an existing template.
+func FromTemplate(t *api.Template) *Template {
+	spec := &TemplateSpec{
+		ImageName:         t.ImageName,
+		ContainerDiskInGb: t.ContainerDiskInGb,
+		VolumeInGb:        t.VolumeInGb,
+		VolumeMountPath:   t.VolumeMountPath,
+		DockerArgs:        t.DockerArgs,
+		Readme:            t.Readme,
+		Serverless:        t.IsServerless,
+	}
+	if t.Ports != "" {
+		spec.Ports = strings.Split(t.Ports, ",")
+	}
+	if len(t.Env) > 0 {
+		spec.Env = make(map[string]string, len(t.Env))
+		for _, e := range t.Env {
+			spec.Env[e.Key] = e.Value
+		}
+	}
+	return &Template{Kind: KindTemplate, Name: t.Name, Spec: spec}
+}
+
+// SaveTemplateInput converts the manifest into the input of api.CreateTemplate.
+func (t *Template) SaveTemplateInput() *api.SaveTemplateInput {
+	s := t.Spec
+	input := &api.SaveTemplateInput{
+		ContainerDiskInGb: s.ContainerDiskInGb,
+		DockerArgs:        s.DockerArgs,
+		ImageName:         s.ImageName,
+		IsServerless:      s.Serverless,
+		Name:              t.Name,
+		Ports:             strings.Join(s.Ports, ","),
+		Readme:            s.Readme,
+		VolumeInGb:        s.VolumeInGb,
+		VolumeMountPath:   s.VolumeMountPath,
+	}
+	input.Env = make([]*api.PodEnv, 0, len(s.Env))
+	for _, k := range sortedKeys(s.Env) {
+		input.Env = append(input.Env, &api.PodEnv{Key: k, Value: s.Env[k]})
+	}
+	return input
+}
+
+// Template decodes a document of kind template.
+func (d *Document) Template() (*Template, error) {
+	if d.Kind != KindTemplate {
+		return nil, fmt.Errorf("%s: %q is a %s, not a template", d.Source, d.Name, d.Kind)
+	}
+	t := &Template{}
+	if err := d.Decode(t); err != nil {
+		return nil, err
+	}
+	if t.Spec == nil {
+		return nil, fmt.Errorf("%s: template %q has no spec", d.Source, t.Name)
+	}
+	if t.Name == "" || t.Spec.ImageName == "" {
+		return nil, fmt.Errorf("%s: template needs a name and spec.imageName", d.Source)
+	}
+	return t, nil
+}
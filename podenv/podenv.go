@@ -0,0 +1,173 @@
+package podenv
+
+import (
+	"bufio"
+	"cli/api"
+	"cli/secrets"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var validKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// Build assembles pod env from dotenv files, then KEY=VALUE pairs, then
+// KEY=secretName references to RunPod secrets. A key set again replaces the
+// earlier value but keeps its position.
+func Build(files []string, pairs []string, secretRefs []string) ([]*api.PodEnv, error) {
+	var env []*api.PodEnv
+	for _, f := range files {
+		fromFile, err := ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		env = Merge(env, fromFile...)
+	}
+	for _, p := range pairs {
+		e, err := Parse(p)
+		if err != nil {
+			return nil, err
+		}
+		env = Merge(env, e)
+	}
+	for _, p := range secretRefs {
+		e, err := Parse(p)
+		if err != nil {
+			return nil, err
+		}
+		if !validKey.MatchString(e.Value) {
+			return nil, fmt.Errorf("invalid secret name %q for %s", e.Value, e.Key)
+		}
+		e.Value = secrets.Reference(e.Value)
+		env = Merge(env, e)
+	}
+	return env, nil
+}
+
+// Parse reads a KEY=VALUE pair. The value is everything after the first =,
+// so it may itself contain = and commas.
+func Parse(pair string) (*api.PodEnv, error) {
+	kv := strings.SplitN(pair, "=", 2)
+	if len(kv) != 2 || !validKey.MatchString(kv[0]) {
+		return nil, fmt.Errorf("wrong env value %q: use KEY=VALUE", pair)
+	}
+	return &api.PodEnv{Key: kv[0], Value: kv[1]}, nil
+}
+
+// Merge sets each of vars in env, replacing the value of keys already set.
+func Merge(env []*api.PodEnv, vars ...*api.PodEnv) []*api.PodEnv {
+	for _, v := range vars {
+		replaced := false
+		for _, e := range env {
+			if e.Key == v.Key {
+				e.Value = v.Value
+				replaced = true
+			}
+		}
+		if !replaced {
+			env = append(env, v)
+		}
+	}
+	return env
+}
+
+// ReadFile parses a dotenv file: KEY=VALUE lines with optional `export`,
+// # comments, 'literal' values and "quoted" values with \n, \" and \\
+// escapes that may span lines.
+func ReadFile(path string) ([]*api.PodEnv, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var env []*api.PodEnv
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	n := 0
+	for scanner.Scan() {
+		n++
+		start := n
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		kv := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || !validKey.MatchString(key) {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, start)
+		}
+		value := strings.TrimSpace(kv[1])
+		switch {
+		case strings.HasPrefix(value, `"`):
+			// a quoted value ends at the first unescaped quote, maybe lines later
+			for !closedQuote(value[1:]) && scanner.Scan() {
+				n++
+				value += "\n" + scanner.Text()
+			}
+			if !closedQuote(value[1:]) {
+				return nil, fmt.Errorf("%s:%d: unterminated quoted value for %s", path, start, key)
+			}
+			value, err = unquote(value[1:])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, start, err)
+			}
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("%s:%d: unterminated quoted value for %s", path, start, key)
+			}
+			value = value[1 : end+1]
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		env = Merge(env, &api.PodEnv{Key: key, Value: value})
+	}
+	return env, scanner.Err()
+}
+
+// closedQuote reports whether s has an unescaped double quote.
+func closedQuote(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return true
+		}
+	}
+	return false
+}
+
+// unquote decodes a double quoted value up to its closing quote; anything
+// after it other than a comment is an error.
+func unquote(s string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			rest := strings.TrimSpace(s[i+1:])
+			if rest != "" && !strings.HasPrefix(rest, "#") {
+				return "", fmt.Errorf("unexpected %q after quoted value", rest)
+			}
+			return sb.String(), nil
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(s[i])
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,148 @@
+package prices
+
+import (
+	"cli/history"
+	"cli/store"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Offer is the hourly price of one gpu at a provider, as listed by a price
+// source, e.g.
+//
+//	[{"provider": "aws", "gpu": "A100 40GB", "vramInGb": 40, "kind": "on-demand", "pricePerHour": 4.1}]
+type Offer struct {
+	Provider     string  `json:"provider"`
+	Gpu          string  `json:"gpu"`
+	VramInGb     int     `json:"vramInGb,omitempty"`
+	Kind         string  `json:"kind,omitempty"`
+	PricePerHour float64 `json:"pricePerHour"`
+}
+
+// Source supplies the offers of other providers.
+type Source interface {
+	Offers(ctx context.Context) ([]*Offer, error)
+	String() string
+}
+
+// NewSource returns the source for a json file path, or for an http(s) url
+// that is read through a local cache of maxAge.
+func NewSource(location string, maxAge time.Duration) Source {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return &urlSource{url: location, maxAge: maxAge}
+	}
+	return fileSource(location)
+}
+
+type fileSource string
+
+func (f fileSource) Offers(ctx context.Context) ([]*Offer, error) {
+	data, err := os.ReadFile(string(f))
+	if err != nil {
+		return nil, err
+	}
+	return decode(string(f), data)
+}
+
+func (f fileSource) String() string {
+	return string(f)
+}
+
+// urlSource downloads offers, keeping the last response in
+// ~/.runpod/prices so that it is fetched at most once per maxAge, and is
+// still used, with a warning, while the url is unreachable.
+type urlSource struct {
+	url    string
+	maxAge time.Duration
+}
+
+func (u *urlSource) Offers(ctx context.Context) ([]*Offer, error) {
+	cache, cacheErr := u.cachePath()
+	if cacheErr == nil {
+		if info, err := os.Stat(cache); err == nil && time.Since(info.ModTime()) < u.maxAge {
+			if data, err := os.ReadFile(cache); err == nil {
+				return decode(u.url, data)
+			}
+		}
+	}
+	data, err := u.fetch(ctx)
+	if err != nil {
+		if cacheErr == nil {
+			if stale, staleErr := os.ReadFile(cache); staleErr == nil {
+				fmt.Fprintf(os.Stderr, "warning: %s: %s; using prices cached earlier\n", u.url, err)
+				return decode(u.url, stale)
+			}
+		}
+		return nil, fmt.Errorf("%s: %w", u.url, err)
+	}
+	offers, err := decode(u.url, data)
+	if err != nil {
+		return nil, err
+	}
+	if cacheErr == nil && os.MkdirAll(filepath.Dir(cache), 0700) == nil {
+		store.WriteFile(cache, data, 0600) //nolint
+	}
+	return offers, nil
+}
+
+func (u *urlSource) fetch(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", u.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("statuscode %d", res.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(res.Body, 10<<20))
+}
+
+func (u *urlSource) cachePath() (string, error) {
+	dir, err := history.Dir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(u.url))
+	return filepath.Join(dir, "prices", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+func (u *urlSource) String() string {
+	return u.url
+}
+
+func decode(source string, data []byte) ([]*Offer, error) {
+	var offers []*Offer
+	if err := json.Unmarshal(data, &offers); err != nil {
+		return nil, fmt.Errorf("%s: want a json list of offers: %w", source, err)
+	}
+	for i, o := range offers {
+		if o.Provider == "" || o.Gpu == "" || o.PricePerHour <= 0 {
+			return nil, fmt.Errorf("%s: offer %d needs a provider, gpu and pricePerHour", source, i+1)
+		}
+	}
+	return offers, nil
+}
+
+// Matches reports whether a gpu name contains the query, ignoring case and
+// spacing, so "a100" matches both "NVIDIA A100 80GB PCIe" and "A100 40GB".
+func Matches(name string, query string) bool {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.Join(strings.Fields(s), ""))
+	}
+	return strings.Contains(normalize(name), normalize(query))
+}
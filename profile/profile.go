@@ -0,0 +1,111 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Default is the profile made of the top-level config settings.
+const Default = "default"
+
+// Active is the profile in use, or "" for the top-level settings.
+var Active string
+
+// CrossProfile allows acting on resources recorded as created under another
+// profile than the active one.
+var CrossProfile bool
+
+// explicit is set when the active profile was picked for this command, with
+// --profile or RUNPOD_PROFILE, rather than saved by use-profile.
+var explicit bool
+
+// Select picks the active profile: name if set, else RUNPOD_PROFILE, else the
+// profile saved in the config by use-profile. A saved profile that no longer
+// exists is warned about and the top-level settings are used.
+func Select(name string) error {
+	explicit = name != ""
+	if name == "" {
+		name = os.Getenv("RUNPOD_PROFILE")
+		explicit = name != ""
+	}
+	saved := false
+	if name == "" {
+		name = viper.GetString("profile")
+		saved = true
+	}
+	name = strings.ToLower(name)
+	if name == Default {
+		name = ""
+	}
+	if name != "" && !viper.IsSet("profiles."+name) {
+		if saved {
+			fmt.Fprintf(os.Stderr, "warning: the saved profile %q does not exist; using the default profile, switch with runpodctl config use-profile\n", name)
+			name = ""
+		} else {
+			return fmt.Errorf("unknown profile %q; create it with runpodctl config set-profile %s", name, name)
+		}
+	}
+	Active = name
+	return nil
+}
+
+// Key returns the config key to read for key: the active profile's own
+// setting when it has one, otherwise the top-level key. The api key does not
+// fall back this way; see ApiKey.
+func Key(key string) string {
+	if Active != "" && viper.IsSet("profiles."+Active+"."+key) {
+		return "profiles." + Active + "." + key
+	}
+	return key
+}
+
+// ApiKey returns the api key to use. A profile given with --profile or
+// RUNPOD_PROFILE beats RUNPOD_API_KEY, which beats a profile saved by
+// use-profile. An active profile must have its own key: falling back to the
+// top-level one would send its commands to another account.
+func ApiKey() (string, error) {
+	if env := os.Getenv("RUNPOD_API_KEY"); env != "" && !explicit {
+		return env, nil
+	}
+	if Active == "" {
+		return viper.GetString("apiKey"), nil
+	}
+	key := viper.GetString("profiles." + Active + ".apiKey")
+	if key == "" {
+		return "", fmt.Errorf("profile %q has no api key; set it with runpodctl config set-profile %s --apiKey={key}", Active, Active)
+	}
+	return key, nil
+}
+
+// Names lists the configured profiles.
+func Names() []string {
+	var names []string
+	for name := range viper.GetStringMap("profiles") {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Name is the name of the active profile, Default for the top-level settings.
+func Name() string {
+	if Active == "" {
+		return Default
+	}
+	return Active
+}
+
+// Check refuses to act on a resource recorded as created under profile owner
+// while another profile is active, so a profile switch does not hit another
+// account's resources of the same name. An empty owner, recorded before
+// owners were, passes, as does everything with CrossProfile.
+func Check(resource string, owner string) error {
+	if owner == "" || owner == Name() || CrossProfile {
+		return nil
+	}
+	return fmt.Errorf(`%s belongs to profile "%s", but the active profile is "%s"; use --profile %s, or --cross-profile to act on it anyway`, resource, owner, Name(), owner)
+}
@@ -0,0 +1,217 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const dockerHub = "registry-1.docker.io"
+
+const (
+	mediaTypeManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeOciIndex     = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOciManifest  = "application/vnd.oci.image.manifest.v1+json"
+)
+
+// Reference is a parsed image name such as ghcr.io/org/app:tag.
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+}
+
+func ParseReference(image string) (*Reference, error) {
+	if image == "" {
+		return nil, fmt.Errorf("empty image name")
+	}
+	ref := &Reference{Registry: dockerHub, Tag: "latest"}
+	name := image
+	if i := strings.Index(name, "/"); i > 0 {
+		first := name[:i]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			ref.Registry = first
+			name = name[i+1:]
+		}
+	}
+	if i := strings.Index(name, "@"); i > 0 {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	} else if i := strings.LastIndex(name, ":"); i > 0 {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+	if ref.Registry == dockerHub && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.Repository = name
+	return ref, nil
+}
+
+func (r *Reference) String() string {
+	sep := ":"
+	if strings.HasPrefix(r.Tag, "sha256:") {
+		sep = "@"
+	}
+	return r.Registry + "/" + r.Repository + sep + r.Tag
+}
+
+// Layer is a compressed image layer.
+type Layer struct {
+	Digest    string `json:"digest"`
+	MediaType string `json:"mediaType"`
+	Size      int64  `json:"size"`
+}
+
+// Manifest is the single platform image manifest.
+type Manifest struct {
+	MediaType string  `json:"mediaType"`
+	Config    Layer   `json:"config"`
+	Layers    []Layer `json:"layers"`
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform struct {
+			Architecture string `json:"architecture"`
+			Os           string `json:"os"`
+		} `json:"platform"`
+	} `json:"manifests"`
+}
+
+// Size is the total compressed size of the layers and config.
+func (m *Manifest) Size() (size int64) {
+	size = m.Config.Size
+	for _, l := range m.Layers {
+		size += l.Size
+	}
+	return
+}
+
+// Client fetches manifests from a registry, optionally with basic credentials.
+type Client struct {
+	Username string
+	Password string
+	http     *http.Client
+	token    string
+}
+
+func NewClient() *Client {
+	return &Client{http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// GetManifest returns the linux/amd64 manifest of the image, resolving manifest lists.
+func (c *Client) GetManifest(ref *Reference) (*Manifest, error) {
+	m, err := c.getManifest(ref, ref.Tag)
+	if err != nil {
+		return nil, err
+	}
+	if m.MediaType != mediaTypeManifestList && m.MediaType != mediaTypeOciIndex && len(m.Manifests) == 0 {
+		return m, nil
+	}
+	for _, entry := range m.Manifests {
+		if entry.Platform.Os == "linux" && entry.Platform.Architecture == "amd64" {
+			return c.getManifest(ref, entry.Digest)
+		}
+	}
+	return nil, fmt.Errorf("%s has no linux/amd64 image", ref)
+}
+
+func (c *Client) getManifest(ref *Reference, tagOrDigest string) (*Manifest, error) {
+	u := fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.Registry, ref.Repository, tagOrDigest)
+	accept := strings.Join([]string{mediaTypeManifestList, mediaTypeManifest, mediaTypeOciIndex, mediaTypeOciManifest}, ", ")
+	res, err := c.do(u, accept, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("%s: statuscode %d: %s", ref, res.StatusCode, strings.TrimSpace(string(body)))
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(body, m); err != nil {
+		return nil, err
+	}
+	if m.MediaType == "" {
+		m.MediaType = res.Header.Get("Content-Type")
+	}
+	return m, nil
+}
+
+// do performs an authenticated GET, negotiating a bearer token on the first 401.
+func (c *Client) do(u string, accept string, ref *Reference) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", accept)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		} else if c.Username != "" {
+			req.SetBasicAuth(c.Username, c.Password)
+		}
+		res, err := c.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			return res, nil
+		}
+		challenge := res.Header.Get("WWW-Authenticate")
+		res.Body.Close()
+		if err := c.authenticate(challenge, ref); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) authenticate(challenge string, ref *Reference) error {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return fmt.Errorf("%s: unsupported registry auth challenge %q", ref.Registry, challenge)
+	}
+	params := map[string]string{}
+	for _, part := range strings.Split(challenge[len("bearer "):], ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) == 2 {
+			params[kv[0]] = strings.Trim(kv[1], `"`)
+		}
+	}
+	q := url.Values{}
+	q.Set("service", params["service"])
+	q.Set("scope", "repository:"+ref.Repository+":pull")
+	req, err := http.NewRequest("GET", params["realm"]+"?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return fmt.Errorf("%s: token request failed with statuscode %d", ref.Registry, res.StatusCode)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return err
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	return nil
+}
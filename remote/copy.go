@@ -0,0 +1,72 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Quote quotes s for the remote POSIX shell.
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Upload streams the local file to remotePath on the target. The file is
+// written to a temporary name and renamed so readers never see a partial file.
+func (t *Target) Upload(ctx context.Context, localPath string, remotePath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tmp := remotePath + partialSuffix
+	script := fmt.Sprintf(
+		"mkdir -p %s && cat > %s && mv -f %s %s",
+		Quote(path.Dir(remotePath)), Quote(tmp), Quote(tmp), Quote(remotePath),
+	)
+	var stderr bytes.Buffer
+	if err := t.Run(ctx, script, f, io.Discard, &stderr); err != nil {
+		return commandError(err, &stderr)
+	}
+	return nil
+}
+
+// Checksum returns the sha256 of remotePath on the target.
+func (t *Target) Checksum(ctx context.Context, remotePath string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	if err := t.Run(ctx, "sha256sum "+Quote(remotePath), nil, &stdout, &stderr); err != nil {
+		return "", commandError(err, &stderr)
+	}
+	fields := strings.Fields(stdout.String())
+	if len(fields) == 0 {
+		return "", fmt.Errorf("sha256sum returned no output")
+	}
+	return fields[0], nil
+}
+
+// FileChecksum returns the sha256 of a local file.
+func FileChecksum(localPath string) (string, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func commandError(err error, stderr *bytes.Buffer) error {
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
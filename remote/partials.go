@@ -0,0 +1,140 @@
+package remote
+
+import (
+	"cli/history"
+	"cli/store"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// StalePartialAge is how long a partial download may go untouched before
+// cp cleans it up; until then a rerun of the copy resumes it.
+const StalePartialAge = 7 * 24 * time.Hour
+
+// TrackedPartialBytes is the size from which downloads are tracked. Smaller
+// ones are quick to copy again and left alone, so copying many small files
+// does not write transfers.json twice for each.
+const TrackedPartialBytes = 64 << 20
+
+// Partial is a download in progress, or one a crashed or abandoned copy
+// left behind. Partials of TrackedPartialBytes or more, and the ones that
+// are resumed, are tracked in ~/.runpod/transfers.json so they can
+// be cleaned up without searching the disk.
+type Partial struct {
+	Path    string    `json:"path"`
+	PodId   string    `json:"podId"`
+	Source  string    `json:"source"`
+	Started time.Time `json:"started"`
+}
+
+func partialsPath() (string, error) {
+	dir, err := history.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "transfers.json"), nil
+}
+
+// updatePartials runs fn on the tracked partials, keyed by path, under a
+// lock, and saves what it leaves.
+func updatePartials(fn func(partials map[string]*Partial)) error {
+	p, err := partialsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	unlock, err := store.Lock(p + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	partials := make(map[string]*Partial)
+	data, err := os.ReadFile(p)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(data) > 0 {
+		var list []*Partial
+		// a damaged file only loses track of partials, so start over
+		if json.Unmarshal(data, &list) == nil {
+			for _, partial := range list {
+				partials[partial.Path] = partial
+			}
+		}
+	}
+	fn(partials)
+
+	list := make([]*Partial, 0, len(partials))
+	for _, partial := range partials {
+		list = append(list, partial)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	data, err = json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.WriteFile(p, data, 0600)
+}
+
+// trackPartial records a partial download before it is written.
+func trackPartial(partial *Partial) error {
+	if abs, err := filepath.Abs(partial.Path); err == nil {
+		partial.Path = abs
+	}
+	return updatePartials(func(partials map[string]*Partial) {
+		if _, ok := partials[partial.Path]; !ok {
+			partials[partial.Path] = partial
+		}
+	})
+}
+
+// untrackPartial forgets a partial download once it is complete.
+func untrackPartial(path string) error {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return updatePartials(func(partials map[string]*Partial) {
+		delete(partials, path)
+	})
+}
+
+// CleanPartials removes the tracked partial downloads that were not written
+// to for olderThan, and forgets the ones that are gone. It returns the
+// removed partials and the bytes they took.
+func CleanPartials(olderThan time.Duration) ([]*Partial, int64, error) {
+	var removed []*Partial
+	var freed int64
+	var removeErr error
+	err := updatePartials(func(partials map[string]*Partial) {
+		cutoff := time.Now().Add(-olderThan)
+		for path, partial := range partials {
+			info, err := os.Stat(path)
+			if errors.Is(err, os.ErrNotExist) {
+				delete(partials, path)
+				continue
+			}
+			if err != nil || info.ModTime().After(cutoff) {
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				removeErr = err
+				continue
+			}
+			delete(partials, path)
+			removed = append(removed, partial)
+			freed += info.Size()
+		}
+	})
+	if err == nil {
+		err = removeErr
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].Path < removed[j].Path })
+	return removed, freed, err
+}
@@ -0,0 +1,281 @@
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const partialSuffix = ".runpodctl.tmp"
+
+// Progress follows the transfer of one file. Set64 is called with the bytes
+// that did not need copying, because an earlier attempt left them or the
+// file was already there; Write is called with each chunk copied after that.
+type Progress interface {
+	io.Writer
+	Set64(n int64) error
+}
+
+// NewProgress starts following the transfer of a file of the given size.
+type NewProgress func(name string, size int64) Progress
+
+// Put copies a local file, or a directory when recursive is set, to
+// remotePath on the target; a file copied to an existing directory lands
+// inside it. Each file is written to a partial name and
+// renamed once its checksum matches, so an interrupted Put continues where it
+// stopped when run again, and files already copied are skipped.
+func (t *Target) Put(ctx context.Context, localPath string, remotePath string, recursive bool, progress NewProgress) error {
+	stat, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+	if !stat.IsDir() {
+		if _, isDir, err := t.sizes(ctx, remotePath); err != nil {
+			return err
+		} else if isDir {
+			remotePath = path.Join(remotePath, filepath.Base(localPath))
+		}
+		return t.putFile(ctx, localPath, remotePath, stat.Size(), progress(filepath.Base(localPath), stat.Size()))
+	}
+	if !recursive {
+		return fmt.Errorf("%s is a directory; use -r to copy it", localPath)
+	}
+	return filepath.Walk(localPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() || strings.HasSuffix(p, partialSuffix) {
+			return err
+		}
+		rel, err := filepath.Rel(localPath, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		return t.putFile(ctx, p, path.Join(remotePath, rel), info.Size(), progress(rel, info.Size()))
+	})
+}
+
+// Get copies a file, or a directory when recursive is set, from remotePath
+// on the target to localPath, resuming and skipping like Put.
+func (t *Target) Get(ctx context.Context, remotePath string, localPath string, recursive bool, progress NewProgress) error {
+	files, isDir, err := t.list(ctx, remotePath)
+	if err != nil {
+		return err
+	}
+	if !isDir {
+		if stat, err := os.Stat(localPath); err == nil && stat.IsDir() {
+			localPath = filepath.Join(localPath, path.Base(remotePath))
+		}
+		return t.getFile(ctx, remotePath, localPath, files[""], progress(path.Base(remotePath), files[""]))
+	}
+	if !recursive {
+		return fmt.Errorf("%s is a directory; use -r to copy it", remotePath)
+	}
+	names := make([]string, 0, len(files))
+	for rel := range files {
+		names = append(names, rel)
+	}
+	sort.Strings(names)
+	for _, rel := range names {
+		size := files[rel]
+		err := t.getFile(ctx, path.Join(remotePath, rel), filepath.Join(localPath, filepath.FromSlash(rel)), size, progress(rel, size))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Target) putFile(ctx context.Context, localPath string, remotePath string, size int64, progress Progress) error {
+	partial := remotePath + partialSuffix
+	sizes, _, err := t.sizes(ctx, remotePath, partial)
+	if err != nil {
+		return err
+	}
+	sum, err := FileChecksum(localPath)
+	if err != nil {
+		return err
+	}
+	if sizes[0] == size {
+		if remoteSum, err := t.Checksum(ctx, remotePath); err == nil && remoteSum == sum {
+			return progress.Set64(size)
+		}
+	}
+	offset := sizes[1]
+	if offset < 0 || offset > size {
+		offset = 0
+	}
+
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		progress.Set64(offset) //nolint
+		redirect := ">"
+		if offset > 0 {
+			redirect = ">>"
+		}
+		script := fmt.Sprintf("mkdir -p %s && cat %s %s", Quote(path.Dir(remotePath)), redirect, Quote(partial))
+		var stderr bytes.Buffer
+		if err := t.Run(ctx, script, io.TeeReader(f, progress), io.Discard, &stderr); err != nil {
+			return commandError(err, &stderr)
+		}
+		remoteSum, err := t.Checksum(ctx, partial)
+		if err != nil {
+			return fmt.Errorf("verify: %w", err)
+		}
+		if remoteSum == sum {
+			break
+		}
+		if offset == 0 {
+			return fmt.Errorf("%s: checksum mismatch: %s != %s", remotePath, remoteSum, sum)
+		}
+		// the partial file came from a different version of the file; start over
+		offset = 0
+	}
+	var stderr bytes.Buffer
+	if err := t.Run(ctx, fmt.Sprintf("mv -f %s %s", Quote(partial), Quote(remotePath)), nil, io.Discard, &stderr); err != nil {
+		return commandError(err, &stderr)
+	}
+	return nil
+}
+
+func (t *Target) getFile(ctx context.Context, remotePath string, localPath string, size int64, progress Progress) error {
+	sum, err := t.Checksum(ctx, remotePath)
+	if err != nil {
+		return err
+	}
+	if stat, err := os.Stat(localPath); err == nil && stat.Size() == size {
+		if localSum, err := FileChecksum(localPath); err == nil && localSum == sum {
+			return progress.Set64(size)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return err
+	}
+	partial := localPath + partialSuffix
+	var offset int64
+	if stat, err := os.Stat(partial); err == nil && stat.Size() <= size {
+		offset = stat.Size()
+	}
+	// tracking only helps clean up after a crash, so it must not stop the copy
+	tracked := size >= TrackedPartialBytes || offset > 0
+	if tracked {
+		trackPartial(&Partial{Path: partial, PodId: t.PodId, Source: remotePath, Started: time.Now().UTC()}) //nolint
+	}
+	for {
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if offset > 0 {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		f, err := os.OpenFile(partial, flags, 0644)
+		if err != nil {
+			return err
+		}
+		progress.Set64(offset) //nolint
+		var stderr bytes.Buffer
+		err = t.Run(ctx, fmt.Sprintf("tail -c +%d %s", offset+1, Quote(remotePath)), nil, io.MultiWriter(f, progress), &stderr)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return commandError(err, &stderr)
+		}
+		localSum, err := FileChecksum(partial)
+		if err != nil {
+			return err
+		}
+		if localSum == sum {
+			break
+		}
+		if offset == 0 {
+			return fmt.Errorf("%s: checksum mismatch: %s != %s", localPath, localSum, sum)
+		}
+		offset = 0
+	}
+	if err := os.Rename(partial, localPath); err != nil {
+		return err
+	}
+	if tracked {
+		untrackPartial(partial) //nolint
+	}
+	return nil
+}
+
+// sizes returns the size of each path on the target, -1 for missing ones,
+// and whether the first path is a directory.
+func (t *Target) sizes(ctx context.Context, paths ...string) ([]int64, bool, error) {
+	var script strings.Builder
+	for _, p := range paths {
+		fmt.Fprintf(&script, "if [ -d %s ]; then echo d; else stat -c %%s %s 2>/dev/null || echo -1; fi; ", Quote(p), Quote(p))
+	}
+	var stdout, stderr bytes.Buffer
+	if err := t.Run(ctx, script.String(), nil, &stdout, &stderr); err != nil {
+		return nil, false, commandError(err, &stderr)
+	}
+	lines := strings.Fields(stdout.String())
+	if len(lines) != len(paths) {
+		return nil, false, fmt.Errorf("unexpected stat output: %q", stdout.String())
+	}
+	sizes := make([]int64, len(paths))
+	isDir := false
+	for i, line := range lines {
+		if line == "d" {
+			sizes[i] = -1
+			isDir = isDir || i == 0
+			continue
+		}
+		n, err := strconv.ParseInt(line, 10, 64)
+		if err != nil {
+			return nil, false, fmt.Errorf("unexpected stat output: %q", stdout.String())
+		}
+		sizes[i] = n
+	}
+	return sizes, isDir, nil
+}
+
+// list returns the size of every file under remotePath by path relative to
+// it, or the size of remotePath itself under "" when it is a file.
+func (t *Target) list(ctx context.Context, remotePath string) (map[string]int64, bool, error) {
+	sizes, isDir, err := t.sizes(ctx, remotePath)
+	if err != nil {
+		return nil, false, err
+	}
+	if !isDir {
+		if sizes[0] < 0 {
+			return nil, false, fmt.Errorf("%s: no such file or directory", remotePath)
+		}
+		return map[string]int64{"": sizes[0]}, false, nil
+	}
+	var stdout, stderr bytes.Buffer
+	script := fmt.Sprintf("cd %s && find . -type f ! -name '*%s' -printf '%%s %%P\\n'", Quote(remotePath), partialSuffix)
+	if err := t.Run(ctx, script, nil, &stdout, &stderr); err != nil {
+		return nil, false, commandError(err, &stderr)
+	}
+	files := map[string]int64{}
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		files[fields[1]] = size
+	}
+	return files, true, nil
+}
@@ -0,0 +1,108 @@
+package remote
+
+import (
+	"cli/api"
+	"cli/history"
+	"cli/profile"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/viper"
+)
+
+const proxyHost = "ssh.runpod.io"
+
+// Target is the ssh endpoint of a pod.
+type Target struct {
+	PodId string
+	User  string
+	Host  string
+	Port  int
+	// Proxy targets go through the runpod ssh proxy, which only supports
+	// interactive sessions.
+	Proxy bool
+}
+
+// Resolve finds the ssh endpoint of a running pod from its runtime ports,
+// falling back to the runpod ssh proxy when port 22 is not exposed publicly.
+func Resolve(p *api.Pod) (*Target, error) {
+	if p.Runtime == nil {
+		return nil, fmt.Errorf(`pod "%s" is not running`, p.Id)
+	}
+	for _, port := range p.Runtime.Ports {
+		if port.PrivatePort == 22 && port.IsIpPublic && port.Type == "tcp" {
+			return &Target{PodId: p.Id, User: "root", Host: port.Ip, Port: port.PublicPort}, nil
+		}
+	}
+	if p.Machine != nil && p.Machine.PodHostId != "" {
+		return &Target{PodId: p.Id, User: p.Machine.PodHostId, Host: proxyHost, Port: 22, Proxy: true}, nil
+	}
+	return nil, fmt.Errorf(`pod "%s" has no ssh endpoint; expose 22/tcp`, p.Id)
+}
+
+// SshArgs returns the ssh arguments used to reach the target, excluding the command.
+// A pod's host key is trusted the first time and checked after that. The
+// keys are kept apart from the user's own known_hosts, by pod id, as pods
+// reuse each other's addresses.
+func (t *Target) SshArgs(interactive bool) []string {
+	args := []string{"-o", "StrictHostKeyChecking=no", "-o", "UserKnownHostsFile=/dev/null"}
+	if path, err := knownHosts(); err == nil {
+		args = []string{
+			"-o", "StrictHostKeyChecking=accept-new",
+			"-o", `UserKnownHostsFile="` + path + `"`,
+			"-o", "HostKeyAlias=runpod-" + t.PodId,
+		}
+	}
+	args = append(args, "-o", "LogLevel=ERROR", "-p", strconv.Itoa(t.Port))
+	if key := viper.GetString(profile.Key("sshKey")); key != "" {
+		args = append(args, "-i", key)
+	}
+	if interactive {
+		args = append(args, "-tt")
+	} else {
+		args = append(args, "-o", "BatchMode=yes")
+	}
+	return append(args, t.User+"@"+t.Host)
+}
+
+// knownHosts returns the known_hosts file runpodctl keeps the host keys of
+// pods in, ~/.runpod/known_hosts.
+func knownHosts() (string, error) {
+	dir, err := history.Dir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "known_hosts"), nil
+}
+
+// Command builds the ssh command running command on the target.
+func (t *Target) Command(ctx context.Context, command string, interactive bool) (*exec.Cmd, error) {
+	if t.Proxy && (!interactive || command != "") {
+		return nil, fmt.Errorf(`pod "%s" is only reachable through the ssh proxy, which supports interactive shells only; expose 22/tcp to run commands`, t.PodId)
+	}
+	args := t.SshArgs(interactive)
+	if command != "" {
+		args = append(args, command)
+	}
+	return exec.CommandContext(ctx, "ssh", args...), nil
+}
+
+// Run runs command on the target, wiring the given streams.
+func (t *Target) Run(ctx context.Context, command string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	cmd, err := t.Command(ctx, command, false)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
@@ -0,0 +1,86 @@
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Transfer copies srcPath on src to dstPath on dst directly between the two
+// pods: runpodctl send runs on the source and runpodctl receive on the
+// destination, so the data never passes through this machine. As with cp, a
+// dstPath ending in "/" or naming an existing directory receives the file or
+// folder inside it.
+func Transfer(ctx context.Context, src *Target, srcPath string, dst *Target, dstPath string, progress io.Writer) error {
+	srcPath = strings.TrimSuffix(srcPath, "/")
+	base := path.Base(srcPath)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	send, err := src.Command(ctx, fmt.Sprintf("cd %s && runpodctl send %s", Quote(path.Dir(srcPath)), Quote(base)), false)
+	if err != nil {
+		return err
+	}
+	// stdout is copied by exec and stderr by the scanner below, each on its
+	// own goroutine, so they need a buffer each
+	var sendOut, sendErr bytes.Buffer
+	send.Stdout = &sendOut
+	pipe, err := send.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := send.Start(); err != nil {
+		return err
+	}
+	codes := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(pipe)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if code := strings.TrimPrefix(line, "Code is: "); code != line {
+				codes <- strings.TrimSpace(code)
+			} else {
+				fmt.Fprintln(&sendErr, line)
+			}
+		}
+		close(codes)
+	}()
+	code, ok := <-codes
+	if !ok {
+		send.Wait() //nolint
+		return fmt.Errorf("send from pod %s failed: %s", src.PodId, sendOutput(&sendOut, &sendErr))
+	}
+	fmt.Fprintf(progress, "sending %s from pod %s to pod %s\n", srcPath, src.PodId, dst.PodId)
+
+	dstDir := path.Dir(dstPath)
+	if strings.HasSuffix(dstPath, "/") {
+		dstDir = dstPath
+	}
+	// files are received next to their destination, so moving them into
+	// place is a rename on the same filesystem rather than a second copy
+	script := fmt.Sprintf(
+		`set -e; mkdir -p %s; tmp=$(mktemp -d -p %s .runpodctl-receive.XXXXXX); trap 'rm -rf "$tmp"' EXIT; cd "$tmp"; runpodctl receive %s; test -e %s; mv "$tmp"/%s %s`,
+		Quote(dstDir), Quote(dstDir), Quote(code), Quote(base), Quote(base), Quote(dstPath),
+	)
+	var recvOut bytes.Buffer
+	if err := dst.Run(ctx, script, nil, &recvOut, &recvOut); err != nil {
+		return fmt.Errorf("receive on pod %s failed: %w: %s", dst.PodId, err, strings.TrimSpace(recvOut.String()))
+	}
+	// the scanner must be done reading stderr before Wait closes the pipe
+	for range codes {
+	}
+	if err := send.Wait(); err != nil {
+		return fmt.Errorf("send from pod %s failed: %w: %s", src.PodId, err, sendOutput(&sendOut, &sendErr))
+	}
+	return nil
+}
+
+// sendOutput is what runpodctl send printed, for errors. It is only read
+// once send has exited and both streams are done.
+func sendOutput(stdout *bytes.Buffer, stderr *bytes.Buffer) string {
+	return strings.TrimSpace(stdout.String() + "\n" + stderr.String())
+}
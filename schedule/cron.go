@@ -0,0 +1,165 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed five-field cron expression: minute, hour, day of month,
+// month and day of week. Fields take *, numbers, ranges (1-5), steps (*/15,
+// 8-18/2) and comma-separated lists of those; months and weekdays also take
+// names such as jan or mon. Sunday is 0 or 7.
+type Cron struct {
+	expr   string
+	minute []bool
+	hour   []bool
+	dom    []bool
+	month  []bool
+	dow    []bool
+	anyDom bool
+	anyDow bool
+}
+
+var monthNames = []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseCron parses a five-field cron expression.
+func ParseCron(expr string) (*Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: want 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+	c := &Cron{expr: expr}
+	var err error
+	if c.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid minute in %q: %w", expr, err)
+	}
+	if c.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid hour in %q: %w", expr, err)
+	}
+	if c.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid day of month in %q: %w", expr, err)
+	}
+	if c.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("invalid month in %q: %w", expr, err)
+	}
+	if c.dow, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, fmt.Errorf("invalid day of week in %q: %w", expr, err)
+	}
+	if c.dow[7] {
+		c.dow[0] = true
+	}
+	c.anyDom = strings.HasPrefix(fields[2], "*")
+	c.anyDow = strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// Matches reports whether t falls in a minute the expression selects.
+func (c *Cron) Matches(t time.Time) bool {
+	return c.minute[t.Minute()] && c.hour[t.Hour()] && c.month[int(t.Month())] && c.day(t)
+}
+
+// Next returns the first selected minute after t, in t's location, or the
+// zero time when none comes within five years, e.g. for February 30. Times
+// skipped by a daylight saving change do not occur.
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(5, 0, 0)
+	for t.Before(end) {
+		switch {
+		case !c.month[int(t.Month())]:
+			t = later(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location()))
+		case !c.day(t):
+			t = later(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location()))
+		case !c.hour[t.Hour()]:
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case !c.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// later returns next, or the hour after t when a daylight saving change
+// made time.Date normalize next to a time not after t.
+func later(t time.Time, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return t.Add(time.Hour)
+}
+
+// day reports whether the day of t is selected. As in cron, when both the
+// day of month and the day of week are restricted, either one selects it.
+func (c *Cron) day(t time.Time) bool {
+	dom, dow := c.dom[t.Day()], c.dow[int(t.Weekday())]
+	if c.anyDom || c.anyDow {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// parseField parses one field into the set of values from min to max it
+// selects. names, when given, are accepted for the values at their index.
+func parseField(field string, min int, max int, names []string) ([]bool, error) {
+	set := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:i]
+		}
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = value(bounds[0], names); err != nil {
+				return nil, err
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = value(bounds[1], names); err != nil {
+					return nil, err
+				}
+				if max == 7 && hi == 0 {
+					// fri-sun
+					hi = 7
+				}
+			} else if step > 1 {
+				// 5/15 means from 5 to the end in steps of 15
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+func value(s string, names []string) (int, error) {
+	for i, name := range names {
+		if name != "" && strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
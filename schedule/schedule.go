@@ -0,0 +1,142 @@
+package schedule
+
+import (
+	"cli/profile"
+	"cli/store"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Schedule starts and stops a pod at the times of two cron expressions,
+// from the schedules section of the config. Either may be empty. The
+// expressions are read in Timezone, or in the local time of the machine
+// running the scheduler when it is empty. Profile is the config profile
+// it was added under.
+type Schedule struct {
+	PodId    string
+	Start    string
+	Stop     string
+	Timezone string
+	Profile  string
+}
+
+// List returns the schedules sorted by pod id.
+func List() []*Schedule {
+	all := viper.GetStringMap("schedules")
+	ids := make([]string, 0, len(all))
+	for id := range all {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	schedules := make([]*Schedule, 0, len(ids))
+	for _, id := range ids {
+		s, _ := Get(id)
+		schedules = append(schedules, s)
+	}
+	return schedules
+}
+
+// Get returns the schedule of a pod.
+func Get(podId string) (*Schedule, error) {
+	podId = strings.ToLower(podId)
+	entry, ok := viper.GetStringMap("schedules")[podId].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf(`pod "%s" has no schedule; add one with runpodctl schedule add`, podId)
+	}
+	s := &Schedule{PodId: podId}
+	s.Start, _ = entry["start"].(string)
+	s.Stop, _ = entry["stop"].(string)
+	s.Timezone, _ = entry["timezone"].(string)
+	s.Profile, _ = entry["profile"].(string)
+	return s, nil
+}
+
+// Add saves the schedule under the active profile in the config file,
+// replacing the pod's previous one.
+func Add(s *Schedule) error {
+	if s.Start == "" && s.Stop == "" {
+		return errors.New("a schedule needs --start, --stop or both")
+	}
+	if _, _, err := s.Crons(); err != nil {
+		return err
+	}
+	if _, err := s.Location(); err != nil {
+		return err
+	}
+	return store.UpdateConfig(func() error {
+		if old, err := Get(s.PodId); err == nil {
+			if err := old.Check(); err != nil {
+				return err
+			}
+		}
+		s.Profile = profile.Name()
+		all := viper.GetStringMap("schedules")
+		entry := map[string]interface{}{"profile": s.Profile}
+		if s.Start != "" {
+			entry["start"] = s.Start
+		}
+		if s.Stop != "" {
+			entry["stop"] = s.Stop
+		}
+		if s.Timezone != "" {
+			entry["timezone"] = s.Timezone
+		}
+		all[strings.ToLower(s.PodId)] = entry
+		viper.Set("schedules", all)
+		return nil
+	})
+}
+
+// Remove deletes the pod's schedule from the config file.
+func Remove(podId string) error {
+	return store.UpdateConfig(func() error {
+		s, err := Get(podId)
+		if err != nil {
+			return err
+		}
+		if err := s.Check(); err != nil {
+			return err
+		}
+		all := viper.GetStringMap("schedules")
+		delete(all, s.PodId)
+		viper.Set("schedules", all)
+		return nil
+	})
+}
+
+// Check refuses a schedule added under another profile than the active one.
+func (s *Schedule) Check() error {
+	return profile.Check(fmt.Sprintf(`the schedule of pod "%s"`, s.PodId), s.Profile)
+}
+
+// Crons parses the start and stop expressions; a missing one is nil.
+func (s *Schedule) Crons() (start *Cron, stop *Cron, err error) {
+	if s.Start != "" {
+		if start, err = ParseCron(s.Start); err != nil {
+			return nil, nil, err
+		}
+	}
+	if s.Stop != "" {
+		if stop, err = ParseCron(s.Stop); err != nil {
+			return nil, nil, err
+		}
+	}
+	return start, stop, nil
+}
+
+// Location is the timezone the expressions are read in.
+func (s *Schedule) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q; use an IANA name such as Europe/Berlin", s.Timezone)
+	}
+	return loc, nil
+}
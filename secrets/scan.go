@@ -0,0 +1,99 @@
+package secrets
+
+import (
+	"cli/api"
+	"cli/profile"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	PolicyWarn  = "warn"
+	PolicyBlock = "block"
+	PolicyOff   = "off"
+)
+
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+var valueRules = []rule{
+	{"aws access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"github token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"hugging face token", regexp.MustCompile(`\bhf_[A-Za-z0-9]{30,}\b`)},
+	{"openai key", regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}\b`)},
+	{"slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]+`)},
+}
+
+// sensitiveKey matches keys with a credential word between separators, so
+// HF_TOKEN and DB_PASSWORD match but MAX_TOKENS and TOKENIZER_NAME do not.
+var sensitiveKey = regexp.MustCompile(`(?i)(^|[_.-])(secrets?|token|passw(or)?d|api_?key|access_?key|private_?key|credentials?)([_.-]|$)`)
+
+// Finding is an env var that looks like a credential passed in plaintext.
+type Finding struct {
+	Key  string
+	Rule string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s looks like a %s", f.Key, f.Rule)
+}
+
+// Scan returns the env vars whose values look like plaintext credentials.
+// Values that reference a RunPod secret are never reported.
+func Scan(env []*api.PodEnv) (findings []Finding) {
+	for _, e := range env {
+		if e == nil || isReference(e.Value) {
+			continue
+		}
+		matched := false
+		for _, r := range valueRules {
+			if r.pattern.MatchString(e.Value) {
+				findings = append(findings, Finding{Key: e.Key, Rule: r.name})
+				matched = true
+				break
+			}
+		}
+		if !matched && sensitiveKey.MatchString(e.Key) && len(e.Value) >= 8 {
+			findings = append(findings, Finding{Key: e.Key, Rule: "credential"})
+		}
+	}
+	return
+}
+
+// Check scans env according to the configured secretScan policy, printing
+// warnings to stderr and returning an error when the policy blocks.
+func Check(env []*api.PodEnv) error {
+	policy := strings.ToLower(viper.GetString(profile.Key("secretScan")))
+	if policy == PolicyOff {
+		return nil
+	}
+	findings := Scan(env)
+	if len(findings) == 0 {
+		return nil
+	}
+	for _, f := range findings {
+		fmt.Fprintf(os.Stderr, "warning: env %s in plaintext; use a runpod secret instead\n", f)
+	}
+	if policy == PolicyBlock {
+		return fmt.Errorf("%d plaintext secret(s) found in env; blocked by secretScan policy", len(findings))
+	}
+	return nil
+}
+
+// Reference is the env value that makes RunPod inject the named secret, so
+// the secret itself is never sent with the pod.
+func Reference(name string) string {
+	return "{{ RUNPOD_SECRET_" + name + " }}"
+}
+
+func isReference(value string) bool {
+	return strings.Contains(value, "{{") && strings.Contains(value, "RUNPOD_SECRET_")
+}
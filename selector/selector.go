@@ -0,0 +1,99 @@
+package selector
+
+import (
+	"cli/api"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Selector matches pods by comma separated key=value or key!=value terms.
+// Keys name, id, status, gpu and image match pod fields; any other key
+// matches the pod env var of the same name. Values may use glob patterns.
+type Selector struct {
+	terms []term
+}
+
+type term struct {
+	key    string
+	value  string
+	negate bool
+}
+
+func Parse(s string) (*Selector, error) {
+	sel := &Selector{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		t := term{}
+		if i := strings.Index(part, "!="); i > 0 {
+			t.key, t.value, t.negate = part[:i], part[i+2:], true
+		} else if i := strings.Index(part, "="); i > 0 {
+			t.key, t.value = part[:i], part[i+1:]
+		} else {
+			return nil, fmt.Errorf("invalid selector term %q, expected key=value", part)
+		}
+		if _, err := path.Match(t.value, ""); err != nil {
+			return nil, fmt.Errorf("invalid selector pattern %q: %w", t.value, err)
+		}
+		sel.terms = append(sel.terms, t)
+	}
+	if len(sel.terms) == 0 {
+		return nil, fmt.Errorf("empty selector")
+	}
+	return sel, nil
+}
+
+func (s *Selector) Matches(p *api.Pod) bool {
+	for _, t := range s.terms {
+		values, ok := fieldValues(p, t.key)
+		matched := false
+		for _, v := range values {
+			if m, _ := path.Match(t.value, v); m {
+				matched = true
+				break
+			}
+		}
+		if t.negate == (ok && matched) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the pods matching the selector.
+func (s *Selector) Filter(pods []*api.Pod) (out []*api.Pod) {
+	for _, p := range pods {
+		if s.Matches(p) {
+			out = append(out, p)
+		}
+	}
+	return
+}
+
+func fieldValues(p *api.Pod, key string) ([]string, bool) {
+	switch key {
+	case "name":
+		return []string{p.Name}, true
+	case "id":
+		return []string{p.Id}, true
+	case "status":
+		return []string{p.DesiredStatus}, true
+	case "image":
+		return []string{p.ImageName}, true
+	case "gpu":
+		if p.Machine == nil {
+			return nil, false
+		}
+		return []string{p.Machine.GpuTypeId, p.Machine.GpuDisplayName}, true
+	}
+	for _, e := range p.Env {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) == 2 && kv[0] == key {
+			return []string{kv[1]}, true
+		}
+	}
+	return nil, false
+}
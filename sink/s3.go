@@ -0,0 +1,112 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// putS3 uploads data to s3://bucket/key with a SigV4 signed PUT. It reads
+// the standard AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN
+// and AWS_REGION variables; AWS_ENDPOINT_URL points it at S3-compatible
+// storage, which is addressed path-style.
+func putS3(ctx context.Context, dest string, contentType string, data []byte, now time.Time) error {
+	bucketKey := strings.SplitN(strings.TrimPrefix(dest, "s3://"), "/", 2)
+	if len(bucketKey) != 2 || bucketKey[0] == "" || bucketKey[1] == "" {
+		return fmt.Errorf("invalid sink %s: use s3://bucket/key", dest)
+	}
+	bucket, key := bucketKey[0], bucketKey[1]
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return fmt.Errorf("s3 sink needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	var u *url.URL
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+		var err error
+		if u, err = url.Parse(strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/"); err != nil {
+			return err
+		}
+	} else {
+		u = &url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket, region), Path: "/"}
+	}
+	u.Path += key
+	u.RawPath = escapePath(u.Path)
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", u.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	payloadHash := sha256Hex(data)
+	amzDate := now.Format("20060102T150405Z")
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", amzDate)
+	signed := []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+		signed = append(signed, "x-amz-security-token")
+	}
+
+	var headers strings.Builder
+	for _, h := range signed {
+		value := req.Header.Get(h)
+		if h == "host" {
+			value = u.Host
+		}
+		fmt.Fprintf(&headers, "%s:%s\n", h, strings.TrimSpace(value))
+	}
+	signedHeaders := strings.Join(signed, ";")
+	canonical := strings.Join([]string{"PUT", u.RawPath, "", headers.String(), signedHeaders, payloadHash}, "\n")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", now.Format("20060102"), region)
+	toSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonical))}, "\n")
+
+	signingKey := []byte("AWS4" + secretKey)
+	for _, part := range []string{now.Format("20060102"), region, "s3", "aws4_request"} {
+		signingKey = hmacSha256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSha256(signingKey, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+	return send(req)
+}
+
+// escapePath percent-encodes an object path the way SigV4 expects: every
+// byte but unreserved characters and the / separators.
+func escapePath(path string) string {
+	var sb strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,96 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Expand replaces {date} and {time} in dest with the current UTC date and
+// time so scheduled reports do not overwrite each other.
+func Expand(dest string) string {
+	now := time.Now().UTC()
+	return strings.NewReplacer("{date}", now.Format("2006-01-02"), "{time}", now.Format("20060102T150405Z")).Replace(dest)
+}
+
+// Write delivers a finished report to dest: a file path (or file://path), an
+// http(s) url that receives it in a POST, or s3://bucket/key on S3 or
+// S3-compatible storage.
+func Write(ctx context.Context, dest string, contentType string, data []byte) error {
+	switch {
+	case strings.HasPrefix(dest, "http://") || strings.HasPrefix(dest, "https://"):
+		return post(ctx, dest, contentType, data)
+	case strings.HasPrefix(dest, "s3://"):
+		return putS3(ctx, dest, contentType, data, time.Now().UTC())
+	case strings.Contains(dest, "://") && !strings.HasPrefix(dest, "file://"):
+		return fmt.Errorf("unsupported sink %s: use a file path, http(s):// or s3://", dest)
+	}
+	path := strings.TrimPrefix(dest, "file://")
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ContentType names the media type of a report printed with -o output.
+func ContentType(output string) string {
+	switch output {
+	case "json":
+		return "application/json"
+	case "yaml":
+		return "application/yaml"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+func post(ctx context.Context, url string, contentType string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return send(req)
+}
+
+// send runs the request and turns responses other than 2xx into errors.
+func send(req *http.Request) error {
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("%s %s: statuscode %d: %s", req.Method, req.URL.Redacted(), res.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Deliver expands and writes data to every destination, reporting each
+// delivery on stderr. A failed destination does not stop the others.
+func Deliver(ctx context.Context, dests []string, contentType string, data []byte) error {
+	var failed []string
+	for _, dest := range dests {
+		dest = Expand(dest)
+		if err := Write(ctx, dest, contentType, data); err != nil {
+			failed = append(failed, fmt.Sprintf("sink %s: %s", dest, err))
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "report sent to %s\n", dest)
+	}
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package speedtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pings is how many round trips the latency is the median of.
+const pings = 5
+
+// downloadBytes is asked for by a download, which is cut short after the
+// test duration on all but the fastest links.
+const downloadBytes = 1 << 30
+
+// setupTimeout is the time allowed for the pings and for connecting, on top
+// of the download and upload.
+const setupTimeout = 10 * time.Second
+
+var client = &http.Client{}
+
+// Result is what one endpoint measured. Throughputs are in megabits per
+// second, as connections are advertised.
+type Result struct {
+	LatencyMs    float64 `json:"latencyMs"`
+	DownloadMbps float64 `json:"downloadMbps"`
+	UploadMbps   float64 `json:"uploadMbps"`
+}
+
+// Measure tests the speedtest endpoint at url, which answers
+//
+//	GET  <url>/ping                 with an empty body
+//	GET  <url>/download?bytes=<n>   with n bytes
+//	POST <url>/upload               by reading and discarding the body
+//
+// Latency is the median of a few pings over a warm connection, so it leaves
+// out dns, tcp and tls setup. Download and upload each run for duration, and
+// the whole test is given up after twice that plus setupTimeout, as the
+// client has no timeout of its own.
+func Measure(ctx context.Context, url string, duration time.Duration) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*duration+setupTimeout)
+	defer cancel()
+	url = strings.TrimSuffix(url, "/")
+	r := &Result{}
+	var err error
+	if r.LatencyMs, err = latency(ctx, url); err != nil {
+		return nil, fmt.Errorf("ping: %w", err)
+	}
+	if r.DownloadMbps, err = download(ctx, url, duration); err != nil {
+		return nil, fmt.Errorf("download: %w", err)
+	}
+	if r.UploadMbps, err = upload(ctx, url, duration); err != nil {
+		return nil, fmt.Errorf("upload: %w", err)
+	}
+	return r, nil
+}
+
+func latency(ctx context.Context, url string) (float64, error) {
+	var rtts []float64
+	// the first ping opens the connection the others reuse
+	for i := 0; i <= pings; i++ {
+		start := time.Now()
+		if err := get(ctx, url+"/ping", io.Discard); err != nil {
+			return 0, err
+		}
+		if i > 0 {
+			rtts = append(rtts, float64(time.Since(start))/float64(time.Millisecond))
+		}
+	}
+	sort.Float64s(rtts)
+	return rtts[len(rtts)/2], nil
+}
+
+func download(ctx context.Context, url string, duration time.Duration) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+	var n counter
+	start := time.Now()
+	err := get(ctx, fmt.Sprintf("%s/download?bytes=%d", url, downloadBytes), &n)
+	if err != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("no data received in %s", duration)
+	}
+	return mbps(int64(n), time.Since(start)), nil
+}
+
+func upload(ctx context.Context, url string, duration time.Duration) (float64, error) {
+	body := &zeros{until: time.Now().Add(duration)}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url+"/upload", body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	start := time.Now()
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body) //nolint
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("status %s", res.Status)
+	}
+	return mbps(body.n, time.Since(start)), nil
+}
+
+func get(ctx context.Context, url string, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %s", res.Status)
+	}
+	_, err = io.Copy(w, res.Body)
+	return err
+}
+
+func mbps(n int64, elapsed time.Duration) float64 {
+	return float64(n) * 8 / elapsed.Seconds() / 1e6
+}
+
+// counter counts the bytes written to it.
+type counter int64
+
+func (c *counter) Write(p []byte) (int, error) {
+	*c += counter(len(p))
+	return len(p), nil
+}
+
+// zeros is an upload body of zero bytes that ends at until.
+type zeros struct {
+	until time.Time
+	n     int64
+}
+
+func (z *zeros) Read(p []byte) (int, error) {
+	if time.Now().After(z.until) {
+		return 0, io.EOF
+	}
+	for i := range p {
+		p[i] = 0
+	}
+	z.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,117 @@
+package sshkey
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Key is one ssh public key in authorized_keys format.
+type Key struct {
+	Type        string `json:"type"`
+	Fingerprint string `json:"fingerprint"`
+	Comment     string `json:"comment"`
+	Line        string `json:"key"`
+}
+
+// Parse reads one key from an authorized_keys line.
+func Parse(line string) (*Key, error) {
+	line = strings.TrimSpace(line)
+	pub, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh public key: %w", err)
+	}
+	return &Key{
+		Type:        pub.Type(),
+		Fingerprint: ssh.FingerprintSHA256(pub),
+		Comment:     comment,
+		Line:        line,
+	}, nil
+}
+
+// ParseList reads every key in an authorized_keys text. Blank lines and
+// comments are skipped; so are lines that are not keys, so one bad entry
+// saved through the web UI does not hide the rest.
+func ParseList(text string) []*Key {
+	var keys []*Key
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if k, err := Parse(line); err == nil {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Append adds key to an authorized_keys text. The other lines, comments and
+// lines that are not keys included, are kept as they are.
+func Append(text string, key *Key) string {
+	text = strings.TrimRight(text, "\r\n")
+	if strings.TrimSpace(text) == "" {
+		return key.Line
+	}
+	return text + "\n" + key.Line
+}
+
+// Remove drops the line of key from an authorized_keys text, keeping every
+// other line as it is.
+func Remove(text string, key *Key) string {
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) != key.Line {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// ReadFile reads a public key file. A private key path is accepted too when
+// its .pub file sits next to it.
+func ReadFile(path string) (*Key, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, path[2:])
+	}
+	b, err := os.ReadFile(path)
+	if err == nil && strings.Contains(string(b), "PRIVATE KEY") {
+		b, err = os.ReadFile(path + ".pub")
+	}
+	if err != nil {
+		return nil, err
+	}
+	k, err := Parse(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return k, nil
+}
+
+// Default returns the first of the usual public key files that exists.
+func Default() (*Key, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range []string{"id_ed25519.pub", "id_ecdsa.pub", "id_rsa.pub"} {
+		path := filepath.Join(home, ".ssh", name)
+		if _, err := os.Stat(path); err == nil {
+			return ReadFile(path)
+		}
+	}
+	return nil, fmt.Errorf("no public key in ~/.ssh; pass one with --key-file, or create one with ssh-keygen -t ed25519")
+}
+
+// Match reports whether the key is the one named by a fingerprint (with or
+// without the SHA256: prefix) or by its comment.
+func (k *Key) Match(name string) bool {
+	return k.Fingerprint == name || strings.TrimPrefix(k.Fingerprint, "SHA256:") == name || (k.Comment != "" && k.Comment == name)
+}
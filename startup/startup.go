@@ -0,0 +1,163 @@
+package startup
+
+import (
+	"cli/api"
+	"cli/history"
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Action is the history action startup timings are recorded under.
+const Action = "startup"
+
+var (
+	pullLine  = regexp.MustCompile(`(?i)pull|download|extract|digest`)
+	startLine = regexp.MustCompile(`(?i)(create|start)(ing)? container`)
+)
+
+// Measure splits the time from requested to running into queueing, image pull
+// and container start, from the timestamps of the pod's system log. Without
+// the log only the total is known.
+func Measure(ctx context.Context, pod *api.Pod, requested time.Time, running time.Time) *history.Startup {
+	lines, err := api.DefaultClient.GetPodLogs(ctx, &api.PodLogsInput{PodId: pod.Id, Type: api.LogTypeSystem})
+	if err != nil {
+		lines = nil
+	}
+	s := phases(requested, running, lines)
+	if pod.Machine != nil {
+		s.DataCenterId = pod.Machine.DataCenterId
+	}
+	return s
+}
+
+// phases assigns the time before the first pull or container line to
+// queueing, the span of the pull lines to the image pull and the rest to the
+// container start.
+func phases(requested time.Time, running time.Time, lines []*api.PodLogLine) *history.Startup {
+	var pullStart, pullEnd, containerStart time.Time
+	for _, l := range lines {
+		t, err := time.Parse(time.RFC3339Nano, l.Timestamp)
+		if err != nil {
+			continue
+		}
+		switch {
+		case pullLine.MatchString(l.Message):
+			if pullStart.IsZero() {
+				pullStart = t
+			}
+			pullEnd = t
+		case startLine.MatchString(l.Message) && containerStart.IsZero():
+			containerStart = t
+		}
+	}
+	s := &history.Startup{TotalSeconds: seconds(running.Sub(requested))}
+	switch {
+	case !pullStart.IsZero():
+		s.QueueSeconds = seconds(pullStart.Sub(requested))
+		s.PullSeconds = seconds(pullEnd.Sub(pullStart))
+		s.StartSeconds = seconds(running.Sub(pullEnd))
+	case !containerStart.IsZero():
+		// the image was cached on the machine
+		s.QueueSeconds = seconds(containerStart.Sub(requested))
+		s.StartSeconds = seconds(running.Sub(containerStart))
+	}
+	return s
+}
+
+// seconds rounds d to a tenth of a second; clock skew between this machine
+// and runpod can make a phase negative, which is shown as zero.
+func seconds(d time.Duration) float64 {
+	if d < 0 {
+		return 0
+	}
+	return math.Round(d.Seconds()*10) / 10
+}
+
+// Describe summarizes a startup for humans, e.g.
+// "2m10s (queued 10s, image pull 1m50s, container start 10s)".
+func Describe(s *history.Startup) string {
+	total := duration(s.TotalSeconds)
+	if s.QueueSeconds+s.PullSeconds+s.StartSeconds == 0 {
+		return total
+	}
+	return fmt.Sprintf("%s (queued %s, image pull %s, container start %s)", total, duration(s.QueueSeconds), duration(s.PullSeconds), duration(s.StartSeconds))
+}
+
+func duration(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+}
+
+// Group aggregates the startups of one image in one datacenter. Times are
+// in seconds.
+type Group struct {
+	ImageName    string  `json:"imageName"`
+	DataCenterId string  `json:"dataCenterId"`
+	Pods         int     `json:"pods"`
+	MedianTotal  float64 `json:"medianTotal"`
+	P90Total     float64 `json:"p90Total"`
+	MedianQueue  float64 `json:"medianQueue"`
+	MedianPull   float64 `json:"medianPull"`
+	MedianStart  float64 `json:"medianStart"`
+}
+
+// Aggregate groups the startups recorded since by image and datacenter,
+// slowest first.
+func Aggregate(entries []*history.Entry, since time.Time) []*Group {
+	type key struct{ image, dataCenter string }
+	byKey := make(map[key][]*history.Startup)
+	var order []key
+	for _, e := range entries {
+		if e.Action != Action || e.Startup == nil || e.Time.Before(since) {
+			continue
+		}
+		k := key{e.ImageName, e.Startup.DataCenterId}
+		if _, ok := byKey[k]; !ok {
+			order = append(order, k)
+		}
+		byKey[k] = append(byKey[k], e.Startup)
+	}
+
+	groups := make([]*Group, 0, len(order))
+	for _, k := range order {
+		runs := byKey[k]
+		pick := func(f func(*history.Startup) float64) []float64 {
+			v := make([]float64, len(runs))
+			for i, r := range runs {
+				v[i] = f(r)
+			}
+			sort.Float64s(v)
+			return v
+		}
+		total := pick(func(s *history.Startup) float64 { return s.TotalSeconds })
+		groups = append(groups, &Group{
+			ImageName:    k.image,
+			DataCenterId: k.dataCenter,
+			Pods:         len(runs),
+			MedianTotal:  percentile(total, 50),
+			P90Total:     percentile(total, 90),
+			MedianQueue:  percentile(pick(func(s *history.Startup) float64 { return s.QueueSeconds }), 50),
+			MedianPull:   percentile(pick(func(s *history.Startup) float64 { return s.PullSeconds }), 50),
+			MedianStart:  percentile(pick(func(s *history.Startup) float64 { return s.StartSeconds }), 50),
+		})
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].MedianTotal > groups[j].MedianTotal
+	})
+	return groups
+}
+
+// percentile returns the nearest-rank percentile p of sorted values.
+func percentile(sorted []float64, p int) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(float64(p)/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
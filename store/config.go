@@ -0,0 +1,111 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// ReadConfig reads the config file into viper. A missing file is created at
+// path; one that no longer parses, e.g. after a crash or a bad hand edit, is
+// set aside as .corrupt and restored from the backup UpdateConfig keeps.
+func ReadConfig(path string) error {
+	err := viper.ReadInConfig()
+	var notFound viper.ConfigFileNotFoundError
+	var parseErr viper.ConfigParseError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &notFound):
+		if err := viper.WriteConfigAs(path); err != nil {
+			return err
+		}
+		return viper.ReadInConfig()
+	case errors.As(err, &parseErr):
+		file := viper.ConfigFileUsed()
+		if restoreErr := restore(file); restoreErr != nil {
+			return fmt.Errorf("%s is corrupt: %w; %s", file, err, restoreErr)
+		}
+		fmt.Fprintf(os.Stderr, "warning: %s was corrupt (%s); restored the last good copy and kept the corrupt one as %s.corrupt\n", file, err, file)
+		return viper.ReadInConfig()
+	}
+	return err
+}
+
+// restore replaces a corrupt config file with its backup, if that parses.
+func restore(file string) error {
+	bak, err := os.ReadFile(file + ".bak")
+	if err != nil {
+		return fmt.Errorf("no backup to restore: %w", err)
+	}
+	var settings map[string]interface{}
+	if err := yaml.Unmarshal(bak, &settings); err != nil {
+		return fmt.Errorf("the backup %s.bak is corrupt too: %w", file, err)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if corrupt, err := os.ReadFile(file); err == nil {
+		if err := replace(file+".corrupt", corrupt, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return replace(file, bak, info.Mode().Perm())
+}
+
+// UpdateConfig runs fn, which changes settings with viper.Set, and saves the
+// config file. It holds the config's lock throughout and re-reads the file
+// first, so concurrent runpodctl processes do not drop each other's changes.
+// The file is replaced atomically, keeping the previous one as a backup.
+func UpdateConfig(fn func() error) error {
+	path := viper.ConfigFileUsed()
+	unlock, err := Lock(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := viper.ReadInConfig(); err != nil {
+		return err
+	}
+	if fn != nil {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	// WriteConfigAs picks the format from the extension
+	tmp := fmt.Sprintf("%s.%d.tmp.yaml", strings.TrimSuffix(path, ".yaml"), os.Getpid())
+	if err := viper.WriteConfigAs(tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := sync(tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := Backup(path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func sync(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,42 @@
+//go:build !windows
+// +build !windows
+
+package store
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Alive reports whether a process with the pid is running.
+func Alive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
+
+// StartTime tells when the process with the pid started, in a form only
+// meant to be compared, so a process is not mistaken for a later one that
+// reused its pid. It is "" when the start time cannot be read.
+func StartTime(pid int) string {
+	if b, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid)); err == nil {
+		// starttime is field 22; the command name in field 2 may hold spaces
+		s := string(b)
+		fields := strings.Fields(s[strings.LastIndexByte(s, ')')+1:])
+		if len(fields) > 19 {
+			return fields[19]
+		}
+		return ""
+	}
+	out, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
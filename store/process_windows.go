@@ -0,0 +1,41 @@
+//go:build windows
+// +build windows
+
+package store
+
+import (
+	"strconv"
+	"syscall"
+)
+
+const processQueryLimitedInformation = 0x1000
+
+// Alive reports whether a process with the pid is running.
+func Alive(pid int) bool {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == 259 // STILL_ACTIVE
+}
+
+// StartTime tells when the process with the pid started, in a form only
+// meant to be compared, so a process is not mistaken for a later one that
+// reused its pid. It is "" when the start time cannot be read.
+func StartTime(pid int) string {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return ""
+	}
+	defer syscall.CloseHandle(h)
+	var created, exited, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(h, &created, &exited, &kernel, &user); err != nil {
+		return ""
+	}
+	return strconv.FormatInt(created.Nanoseconds(), 10)
+}
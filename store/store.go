@@ -0,0 +1,101 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockTimeout is how long a change waits for another process to finish its own.
+const lockTimeout = 5 * time.Second
+
+// Lock takes an exclusive lock on the file at path, creating it if needed.
+// The lock is held by the open file rather than by the file existing, so
+// the system lets go of it however the process holding it ends, and a
+// lockfile left behind is never mistaken for a held lock.
+func Lock(path string) (unlock func(), err error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		locked, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if locked {
+			return func() {
+				unlockFile(f) //nolint
+				f.Close()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("%s is locked by another runpodctl", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// WriteFile replaces path with data so that readers see either the old or
+// the new content, never a torn write. The previous content is kept as
+// path.bak.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if err := Backup(path); err != nil {
+		return err
+	}
+	return replace(path, data, perm)
+}
+
+// Backup copies path to path.bak, if path exists.
+func Backup(path string) error {
+	old, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return replace(path+".bak", old, info.Mode().Perm())
+}
+
+// replace writes data to a synced temporary file and renames it over path.
+func replace(path string, data []byte, perm os.FileMode) error {
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err := writeSynced(tmp, data, perm); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func writeSynced(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
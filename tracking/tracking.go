@@ -0,0 +1,78 @@
+package tracking
+
+import (
+	"cli/api"
+	"cli/profile"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	WandB  = "wandb"
+	MLflow = "mlflow"
+)
+
+// Env returns the env vars that point a run inside the pod at the
+// experiment tracker configured under tracking.<provider> in the config,
+// and the url where the runs can be found.
+//
+// W&B runs are tagged with the gpu type. The pod id is only known once the
+// pod exists, so it is not a tag; the run can read it from RUNPOD_POD_ID,
+// which every pod sets, and tag itself from inside the pod.
+//
+// The tracker's credentials come from the config rather than the command
+// line, so callers append env after scanning it for plaintext secrets.
+func Env(provider string, gpuType string) (env []*api.PodEnv, url string, err error) {
+	get := func(key string) string {
+		return viper.GetString(profile.Key("tracking." + provider + "." + key))
+	}
+	add := func(key string, value string) {
+		if value != "" {
+			env = append(env, &api.PodEnv{Key: key, Value: value})
+		}
+	}
+	switch provider {
+	case WandB:
+		apiKey, entity, project := get("apiKey"), get("entity"), get("project")
+		if apiKey == "" || project == "" {
+			return nil, "", fmt.Errorf("set tracking.wandb.apiKey and tracking.wandb.project in the config")
+		}
+		add("WANDB_API_KEY", apiKey)
+		add("WANDB_ENTITY", entity)
+		add("WANDB_PROJECT", project)
+		env = append(env, wandbTags(gpuType))
+		base := get("baseUrl")
+		add("WANDB_BASE_URL", base)
+		if base == "" {
+			base = "https://wandb.ai"
+		}
+		if entity != "" {
+			url = fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(base, "/"), entity, project)
+		} else {
+			url = fmt.Sprintf("%s/home", strings.TrimSuffix(base, "/"))
+		}
+	case MLflow:
+		uri, experiment := get("trackingUri"), get("experiment")
+		if uri == "" {
+			return nil, "", fmt.Errorf("set tracking.mlflow.trackingUri in the config")
+		}
+		add("MLFLOW_TRACKING_URI", uri)
+		add("MLFLOW_TRACKING_USERNAME", get("username"))
+		add("MLFLOW_TRACKING_PASSWORD", get("password"))
+		add("MLFLOW_TRACKING_TOKEN", get("token"))
+		add("MLFLOW_EXPERIMENT_NAME", experiment)
+		url = strings.TrimSuffix(uri, "/")
+	default:
+		return nil, "", fmt.Errorf("unknown tracker %q: use wandb or mlflow", provider)
+	}
+	return
+}
+
+// wandbTags tags runs with runpod and the gpu type. WANDB_TAGS is a comma
+// separated list, and gpu type ids have no commas.
+func wandbTags(gpuType string) *api.PodEnv {
+	tags := []string{"runpod", gpuType}
+	return &api.PodEnv{Key: "WANDB_TAGS", Value: strings.Join(tags, ",")}
+}
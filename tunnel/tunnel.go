@@ -0,0 +1,249 @@
+package tunnel
+
+import (
+	"cli/api"
+	"cli/remote"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	ViaTcp   = "tcp proxy"
+	ViaSsh   = "ssh"
+	ViaHttps = "https proxy"
+)
+
+// Forward maps a local port to a port inside the pod. A Local of 0 is
+// replaced by a free port when Run starts listening.
+type Forward struct {
+	Local  int `json:"local"`
+	Remote int `json:"remote"`
+	// Via is how the port is reached, set by Run.
+	Via string `json:"via"`
+}
+
+// ParseForward parses [localPort:]remotePort; localPort 0 picks a free port.
+func ParseForward(spec string) (*Forward, error) {
+	local, remote := spec, spec
+	if i := strings.Index(spec, ":"); i >= 0 {
+		local, remote = spec[:i], spec[i+1:]
+	}
+	l, err := strconv.Atoi(local)
+	if err != nil || l < 0 || l > 65535 {
+		return nil, fmt.Errorf("invalid local port in %q", spec)
+	}
+	r, err := strconv.Atoi(remote)
+	if err != nil || r <= 0 || r > 65535 {
+		return nil, fmt.Errorf("invalid remote port in %q", spec)
+	}
+	return &Forward{Local: l, Remote: r}, nil
+}
+
+// Run listens on address for each forward and relays connections to the pod
+// until ctx is done. A port exposed publicly as tcp is relayed straight to its
+// public address; any other port goes through an ssh tunnel, and http ports
+// fall back to the runpod https proxy when the pod has no direct ssh. ready is
+// called once every forward is listening.
+func Run(ctx context.Context, pod *api.Pod, address string, forwards []*Forward, ready func()) error {
+	if pod.Runtime == nil {
+		return fmt.Errorf(`pod "%s" is not running`, pod.Id)
+	}
+	target, sshErr := remote.Resolve(pod)
+	if sshErr == nil && target.Proxy {
+		sshErr = fmt.Errorf(`pod "%s" is only reachable through the ssh proxy, which does not forward ports; expose 22/tcp`, pod.Id)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	listeners := make([]net.Listener, len(forwards))
+	defer func() {
+		for _, ln := range listeners {
+			if ln != nil {
+				ln.Close()
+			}
+		}
+	}()
+	for i, f := range forwards {
+		switch {
+		case publicAddress(pod, f.Remote) != "":
+			f.Via = ViaTcp
+		case sshErr == nil:
+			f.Via = ViaSsh
+		case exposesHttp(pod, f.Remote):
+			f.Via = ViaHttps
+		default:
+			return fmt.Errorf("port %d: %w", f.Remote, sshErr)
+		}
+		ln, err := listen(address, f)
+		if err != nil {
+			return err
+		}
+		listeners[i] = ln
+	}
+
+	errc := make(chan error, len(forwards)+1)
+	var sshForwards []*Forward
+	for i, f := range forwards {
+		if f.Via == ViaSsh {
+			// ssh binds the port itself, so give it up just before starting ssh.
+			listeners[i].Close()
+			listeners[i] = nil
+			sshForwards = append(sshForwards, f)
+			continue
+		}
+		go func(f *Forward, ln net.Listener) {
+			if f.Via == ViaTcp {
+				errc <- relay(ctx, ln, publicAddress(pod, f.Remote))
+			} else {
+				errc <- proxyHttps(ctx, ln, fmt.Sprintf("%s-%d.proxy.runpod.net", pod.Id, f.Remote))
+			}
+		}(f, listeners[i])
+		listeners[i] = nil
+	}
+	if len(sshForwards) > 0 {
+		args := []string{"-N", "-o", "ExitOnForwardFailure=yes"}
+		for _, f := range sshForwards {
+			args = append(args, "-L", fmt.Sprintf("%s:%d:localhost:%d", address, f.Local, f.Remote))
+		}
+		ssh := exec.CommandContext(ctx, "ssh", append(args, target.SshArgs(false)...)...)
+		ssh.Stderr = os.Stderr
+		if err := ssh.Start(); err != nil {
+			return err
+		}
+		go func() {
+			err := ssh.Wait()
+			if ctx.Err() == nil {
+				err = fmt.Errorf("ssh tunnel closed: %v", err)
+			} else {
+				err = nil
+			}
+			errc <- err
+		}()
+	}
+	if ready != nil {
+		ready()
+	}
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// listen opens the local port of f, choosing a free one when it is 0. When the
+// port is taken, the error suggests a free port instead.
+func listen(address string, f *Forward) (net.Listener, error) {
+	ln, err := net.Listen("tcp", net.JoinHostPort(address, strconv.Itoa(f.Local)))
+	if err != nil {
+		if errors.Is(err, syscall.EADDRINUSE) {
+			if free := FreePort(address, f.Local+1); free != 0 {
+				return nil, fmt.Errorf("local port %d is in use; use %d:%d, or 0:%d to pick a free port", f.Local, free, f.Remote, f.Remote)
+			}
+			return nil, fmt.Errorf("local port %d is in use; use 0:%d to pick a free port", f.Local, f.Remote)
+		}
+		return nil, err
+	}
+	f.Local = ln.Addr().(*net.TCPAddr).Port
+	return ln, nil
+}
+
+// FreePort returns the first port from start on that can be listened on at
+// address, trying up to 100 ports, or 0 if none is free.
+func FreePort(address string, start int) int {
+	for port := start; port < start+100 && port <= 65535; port++ {
+		ln, err := net.Listen("tcp", net.JoinHostPort(address, strconv.Itoa(port)))
+		if err == nil {
+			ln.Close()
+			return port
+		}
+	}
+	return 0
+}
+
+// publicAddress returns the public ip:port a pod port is exposed on as tcp, if any.
+func publicAddress(pod *api.Pod, port int) string {
+	for _, p := range pod.Runtime.Ports {
+		if p.PrivatePort == port && p.IsIpPublic && p.Type == "tcp" {
+			return net.JoinHostPort(p.Ip, strconv.Itoa(p.PublicPort))
+		}
+	}
+	return ""
+}
+
+func exposesHttp(pod *api.Pod, port int) bool {
+	for _, p := range strings.Split(pod.Ports, ",") {
+		if strings.TrimSpace(p) == fmt.Sprintf("%d/http", port) {
+			return true
+		}
+	}
+	return false
+}
+
+// relay copies every connection accepted on ln to addr and back.
+func relay(ctx context.Context, ln net.Listener, addr string) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	var dialer net.Dialer
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			upstream, err := dialer.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				return
+			}
+			defer upstream.Close()
+			done := make(chan struct{}, 2)
+			go func() {
+				io.Copy(upstream, conn) //nolint
+				done <- struct{}{}
+			}()
+			go func() {
+				io.Copy(conn, upstream) //nolint
+				done <- struct{}{}
+			}()
+			<-done
+		}()
+	}
+}
+
+// proxyHttps serves ln as a reverse proxy to host over https, so local
+// clients can use plain http and websockets.
+func proxyHttps(ctx context.Context, ln net.Listener, host string) error {
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "https", Host: host})
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+		r.Host = host
+	}
+	server := &http.Server{Handler: proxy}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	if err := server.Serve(ln); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
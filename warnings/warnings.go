@@ -0,0 +1,85 @@
+package warnings
+
+import (
+	"cli/api"
+	"cli/format"
+	"cli/history"
+	"cli/profile"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// StuckAfter is how long a pod may stay CREATED before it is reported as stuck.
+const StuckAfter = 15 * time.Minute
+
+// CheckInterval is how often Print lists the pods to check them, so commands
+// run in quick succession do not each make the extra api call.
+const CheckInterval = 5 * time.Minute
+
+// Check returns a line for each pod in a state that needs attention: failed,
+// running without the gpus it asked for, which happens when a resumed pod's
+// machine has none left, or stuck in CREATED.
+func Check(pods []*api.Pod, now time.Time) []string {
+	var lines []string
+	for _, p := range pods {
+		name := fmt.Sprintf("%s (%s)", p.Id, p.Name)
+		switch {
+		case p.DesiredStatus == "FAILED" || p.DesiredStatus == "ERROR":
+			lines = append(lines, fmt.Sprintf("%s: status %s", name, p.DesiredStatus))
+		case p.DesiredStatus == "RUNNING" && p.GpuCount > 0 && p.Runtime != nil && len(p.Runtime.Gpus) == 0:
+			lines = append(lines, fmt.Sprintf("%s: running with no gpus attached; stop it and start it again to get one", name))
+		case p.DesiredStatus == "CREATED" && !p.StatusChangedAt.IsZero() && now.Sub(p.StatusChangedAt) > StuckAfter:
+			lines = append(lines, fmt.Sprintf("%s: created %s and still not running", name, format.Ago(p.StatusChangedAt)))
+		}
+	}
+	return lines
+}
+
+// Print checks every pod in the account and writes a short warning footer
+// for the ones that need attention, unless warnings are off in the config or
+// the pods were checked less than CheckInterval ago. A failure to list pods
+// is not reported; the banner is best effort.
+func Print(ctx context.Context, w io.Writer) {
+	if strings.ToLower(viper.GetString(profile.Key("warnings"))) == "off" || !due() {
+		return
+	}
+	pods, err := api.DefaultClient.GetPods(ctx)
+	if err != nil {
+		return
+	}
+	lines := Check(pods, time.Now())
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nwarning: %d pod(s) need attention:\n", len(lines))
+	for _, line := range lines {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+	fmt.Fprintln(w, "turn these off with: runpodctl config --warnings off")
+}
+
+// due reports whether the pods of the profile were last checked over
+// CheckInterval ago, and if so records that they are checked now.
+func due() bool {
+	dir, err := history.Dir()
+	if err != nil {
+		return true
+	}
+	stamp := filepath.Join(dir, "warnings."+profile.Name()+".checked")
+	if data, err := os.ReadFile(stamp); err == nil {
+		if checked, err := time.Parse(time.RFC3339, string(data)); err == nil && time.Since(checked) < CheckInterval {
+			return false
+		}
+	}
+	if os.MkdirAll(dir, 0700) == nil {
+		os.WriteFile(stamp, []byte(time.Now().UTC().Format(time.RFC3339)), 0600) //nolint
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package watch
+
+import (
+	"cli/api"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PollInterval is how often pod state is polled.
+const PollInterval = 5 * time.Second
+
+// Poll calls fn every interval until it returns done or an error, or ctx ends.
+func Poll(ctx context.Context, interval time.Duration, fn func() (done bool, err error)) error {
+	for {
+		done, err := fn()
+		if done || err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Pods calls fn with the account's pods whenever they change, until ctx ends
+// or fn fails. Changes are pushed by an api subscription when the api has one;
+// otherwise pods are polled every interval. fn gets the error of a failed
+// refresh and decides whether to stop.
+func Pods(ctx context.Context, interval time.Duration, fn func(pods []*api.Pod, err error) error) error {
+	refresh := func() error {
+		pods, err := api.DefaultClient.GetPods(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fn(pods, err)
+	}
+	err := api.DefaultClient.SubscribePods(ctx, refresh)
+	if !errors.Is(err, api.ErrSubscriptionsUnsupported) {
+		return err
+	}
+	return Poll(ctx, interval, func() (bool, error) {
+		return false, refresh()
+	})
+}
+
+// Running waits until the pod is running with its container up. It fails as
+// soon as the pod leaves the CREATED and RUNNING states, or when ctx ends.
+func Running(ctx context.Context, podId string) (*api.Pod, error) {
+	var pod *api.Pod
+	err := Poll(ctx, PollInterval, func() (bool, error) {
+		var err error
+		pod, err = api.DefaultClient.GetPod(ctx, podId)
+		if err != nil {
+			return false, err
+		}
+		if pod.DesiredStatus != "RUNNING" && pod.DesiredStatus != "CREATED" {
+			return false, fmt.Errorf(`pod "%s" did not start; status is %s`, podId, pod.DesiredStatus)
+		}
+		return pod.DesiredStatus == "RUNNING" && pod.Runtime != nil, nil
+	})
+	if err == context.DeadlineExceeded || err == context.Canceled {
+		status := "unknown"
+		if pod != nil {
+			status = pod.DesiredStatus
+		}
+		return nil, fmt.Errorf(`timed out waiting for pod "%s" to run; status is %s`, podId, status)
+	}
+	return pod, err
+}